@@ -0,0 +1,92 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kourier-standalone serves the Envoy config Kourier generates for the ingresses
+// in the given manifests to local gateways, without a Kubernetes cluster, and
+// reloads it whenever the manifests change.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"go.uber.org/zap"
+	"knative.dev/net-kourier/pkg/envoy/server"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/net-kourier/pkg/standalone"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/signals"
+	"knative.dev/pkg/system"
+)
+
+func main() {
+	flags := flag.NewFlagSet("kourier-standalone", flag.ExitOnError)
+	port := flags.Uint("port", 18000, "Port of the xDS management server.")
+	interval := flags.Duration("interval", 2*time.Second, "Interval to check the manifests for changes at.")
+	systemNamespace := flags.String("system-namespace", "knative-serving", "Namespace of the config maps and secrets of the controller, unless set with "+system.NamespaceEnvKey+".")
+	gatewayNamespace := flags.String("gateway-namespace", "kourier-system", "Namespace of the gateways, unless set with "+config.GatewayNamespaceEnv+".")
+	level := flags.String("log-level", "info", "Log level.")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `Usage: kourier-standalone [flags] FILE|DIR...
+
+Reads KIngress, Service, Endpoints, EndpointSlice, Secret, Namespace,
+config-kourier and config-network manifests and serves the generated config over
+xDS. Gateways get the config of the fleet named by their node ID. The manifests
+are reloaded whenever they change.
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(os.Args[1:])
+	if flags.NArg() == 0 || *interval <= 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	setenvDefault(system.NamespaceEnvKey, *systemNamespace)
+	setenvDefault(config.GatewayNamespaceEnv, *gatewayNamespace)
+
+	logger, _ := logging.NewLogger("", *level)
+	defer logger.Sync() //nolint:errcheck
+	ctx := logging.WithLogger(signals.NewContext(), logger)
+
+	xdsServer := server.NewXdsServer(ctx, *port, &xds.CallbackFuncs{})
+	watcher := standalone.NewWatcher(xdsServer, flags.Args()...)
+	if _, err := watcher.Reload(ctx); err != nil {
+		// Rejected ingresses are logged, the others are served.
+		logger.Errorw("Failed to load manifests", zap.Strings("paths", flags.Args()), zap.Error(err))
+		if len(xdsServer.Snapshots()) == 0 {
+			os.Exit(1)
+		}
+	}
+	go watcher.Run(ctx, *interval)
+
+	logger.Info("Starting Management Server on Port ", *port)
+	if err := xdsServer.RunManagementServer(); err != nil {
+		logger.Fatalw("Failed to serve XDS Server", zap.Error(err))
+	}
+}
+
+// setenvDefault sets the given environment variable unless it's set already.
+func setenvDefault(key, value string) {
+	if _, ok := os.LookupEnv(key); !ok {
+		os.Setenv(key, value)
+	}
+}
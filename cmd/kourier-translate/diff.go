@@ -0,0 +1,78 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"knative.dev/net-kourier/pkg/generator"
+)
+
+func diff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	out := addOutputFlags(flags)
+	tokenFile := flags.String("token-file", "", "File holding the bearer token of the debug endpoint.")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `Usage: kourier-translate diff [flags] OLD NEW
+
+Reports the listeners, virtual hosts, routes and clusters that differ between two
+dumped snapshots, per node, and the ingresses they belong to. OLD and NEW are
+files or URLs holding snapshots as printed by kourier-translate or served by the
+/debug/snapshots endpoint of the controller.
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	old, err := readSnapshots(ctx, flags.Arg(0), *tokenFile)
+	if err != nil {
+		return err
+	}
+	new, err := readSnapshots(ctx, flags.Arg(1), *tokenFile)
+	if err != nil {
+		return err
+	}
+
+	diffs := make(map[string]*generator.SnapshotDiff)
+	for node, snapshot := range new {
+		var d *generator.SnapshotDiff
+		if oldSnapshot, ok := old[node]; ok {
+			d = generator.DiffSnapshots(oldSnapshot, snapshot)
+		} else {
+			d = generator.DiffSnapshots(nil, snapshot)
+		}
+		if !d.Empty() {
+			diffs[node] = d
+		}
+	}
+	for node, snapshot := range old {
+		if _, ok := new[node]; !ok {
+			diffs[node] = generator.DiffSnapshots(snapshot, nil)
+		}
+	}
+	return out.print(diffs)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kourier-translate prints the Envoy config Kourier generates for the ingresses
+// in the given manifests, without a Kubernetes cluster, simulates how the gateways
+// route requests with it and compares dumped configs.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"knative.dev/net-kourier/pkg/offline"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+	"sigs.k8s.io/yaml"
+)
+
+func main() {
+	var err error
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "simulate":
+		err = simulate(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "diff":
+		err = diff(os.Args[2:])
+	default:
+		err = translate(os.Args[1:])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func translate(args []string) error {
+	flags := flag.NewFlagSet("kourier-translate", flag.ExitOnError)
+	common := addCommonFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `Usage: kourier-translate [flags] FILE|DIR...
+       kourier-translate simulate [flags] [FILE|DIR...]
+       kourier-translate diff [flags] OLD NEW
+
+Reads KIngress, Service, EndpointSlice, Secret, Namespace, config-kourier and
+config-network manifests and prints the listeners, routes, clusters and
+endpoints generated for every gateway fleet.
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	objects, err := offline.Load(flags.Args()...)
+	if err != nil {
+		return err
+	}
+	snapshots, err := offline.Translate(common.context(), objects)
+	if err != nil {
+		return err
+	}
+	data, err := offline.Marshal(snapshots, common.output)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// outputFlags are the flags of all commands.
+type outputFlags struct {
+	output string
+}
+
+func addOutputFlags(flags *flag.FlagSet) *outputFlags {
+	out := &outputFlags{}
+	out.register(flags)
+	return out
+}
+
+func (o *outputFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.output, "o", "json", "Output format, either json or yaml.")
+}
+
+// commonFlags are the flags of the commands translating ingresses.
+type commonFlags struct {
+	outputFlags
+	systemNamespace  string
+	gatewayNamespace string
+	verbose          bool
+}
+
+func addCommonFlags(flags *flag.FlagSet) *commonFlags {
+	common := &commonFlags{}
+	common.outputFlags.register(flags)
+	flags.StringVar(&common.systemNamespace, "system-namespace", "knative-serving", "Namespace of the config maps and secrets of the controller, unless set with "+system.NamespaceEnvKey+".")
+	flags.StringVar(&common.gatewayNamespace, "gateway-namespace", "kourier-system", "Namespace of the gateways, unless set with "+config.GatewayNamespaceEnv+".")
+	flags.BoolVar(&common.verbose, "v", false, "Log the translation of every ingress.")
+	return common
+}
+
+// context sets up the environment for translating ingresses and returns the
+// context to translate them with.
+func (c *commonFlags) context() context.Context {
+	setenvDefault(system.NamespaceEnvKey, c.systemNamespace)
+	setenvDefault(config.GatewayNamespaceEnv, c.gatewayNamespace)
+
+	level := zapcore.WarnLevel
+	if c.verbose {
+		level = zapcore.DebugLevel
+	}
+	return logging.WithLogger(context.Background(), newLogger(level))
+}
+
+// print writes the given value to stdout in the requested output format.
+func (o *outputFlags) print(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	switch o.output {
+	case "json":
+		data = append(data, '\n')
+	case "yaml":
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", o.output)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// setenvDefault sets the given environment variable unless it's set already.
+func setenvDefault(key, value string) {
+	if _, ok := os.LookupEnv(key); !ok {
+		os.Setenv(key, value)
+	}
+}
+
+// newLogger returns a human readable logger writing to stderr.
+func newLogger(level zapcore.Level) *zap.SugaredLogger {
+	cfg := zap.NewDevelopmentConfig()
+	cfg.Level = zap.NewAtomicLevelAt(level)
+	cfg.DisableStacktrace = true
+	logger, err := cfg.Build()
+	if err != nil {
+		return zap.NewNop().Sugar()
+	}
+	return logger.Sugar()
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"knative.dev/net-kourier/pkg/generator"
+	"knative.dev/net-kourier/pkg/offline"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/net-kourier/pkg/simulator"
+	"sigs.k8s.io/yaml"
+)
+
+func simulate(args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	common := addCommonFlags(flags)
+	snapshotSource := flags.String("snapshot", "", "File or URL of the snapshots to simulate the request with, as printed by kourier-translate or served by the /debug/snapshots endpoint of the controller. If unset, the snapshots are translated from the given manifests.")
+	tokenFile := flags.String("token-file", "", "File holding the bearer token of the debug endpoint.")
+	node := flags.String("node", config.DefaultGatewayFleet, "Node ID of the gateways receiving the request.")
+	port := flags.Uint("port", 8080, "Port of the listener receiving the request.")
+	sni := flags.String("sni", "", "Server name sent in the TLS handshake. Unset for plain text requests.")
+	host := flags.String("host", "", "Host of the request.")
+	path := flags.String("path", "/", "Path of the request, including the query string.")
+	headers := http.Header{}
+	flags.Func("H", "Header of the request, as 'Name: value'. Can be repeated.", func(s string) error {
+		name, value, ok := strings.Cut(s, ":")
+		if !ok {
+			return fmt.Errorf("header %q is not formatted as 'Name: value'", s)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `Usage: kourier-translate simulate [flags] [FILE|DIR...]
+
+Reports the listener, virtual host and route matching a request, the clusters it
+is balanced to, its header mutations and whether ext_authz checks it.
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if *host == "" || *port > 65535 || (*snapshotSource == "") == (flags.NArg() == 0) {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx := common.context()
+	var snapshots map[string]*cache.Snapshot
+	var err error
+	if *snapshotSource != "" {
+		snapshots, err = readSnapshots(ctx, *snapshotSource, *tokenFile)
+	} else {
+		var objects *offline.Objects
+		if objects, err = offline.Load(flags.Args()...); err == nil {
+			snapshots, err = offline.Translate(ctx, objects)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	snapshot, ok := snapshots[*node]
+	if !ok {
+		return fmt.Errorf("no snapshot for node %q, known nodes are %v", *node, slices.Sorted(maps.Keys(snapshots)))
+	}
+
+	result, err := simulator.Simulate(snapshot, simulator.Request{
+		Port:    uint32(*port),
+		SNI:     *sni,
+		Host:    *host,
+		Path:    *path,
+		Headers: headers,
+	})
+	if err != nil && !errors.Is(err, simulator.ErrNoMatch) {
+		return err
+	}
+	if printErr := common.print(result); printErr != nil {
+		return printErr
+	}
+	return err
+}
+
+// readSnapshots reads the dumped snapshots from the given file or URL.
+func readSnapshots(ctx context.Context, source, tokenFile string) (map[string]*cache.Snapshot, error) {
+	var data []byte
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		if tokenFile != "" {
+			token, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to get %s: %s: %s", source, resp.Status, strings.TrimSpace(string(data)))
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(source); err != nil {
+			return nil, err
+		}
+	}
+
+	var dumps map[string]*generator.SnapshotDump
+	// YAML is a superset of JSON, so dumps printed in either format are read.
+	if err := yaml.Unmarshal(data, &dumps); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	snapshots := make(map[string]*cache.Snapshot, len(dumps))
+	for node, dump := range dumps {
+		snapshot, err := dump.Snapshot()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot of %s: %w", node, err)
+		}
+		snapshots[node] = snapshot
+	}
+	return snapshots, nil
+}
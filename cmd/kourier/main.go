@@ -17,6 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"context"
+
 	"knative.dev/net-kourier/pkg/reconciler/informerfiltering"
 	kourierIngressController "knative.dev/net-kourier/pkg/reconciler/ingress"
 	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
@@ -27,7 +29,14 @@ import (
 )
 
 func main() {
-	ctx := informerfiltering.GetContextWithFilteringLabelSelector(signals.NewContext())
+	ctx, cancel := context.WithCancel(signals.NewContext())
+	ctx = informerfiltering.GetContextWithFilteringLabelSelector(ctx)
 	ctx = sharedmain.WithHealthProbesDisabled(ctx)
+	ctx, waitForServers := kourierIngressController.WithServerShutdown(ctx)
 	sharedmain.MainWithContext(ctx, config.ControllerName, kourierIngressController.NewController)
+
+	// The controllers might also have stopped on an error. Either way, the gateways
+	// are drained from the management server before exiting.
+	cancel()
+	waitForServers()
 }
@@ -24,6 +24,7 @@ import (
 
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	httpOptions "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
@@ -67,3 +68,25 @@ func NewCluster(
 
 	return cluster
 }
+
+// NewEDSCluster generates a new v3.Cluster whose endpoints are discovered via EDS
+// over the aggregated stream. The endpoints have to be published separately as a
+// ClusterLoadAssignment with the same name as the cluster.
+func NewEDSCluster(
+	name string,
+	connectTimeout time.Duration,
+	isHTTP2 bool, transportSocket *envoycorev3.TransportSocket,
+) *envoyclusterv3.Cluster {
+	cluster := NewCluster(name, connectTimeout, nil, isHTTP2, transportSocket, envoyclusterv3.Cluster_EDS)
+	cluster.LoadAssignment = nil
+	cluster.EdsClusterConfig = &envoyclusterv3.Cluster_EdsClusterConfig{
+		EdsConfig: &envoycorev3.ConfigSource{
+			ResourceApiVersion: resource.DefaultAPIVersion,
+			ConfigSourceSpecifier: &envoycorev3.ConfigSource_Ads{
+				Ads: &envoycorev3.AggregatedConfigSource{},
+			},
+		},
+	}
+
+	return cluster
+}
@@ -45,3 +45,15 @@ func TestNewCluster(t *testing.T) {
 	c = NewCluster(name, connectTimeout, endpoints, false, nil, v3Cluster.Cluster_STATIC)
 	assert.Assert(t, c.TypedExtensionProtocolOptions["envoy.extensions.upstreams.http.v3.HttpProtocolOptions"] == nil)
 }
+
+func TestNewEDSCluster(t *testing.T) {
+	name := "myTestCluster_12345"
+	connectTimeout := 5 * time.Second
+
+	c := NewEDSCluster(name, connectTimeout, true, nil)
+	assert.Equal(t, c.GetName(), name)
+	assert.Equal(t, c.GetType(), v3Cluster.Cluster_EDS)
+	assert.Assert(t, c.GetLoadAssignment() == nil)
+	assert.Assert(t, c.GetEdsClusterConfig().GetEdsConfig().GetAds() != nil)
+	assert.Assert(t, c.TypedExtensionProtocolOptions["envoy.extensions.upstreams.http.v3.HttpProtocolOptions"] != nil)
+}
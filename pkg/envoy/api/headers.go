@@ -17,6 +17,9 @@ limitations under the License.
 package envoy
 
 import (
+	"maps"
+	"slices"
+
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 )
 
@@ -26,12 +29,13 @@ func headersToAdd(headers map[string]string) []*core.HeaderValueOption {
 		return nil
 	}
 
+	// Sort by name for deterministic Envoy configuration
 	res := make([]*core.HeaderValueOption, 0, len(headers))
-	for headerName, headerVal := range headers {
+	for _, headerName := range slices.Sorted(maps.Keys(headers)) {
 		res = append(res, &core.HeaderValueOption{
 			Header: &core.HeaderValue{
 				Key:   headerName,
-				Value: headerVal,
+				Value: headers[headerName],
 			},
 			// In Knative Serving, headers are set instead of appended.
 			// Ref: https://github.com/knative/serving/pull/6366
@@ -42,3 +42,14 @@ func NewLBEndpoint(ip string, port uint32) *endpoint.LbEndpoint {
 		},
 	}
 }
+
+// NewClusterLoadAssignment creates a ClusterLoadAssignment for the given cluster,
+// placing all the given endpoints into a single locality.
+func NewClusterLoadAssignment(clusterName string, endpoints []*endpoint.LbEndpoint) *endpoint.ClusterLoadAssignment {
+	return &endpoint.ClusterLoadAssignment{
+		ClusterName: clusterName,
+		Endpoints: []*endpoint.LocalityLbEndpoints{{
+			LbEndpoints: endpoints,
+		}},
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	v3Endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	"google.golang.org/protobuf/testing/protocmp"
 	"gotest.tools/v3/assert"
 )
 
@@ -35,3 +36,15 @@ func TestNewLBEndpoint(t *testing.T) {
 	assert.Equal(t, ip, socketAddress.Address)
 	assert.Equal(t, port, socketAddress.PortSpecifier.(*core.SocketAddress_PortValue).PortValue)
 }
+
+func TestNewClusterLoadAssignment(t *testing.T) {
+	endpoints := []*v3Endpoint.LbEndpoint{
+		NewLBEndpoint("127.0.0.1", 8080),
+		NewLBEndpoint("127.0.0.2", 8080),
+	}
+
+	cla := NewClusterLoadAssignment("myTestCluster", endpoints)
+
+	assert.Equal(t, cla.GetClusterName(), "myTestCluster")
+	assert.DeepEqual(t, cla.GetEndpoints()[0].GetLbEndpoints(), endpoints, protocmp.Transform())
+}
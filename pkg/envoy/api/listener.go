@@ -102,7 +102,8 @@ func NewHTTPSListener(port uint32, filterChain []*listener.FilterChain, enablePr
 	}, nil
 }
 
-// CreateFilterChainFromCertificateAndPrivateKey creates a new filter chain from a certificate and a private key
+// CreateFilterChainFromCertificateAndPrivateKey creates a new filter chain from a certificate and a private key.
+// The filter chain refers to the certificate via SDS, so it has to be published via NewSecret too.
 func CreateFilterChainFromCertificateAndPrivateKey(
 	manager *hcm.HttpConnectionManager,
 	cert *Certificate,
@@ -112,11 +113,7 @@ func CreateFilterChainFromCertificateAndPrivateKey(
 		return nil, err
 	}
 
-	tlsContext, err := cert.createTLSContext()
-	if err != nil {
-		return nil, err
-	}
-	tlsAny, err := anypb.New(tlsContext)
+	tlsAny, err := anypb.New(cert.createTLSContext())
 	if err != nil {
 		return nil, err
 	}
@@ -140,6 +137,13 @@ func NewHTTPSListenerWithSNI(manager *hcm.HttpConnectionManager, port uint32, sn
 		return nil, err
 	}
 
+	return NewHTTPSListenerWithSNIFilterChains(port, filterChains, kourierConfig)
+}
+
+// NewHTTPSListenerWithSNIFilterChains creates a new Listener at the given port that
+// selects one of the given filter chains by SNI. The filter chains are usually
+// created by NewSNIFilterChain.
+func NewHTTPSListenerWithSNIFilterChains(port uint32, filterChains []*listener.FilterChain, kourierConfig *config.Kourier) (*listener.Listener, error) {
 	var listenerFilter []*listener.ListenerFilter
 
 	// proxy protocol listener filter should be executed before the TLS inspector listener filter.
@@ -215,41 +219,47 @@ func createFilters(manager *hcm.HttpConnectionManager) ([]*listener.Filter, erro
 func createFilterChainsForTLS(manager *hcm.HttpConnectionManager, sniMatches []*SNIMatch, kourierConfig *config.Kourier) ([]*listener.FilterChain, error) {
 	res := make([]*listener.FilterChain, 0, len(sniMatches))
 	for _, sniMatch := range sniMatches {
-		filters, err := createFilters(manager)
+		filterChain, err := NewSNIFilterChain(manager, sniMatch, kourierConfig)
 		if err != nil {
 			return nil, err
 		}
+		res = append(res, filterChain)
+	}
 
-		c := Certificate{Certificate: sniMatch.CertificateChain, PrivateKey: sniMatch.PrivateKey, CipherSuites: sets.List(kourierConfig.CipherSuites)}
+	return res, nil
+}
 
-		tlsContext, err := c.createTLSContext()
-		if err != nil {
-			return nil, err
-		}
-		tlsAny, err := anypb.New(tlsContext)
-		if err != nil {
-			return nil, err
-		}
+// NewSNIFilterChain creates a filter chain backed by the given manager, serving the
+// certificate of the given sniMatch to its hosts.
+func NewSNIFilterChain(manager *hcm.HttpConnectionManager, sniMatch *SNIMatch, kourierConfig *config.Kourier) (*listener.FilterChain, error) {
+	filters, err := createFilters(manager)
+	if err != nil {
+		return nil, err
+	}
 
-		filterChain := listener.FilterChain{
-			FilterChainMatch: &listener.FilterChainMatch{
-				ServerNames: sniMatch.Hosts,
-			},
-			TransportSocket: &core.TransportSocket{
-				Name:       wellknown.TransportSocketTls,
-				ConfigType: &core.TransportSocket_TypedConfig{TypedConfig: tlsAny},
-			},
-			Filters: filters,
-		}
+	c := Certificate{SecretName: SecretName(sniMatch.CertSource, ""), CipherSuites: sets.List(kourierConfig.CipherSuites)}
 
-		res = append(res, &filterChain)
+	tlsAny, err := anypb.New(c.createTLSContext())
+	if err != nil {
+		return nil, err
 	}
 
-	return res, nil
+	return &listener.FilterChain{
+		FilterChainMatch: &listener.FilterChainMatch{
+			ServerNames: sniMatch.Hosts,
+		},
+		TransportSocket: &core.TransportSocket{
+			Name:       wellknown.TransportSocketTls,
+			ConfigType: &core.TransportSocket_TypedConfig{TypedConfig: tlsAny},
+		},
+		Filters: filters,
+	}, nil
 }
 
 // Certificate stores certificate data to generrate TLS context for downstream.
 type Certificate struct {
+	// SecretName is the name of the SDS secret the TLS context refers to.
+	SecretName         string
 	Certificate        []byte
 	PrivateKey         []byte
 	PrivateKeyProvider string
@@ -283,12 +293,9 @@ func (c Certificate) createCryptoMbMessaage() (*anypb.Any, error) {
 	return messageToAny(&config)
 }
 
-func (c Certificate) createTLSContext() (*auth.DownstreamTlsContext, error) {
-	tlsCertificates, err := c.createTLScertificates()
-	if err != nil {
-		return nil, err
-	}
-
+// createTLSContext creates a TLS context that references the certificate via SDS.
+// The certificate itself has to be published separately, see NewSecret.
+func (c Certificate) createTLSContext() *auth.DownstreamTlsContext {
 	return &auth.DownstreamTlsContext{
 		CommonTlsContext: &auth.CommonTlsContext{
 			AlpnProtocols: []string{"h2", "http/1.1"},
@@ -297,40 +304,8 @@ func (c Certificate) createTLSContext() (*auth.DownstreamTlsContext, error) {
 				TlsMinimumProtocolVersion: auth.TlsParameters_TLSv1_2,
 				CipherSuites:              c.CipherSuites,
 			},
-			TlsCertificates: []*auth.TlsCertificate{tlsCertificates},
+			TlsCertificateSdsSecretConfigs: []*auth.SdsSecretConfig{NewSdsSecretConfig(c.SecretName)},
 		},
-	}, nil
-}
-
-func (c Certificate) createTLScertificates() (*auth.TlsCertificate, error) {
-	switch c.PrivateKeyProvider {
-	case "":
-		return &auth.TlsCertificate{
-			CertificateChain: &core.DataSource{
-				Specifier: &core.DataSource_InlineBytes{InlineBytes: c.Certificate},
-			},
-			PrivateKey: &core.DataSource{
-				Specifier: &core.DataSource_InlineBytes{InlineBytes: c.PrivateKey},
-			},
-		}, nil
-	case "cryptomb":
-		msg, err := c.createCryptoMbMessaage()
-		if err != nil {
-			return nil, err
-		}
-		return &auth.TlsCertificate{
-			CertificateChain: &core.DataSource{
-				Specifier: &core.DataSource_InlineBytes{InlineBytes: c.Certificate},
-			},
-			PrivateKeyProvider: &auth.PrivateKeyProvider{
-				ProviderName: "cryptomb",
-				ConfigType: &auth.PrivateKeyProvider_TypedConfig{
-					TypedConfig: msg,
-				},
-			},
-		}, nil
-	default:
-		return nil, errors.New("Unsupported private key provider: " + c.PrivateKeyProvider)
 	}
 }
 
@@ -32,6 +32,7 @@ import (
 	"google.golang.org/protobuf/types/known/durationpb"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
 )
@@ -141,11 +142,13 @@ func TestNewHTTPListenerWithIPv6AndIPv4(t *testing.T) {
 }
 
 var c = Certificate{
+	SecretName:  "secretns/secretname",
 	Certificate: []byte("some_certificate_chain"),
 	PrivateKey:  []byte("some_private_key"),
 }
 
 var crypto = Certificate{
+	SecretName:         "secretns/secretname/cryptomb",
 	Certificate:        []byte("some_certificate_chain"),
 	PrivateKey:         []byte("some_private_key"),
 	PrivateKeyProvider: "cryptomb",
@@ -173,11 +176,9 @@ func TestNewHTTPSListener(t *testing.T) {
 	assert.Equal(t, uint32(8081), l.Address.GetSocketAddress().GetPortValue())
 
 	// Check that TLS is configured
-	gotCertChain, gotPrivateKey, _, err := getTLSCreds(l.FilterChains[0])
+	gotSecretName, err := getTLSSecretName(l.FilterChains[0])
 	assert.NilError(t, err)
-
-	assert.DeepEqual(t, c.Certificate, gotCertChain)
-	assert.DeepEqual(t, c.PrivateKey, gotPrivateKey)
+	assert.Equal(t, c.SecretName, gotSecretName)
 
 	// check proxy protocol is not configured
 	assert.Check(t, len(l.ListenerFilters) == 0)
@@ -193,16 +194,6 @@ func TestNewHTTPSListenerWithPrivatekeyProvider(t *testing.T) {
 	}
 	manager := NewHTTPConnectionManager("test", &kourierConfig)
 
-	msg, err := c.createCryptoMbMessaage()
-	assert.NilError(t, err)
-
-	fakePrivateKeyProvider := &auth.PrivateKeyProvider{
-		ProviderName: "cryptomb",
-		ConfigType: &auth.PrivateKeyProvider_TypedConfig{
-			TypedConfig: msg,
-		},
-	}
-
 	filterChain, err := CreateFilterChainFromCertificateAndPrivateKey(manager, &crypto)
 	assert.NilError(t, err)
 
@@ -215,12 +206,9 @@ func TestNewHTTPSListenerWithPrivatekeyProvider(t *testing.T) {
 	assert.Equal(t, uint32(8081), l.Address.GetSocketAddress().GetPortValue())
 
 	// Check that TLS is configured
-	gotCertChain, gotPrivateKey, gotProvider, err := getTLSCreds(l.FilterChains[0])
+	gotSecretName, err := getTLSSecretName(l.FilterChains[0])
 	assert.NilError(t, err)
-
-	assert.DeepEqual(t, c.Certificate, gotCertChain)
-	assert.DeepEqual(t, []byte(nil), gotPrivateKey)
-	assert.DeepEqual(t, fakePrivateKeyProvider.String(), gotProvider.String())
+	assert.Equal(t, crypto.SecretName, gotSecretName)
 
 	// check proxy protocol is not configured
 	assert.Check(t, len(l.ListenerFilters) == 0)
@@ -229,10 +217,12 @@ func TestNewHTTPSListenerWithPrivatekeyProvider(t *testing.T) {
 func TestNewHTTPSListenerWithSNIWithCipherSuites(t *testing.T) {
 	sniMatches := []*SNIMatch{{
 		Hosts:            []string{"some_host.com"},
+		CertSource:       types.NamespacedName{Namespace: "secretns", Name: "secretname1"},
 		CertificateChain: []byte("cert1"),
 		PrivateKey:       []byte("key1"),
 	}, {
 		Hosts:            []string{"another_host.com"},
+		CertSource:       types.NamespacedName{Namespace: "secretns", Name: "secretname2"},
 		CertificateChain: []byte("cert2"),
 		PrivateKey:       []byte("key2"),
 	}}
@@ -282,11 +272,9 @@ func TestNewHTTPSListenerWithProxyProtocol(t *testing.T) {
 	assert.Equal(t, uint32(8081), l.Address.GetSocketAddress().GetPortValue())
 
 	// Check that TLS is configured
-	gotCertChain, gotPrivateKey, _, err := getTLSCreds(l.FilterChains[0])
+	gotSecretName, err := getTLSSecretName(l.FilterChains[0])
 	assert.NilError(t, err)
-
-	assert.DeepEqual(t, c.Certificate, gotCertChain)
-	assert.DeepEqual(t, c.PrivateKey, gotPrivateKey)
+	assert.Equal(t, c.SecretName, gotSecretName)
 	// check proxy protocol is configured
 	assertListenerHasProxyProtocolConfigured(t, l.ListenerFilters[0])
 }
@@ -294,10 +282,12 @@ func TestNewHTTPSListenerWithProxyProtocol(t *testing.T) {
 func TestNewHTTPSListenerWithSNI(t *testing.T) {
 	sniMatches := []*SNIMatch{{
 		Hosts:            []string{"some_host.com"},
+		CertSource:       types.NamespacedName{Namespace: "secretns", Name: "secretname1"},
 		CertificateChain: []byte("cert1"),
 		PrivateKey:       []byte("key1"),
 	}, {
 		Hosts:            []string{"another_host.com"},
+		CertSource:       types.NamespacedName{Namespace: "secretns", Name: "secretname2"},
 		CertificateChain: []byte("cert2"),
 		PrivateKey:       []byte("key2"),
 	}}
@@ -328,10 +318,12 @@ func TestNewHTTPSListenerWithSNI(t *testing.T) {
 func TestNewHTTPSListenerWithSNIWithProxyProtocol(t *testing.T) {
 	sniMatches := []*SNIMatch{{
 		Hosts:            []string{"some_host.com"},
+		CertSource:       types.NamespacedName{Namespace: "secretns", Name: "secretname1"},
 		CertificateChain: []byte("cert1"),
 		PrivateKey:       []byte("key1"),
 	}, {
 		Hosts:            []string{"another_host.com"},
+		CertSource:       types.NamespacedName{Namespace: "secretns", Name: "secretname2"},
 		CertificateChain: []byte("cert2"),
 		PrivateKey:       []byte("key2"),
 	}}
@@ -361,10 +353,9 @@ func assertListenerHasSNIMatchConfigured(t *testing.T, listener *envoy_api_v3.Li
 	filterChainFirstSNIMatch := getFilterChainByServerName(listener, match.Hosts)
 	assert.Assert(t, filterChainFirstSNIMatch != nil)
 
-	certChain, privateKey, _, err := getTLSCreds(filterChainFirstSNIMatch)
+	secretName, err := getTLSSecretName(filterChainFirstSNIMatch)
 	assert.NilError(t, err)
-	assert.DeepEqual(t, match.CertificateChain, certChain)
-	assert.DeepEqual(t, match.PrivateKey, privateKey)
+	assert.Equal(t, SecretName(match.CertSource, ""), secretName)
 }
 
 func assertListenerHasProxyProtocolConfigured(t *testing.T, listenerFilter *envoy_api_v3.ListenerFilter) {
@@ -386,21 +377,19 @@ func getFilterChainByServerName(listener *envoy_api_v3.Listener, serverNames []s
 }
 
 // Note: Returns an error when there are multiple certificates
-func getTLSCreds(filterChain *envoy_api_v3.FilterChain) (certChain []byte, privateKey []byte, privateKeyProvider *auth.PrivateKeyProvider, err error) {
+func getTLSSecretName(filterChain *envoy_api_v3.FilterChain) (string, error) {
 	downstreamTLSContext := &auth.DownstreamTlsContext{}
-	err = anypb.UnmarshalTo(filterChain.GetTransportSocket().GetTypedConfig(), downstreamTLSContext, proto.UnmarshalOptions{})
+	err := anypb.UnmarshalTo(filterChain.GetTransportSocket().GetTypedConfig(), downstreamTLSContext, proto.UnmarshalOptions{})
 	if err != nil {
-		return nil, nil, nil, err
+		return "", err
 	}
 
-	if len(downstreamTLSContext.CommonTlsContext.TlsCertificates) > 1 {
-		return nil, nil, nil, errors.New("more than one certificate configured")
+	if len(downstreamTLSContext.CommonTlsContext.TlsCertificateSdsSecretConfigs) > 1 {
+		return "", errors.New("more than one certificate configured")
+	}
+	if len(downstreamTLSContext.CommonTlsContext.TlsCertificates) > 0 {
+		return "", errors.New("certificate configured inline")
 	}
 
-	certs := downstreamTLSContext.CommonTlsContext.TlsCertificates[0]
-	certChain = certs.CertificateChain.GetInlineBytes()
-	privateKey = certs.PrivateKey.GetInlineBytes()
-	privateKeyProvider = certs.PrivateKeyProvider
-
-	return certChain, privateKey, privateKeyProvider, nil
+	return downstreamTLSContext.CommonTlsContext.TlsCertificateSdsSecretConfigs[0].GetName(), nil
 }
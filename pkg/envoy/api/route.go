@@ -17,17 +17,142 @@ limitations under the License.
 package envoy
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	extAuthService "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
+	previousHosts "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/ptypes/any"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
 )
 
+// RouteOption customizes the routes created by NewRoute, NewRedirectRoute and
+// NewRouteExtAuthzDisabled.
+type RouteOption func(*route.Route)
+
+// PathMatchType is how a route matches the path of requests.
+type PathMatchType string
+
+const (
+	// PathMatchPrefix matches paths starting with the path, so "/api" also matches
+	// "/apiary". It's the default.
+	PathMatchPrefix PathMatchType = "Prefix"
+	// PathMatchExact matches the path only.
+	PathMatchExact PathMatchType = "Exact"
+	// PathMatchPathSeparatedPrefix matches the path and the paths below it, so
+	// "/api" matches "/api" and "/api/v1" but not "/apiary".
+	PathMatchPathSeparatedPrefix PathMatchType = "PathSeparatedPrefix"
+	// PathMatchRegex matches paths matching the path as a whole, as an RE2 regular
+	// expression.
+	PathMatchRegex PathMatchType = "Regex"
+)
+
+// WithPathMatch makes the route match the path it was created with the given way,
+// instead of as a prefix.
+func WithPathMatch(typ PathMatchType) RouteOption {
+	return func(r *route.Route) {
+		path := r.GetMatch().GetPrefix()
+		switch typ {
+		case PathMatchExact:
+			r.Match.PathSpecifier = &route.RouteMatch_Path{Path: path}
+		case PathMatchPathSeparatedPrefix:
+			// Envoy rejects a trailing slash, which would not separate anything anyway.
+			// Matching "/" that way is the same as matching it as a prefix.
+			if trimmed := strings.TrimSuffix(path, "/"); trimmed != "" {
+				r.Match.PathSpecifier = &route.RouteMatch_PathSeparatedPrefix{PathSeparatedPrefix: trimmed}
+			}
+		case PathMatchRegex:
+			r.Match.PathSpecifier = &route.RouteMatch_SafeRegex{SafeRegex: &matcher.RegexMatcher{Regex: path}}
+		}
+	}
+}
+
+// WithHeaderMatchers adds the given header matchers to the route, replacing the
+// ones matching the same headers.
+func WithHeaderMatchers(matchers ...*route.HeaderMatcher) RouteOption {
+	return func(r *route.Route) {
+		headers := slices.DeleteFunc(r.GetMatch().GetHeaders(), func(existing *route.HeaderMatcher) bool {
+			return slices.ContainsFunc(matchers, func(m *route.HeaderMatcher) bool {
+				return strings.EqualFold(m.GetName(), existing.GetName())
+			})
+		})
+		r.Match.Headers = append(headers, matchers...)
+	}
+}
+
+// WithQueryParameters adds the given query parameter matchers to the route.
+func WithQueryParameters(matchers ...*route.QueryParameterMatcher) RouteOption {
+	return func(r *route.Route) {
+		r.Match.QueryParameters = append(r.Match.QueryParameters, matchers...)
+	}
+}
+
+// WithRetryPolicy makes the route retry failed requests as configured by the
+// given policy, unless it's disabled. Redirect routes are left alone.
+func WithRetryPolicy(policy *config.RetryPolicy) RouteOption {
+	return func(r *route.Route) {
+		action := r.GetRoute()
+		if action == nil || !policy.Enabled() {
+			return
+		}
+
+		retryPolicy := &route.RetryPolicy{
+			RetryOn:              strings.Join(policy.RetryOn, ","),
+			RetriableStatusCodes: policy.RetriableStatusCodes,
+		}
+		if policy.NumRetries > 0 {
+			retryPolicy.NumRetries = wrapperspb.UInt32(policy.NumRetries)
+		}
+		if policy.PerTryTimeout > 0 {
+			retryPolicy.PerTryTimeout = durationpb.New(policy.PerTryTimeout)
+		}
+		if policy.RetryPreviousHosts {
+			predicate, _ := anypb.New(&previousHosts.PreviousHostsPredicate{})
+			retryPolicy.RetryHostPredicate = []*route.RetryPolicy_RetryHostPredicate{{
+				Name:       "envoy.retry_host_predicates.previous_hosts",
+				ConfigType: &route.RetryPolicy_RetryHostPredicate_TypedConfig{TypedConfig: predicate},
+			}}
+			// Envoy gives up on finding a host not tried yet after that many attempts,
+			// like when all hosts have been tried.
+			retryPolicy.HostSelectionRetryMaxAttempts = 5
+		}
+		action.RetryPolicy = retryPolicy
+	}
+}
+
+// WithTimeout replaces the timeout the route was created with. 0 disables it.
+// Redirect routes are left alone.
+func WithTimeout(timeout time.Duration) RouteOption {
+	return func(r *route.Route) {
+		if action := r.GetRoute(); action != nil {
+			action.Timeout = durationpb.New(timeout)
+		}
+	}
+}
+
+// WithIdleTimeout bounds the time the requests, websocket connections and
+// streams of the route may go without activity. 0 leaves it to the stream idle
+// timeout of the connection manager. Redirect routes are left alone.
+func WithIdleTimeout(timeout time.Duration) RouteOption {
+	return func(r *route.Route) {
+		action := r.GetRoute()
+		if action == nil {
+			return
+		}
+		action.IdleTimeout = nil
+		if timeout > 0 {
+			action.IdleTimeout = durationpb.New(timeout)
+		}
+	}
+}
+
 // NewRoute creates a new Route.
 func NewRoute(name string,
 	headersMatch []*route.HeaderMatcher,
@@ -36,6 +161,7 @@ func NewRoute(name string,
 	routeTimeout time.Duration,
 	headers map[string]string,
 	hostRewrite string,
+	opts ...RouteOption,
 ) *route.Route {
 	routeAction := &route.RouteAction{
 		ClusterSpecifier: &route.RouteAction_WeightedClusters{
@@ -56,7 +182,7 @@ func NewRoute(name string,
 		}
 	}
 
-	return &route.Route{
+	r := &route.Route{
 		Name: name,
 		Match: &route.RouteMatch{
 			PathSpecifier: &route.RouteMatch_Prefix{
@@ -69,13 +195,18 @@ func NewRoute(name string,
 		},
 		RequestHeadersToAdd: headersToAdd(headers),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func NewRedirectRoute(name string,
 	headersMatch []*route.HeaderMatcher,
 	path string,
+	opts ...RouteOption,
 ) *route.Route {
-	return &route.Route{
+	r := &route.Route{
 		Name: name,
 		Match: &route.RouteMatch{
 			PathSpecifier: &route.RouteMatch_Prefix{
@@ -91,6 +222,10 @@ func NewRedirectRoute(name string,
 			},
 		},
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func NewRouteExtAuthzDisabled(name string,
@@ -100,8 +235,9 @@ func NewRouteExtAuthzDisabled(name string,
 	routeTimeout time.Duration,
 	headers map[string]string,
 	hostRewrite string,
+	opts ...RouteOption,
 ) *route.Route {
-	newRoute := NewRoute(name, headersMatch, path, wrs, routeTimeout, headers, hostRewrite)
+	newRoute := NewRoute(name, headersMatch, path, wrs, routeTimeout, headers, hostRewrite, opts...)
 	extAuthzDisabled, _ := anypb.New(&extAuthService.ExtAuthzPerRoute{
 		Override: &extAuthService.ExtAuthzPerRoute_Disabled{
 			Disabled: true,
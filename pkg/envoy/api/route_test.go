@@ -18,10 +18,16 @@ package envoy
 
 import (
 	"testing"
+	"time"
 
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	"gotest.tools/v3/assert"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
 )
 
 func TestNewRouteHeaderMatch(t *testing.T) {
@@ -64,10 +70,10 @@ func TestNewRouteExtAuthzDisabled(t *testing.T) {
 	assert.Assert(t, r.TypedPerFilterConfig[wellknown.HTTPExternalAuthorization] != nil)
 }
 
-// TestRoutesUsePrefixMatching verifies all route creation functions use prefix matching.
-// This is critical because ingress_translator.go sorts routes by prefix path length.
-// If you change the path matching type, you must also update getRoutePrefix() in
-// pkg/generator/ingress_translator.go to extract paths from the new type.
+// TestRoutesUsePrefixMatching verifies all route creation functions use prefix matching
+// unless asked otherwise with WithPathMatch. This is critical because ingress_translator.go
+// sorts routes by path length. If you change the path matching type, you must also update
+// routePath() in pkg/generator/ingress_translator.go to extract paths from the new type.
 func TestRoutesUsePrefixMatching(t *testing.T) {
 	testPath := "/test/path"
 
@@ -92,3 +98,116 @@ func TestRoutesUsePrefixMatching(t *testing.T) {
 		})
 	}
 }
+
+func TestWithPathMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  PathMatchType
+		path string
+		want *route.RouteMatch
+	}{{
+		name: "prefix",
+		typ:  PathMatchPrefix,
+		path: "/api",
+		want: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/api"}},
+	}, {
+		name: "exact",
+		typ:  PathMatchExact,
+		path: "/healthz",
+		want: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Path{Path: "/healthz"}},
+	}, {
+		name: "path separated prefix",
+		typ:  PathMatchPathSeparatedPrefix,
+		path: "/api/",
+		want: &route.RouteMatch{PathSpecifier: &route.RouteMatch_PathSeparatedPrefix{PathSeparatedPrefix: "/api"}},
+	}, {
+		name: "path separated root",
+		typ:  PathMatchPathSeparatedPrefix,
+		path: "/",
+		want: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+	}, {
+		name: "regex",
+		typ:  PathMatchRegex,
+		path: "/v[0-9]+/.*",
+		want: &route.RouteMatch{PathSpecifier: &route.RouteMatch_SafeRegex{SafeRegex: &matcher.RegexMatcher{Regex: "/v[0-9]+/.*"}}},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewRoute("test", nil, test.path, nil, 0, nil, "", WithPathMatch(test.typ))
+			assert.DeepEqual(t, r.GetMatch(), test.want, protocmp.Transform())
+
+			redirect := NewRedirectRoute("test", nil, test.path, WithPathMatch(test.typ))
+			assert.DeepEqual(t, redirect.GetMatch(), test.want, protocmp.Transform())
+		})
+	}
+}
+
+func TestWithHeaderMatchers(t *testing.T) {
+	exact := func(name, value string) *route.HeaderMatcher {
+		return &route.HeaderMatcher{
+			Name: name,
+			HeaderMatchSpecifier: &route.HeaderMatcher_StringMatch{StringMatch: &matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_Exact{Exact: value},
+			}},
+		}
+	}
+	present := &route.HeaderMatcher{
+		Name:                 "x-debug",
+		HeaderMatchSpecifier: &route.HeaderMatcher_PresentMatch{PresentMatch: true},
+	}
+
+	r := NewRoute("test", []*route.HeaderMatcher{exact("X-User", "alice"), exact("x-tenant", "a")}, "/", nil, 0, nil, "",
+		WithHeaderMatchers(exact("x-user", "bob"), present))
+
+	assert.DeepEqual(t, r.GetMatch().GetHeaders(), []*route.HeaderMatcher{
+		exact("x-tenant", "a"), exact("x-user", "bob"), present,
+	}, protocmp.Transform())
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	policy := &config.RetryPolicy{
+		RetryOn:              []string{"connect-failure", "retriable-status-codes"},
+		NumRetries:           3,
+		PerTryTimeout:        2 * time.Second,
+		RetriableStatusCodes: []uint32{503},
+		RetryPreviousHosts:   true,
+	}
+
+	r := NewRoute("test", nil, "/", nil, 0, nil, "", WithRetryPolicy(policy))
+	retryPolicy := r.GetRoute().GetRetryPolicy()
+	assert.Equal(t, retryPolicy.GetRetryOn(), "connect-failure,retriable-status-codes")
+	assert.DeepEqual(t, retryPolicy.GetNumRetries(), wrapperspb.UInt32(3), protocmp.Transform())
+	assert.DeepEqual(t, retryPolicy.GetPerTryTimeout(), durationpb.New(2*time.Second), protocmp.Transform())
+	assert.DeepEqual(t, retryPolicy.GetRetriableStatusCodes(), []uint32{503})
+	assert.Equal(t, len(retryPolicy.GetRetryHostPredicate()), 1)
+	assert.Equal(t, retryPolicy.GetRetryHostPredicate()[0].GetTypedConfig().GetTypeUrl(),
+		"type.googleapis.com/envoy.extensions.retry.host.previous_hosts.v3.PreviousHostsPredicate")
+
+	// Envoy's defaults apply to unset fields.
+	r = NewRoute("test", nil, "/", nil, 0, nil, "", WithRetryPolicy(&config.RetryPolicy{RetryOn: []string{"5xx"}}))
+	assert.DeepEqual(t, r.GetRoute().GetRetryPolicy(), &route.RetryPolicy{RetryOn: "5xx"}, protocmp.Transform())
+
+	r = NewRoute("test", nil, "/", nil, 0, nil, "", WithRetryPolicy(&config.RetryPolicy{NumRetries: 3}))
+	assert.Assert(t, r.GetRoute().GetRetryPolicy() == nil)
+
+	redirect := NewRedirectRoute("test", nil, "/", WithRetryPolicy(policy))
+	assert.Assert(t, redirect.GetRoute() == nil)
+}
+
+func TestWithTimeouts(t *testing.T) {
+	r := NewRoute("test", nil, "/", nil, time.Minute, nil, "")
+	assert.DeepEqual(t, r.GetRoute().GetTimeout(), durationpb.New(time.Minute), protocmp.Transform())
+	assert.Assert(t, r.GetRoute().GetIdleTimeout() == nil)
+
+	r = NewRoute("test", nil, "/", nil, time.Minute, nil, "", WithIdleTimeout(5*time.Minute), WithTimeout(0))
+	assert.DeepEqual(t, r.GetRoute().GetTimeout(), durationpb.New(0), protocmp.Transform())
+	assert.DeepEqual(t, r.GetRoute().GetIdleTimeout(), durationpb.New(5*time.Minute), protocmp.Transform())
+
+	// A later option falls back to the stream idle timeout.
+	r = NewRoute("test", nil, "/", nil, 0, nil, "", WithIdleTimeout(5*time.Minute), WithIdleTimeout(0))
+	assert.Assert(t, r.GetRoute().GetIdleTimeout() == nil)
+
+	redirect := NewRedirectRoute("test", nil, "/", WithTimeout(time.Minute), WithIdleTimeout(time.Minute))
+	assert.Assert(t, redirect.GetRoute() == nil)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envoy
+
+import (
+	"errors"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// SecretName returns the name of the SDS secret carrying the certificate stored in
+// the given Kubernetes Secret. Certificates whose key is handled by a private key
+// provider get a distinct name, as their secret is shaped differently.
+func SecretName(source types.NamespacedName, privateKeyProvider string) string {
+	name := source.Namespace + "/" + source.Name
+	if privateKeyProvider != "" {
+		name += "/" + privateKeyProvider
+	}
+	return name
+}
+
+// NewSdsSecretConfig creates a reference to the SDS secret with the given name,
+// which is fetched over the aggregated stream.
+func NewSdsSecretConfig(name string) *auth.SdsSecretConfig {
+	return &auth.SdsSecretConfig{
+		Name: name,
+		SdsConfig: &core.ConfigSource{
+			ResourceApiVersion: resource.DefaultAPIVersion,
+			ConfigSourceSpecifier: &core.ConfigSource_Ads{
+				Ads: &core.AggregatedConfigSource{},
+			},
+		},
+	}
+}
+
+// NewSecret creates an SDS secret holding the given certificate, named after the
+// certificate's SecretName.
+func NewSecret(c *Certificate) (*auth.Secret, error) {
+	tlsCertificate, err := c.createTLScertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.Secret{
+		Name: c.SecretName,
+		Type: &auth.Secret_TlsCertificate{
+			TlsCertificate: tlsCertificate,
+		},
+	}, nil
+}
+
+// NewValidationContextSecret creates an SDS secret holding the given CA bundle to
+// validate peer certificates against.
+func NewValidationContextSecret(name string, trustedCA []byte) *auth.Secret {
+	return &auth.Secret{
+		Name: name,
+		Type: &auth.Secret_ValidationContext{
+			ValidationContext: &auth.CertificateValidationContext{
+				TrustedCa: &core.DataSource{
+					Specifier: &core.DataSource_InlineBytes{InlineBytes: trustedCA},
+				},
+			},
+		},
+	}
+}
+
+func (c Certificate) createTLScertificates() (*auth.TlsCertificate, error) {
+	switch c.PrivateKeyProvider {
+	case "":
+		return &auth.TlsCertificate{
+			CertificateChain: &core.DataSource{
+				Specifier: &core.DataSource_InlineBytes{InlineBytes: c.Certificate},
+			},
+			PrivateKey: &core.DataSource{
+				Specifier: &core.DataSource_InlineBytes{InlineBytes: c.PrivateKey},
+			},
+		}, nil
+	case "cryptomb":
+		msg, err := c.createCryptoMbMessaage()
+		if err != nil {
+			return nil, err
+		}
+		return &auth.TlsCertificate{
+			CertificateChain: &core.DataSource{
+				Specifier: &core.DataSource_InlineBytes{InlineBytes: c.Certificate},
+			},
+			PrivateKeyProvider: &auth.PrivateKeyProvider{
+				ProviderName: "cryptomb",
+				ConfigType: &auth.PrivateKeyProvider_TypedConfig{
+					TypedConfig: msg,
+				},
+			},
+		}, nil
+	default:
+		return nil, errors.New("Unsupported private key provider: " + c.PrivateKeyProvider)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envoy
+
+import (
+	"testing"
+
+	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSecretName(t *testing.T) {
+	source := types.NamespacedName{Namespace: "secretns", Name: "secretname"}
+
+	assert.Equal(t, SecretName(source, ""), "secretns/secretname")
+	assert.Equal(t, SecretName(source, "cryptomb"), "secretns/secretname/cryptomb")
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret(&c)
+	assert.NilError(t, err)
+
+	assert.Equal(t, secret.GetName(), c.SecretName)
+	assert.DeepEqual(t, secret.GetTlsCertificate().GetCertificateChain().GetInlineBytes(), c.Certificate)
+	assert.DeepEqual(t, secret.GetTlsCertificate().GetPrivateKey().GetInlineBytes(), c.PrivateKey)
+}
+
+func TestNewSecretWithPrivateKeyProvider(t *testing.T) {
+	msg, err := crypto.createCryptoMbMessaage()
+	assert.NilError(t, err)
+
+	secret, err := NewSecret(&crypto)
+	assert.NilError(t, err)
+
+	assert.Equal(t, secret.GetName(), crypto.SecretName)
+	assert.DeepEqual(t, secret.GetTlsCertificate().GetCertificateChain().GetInlineBytes(), crypto.Certificate)
+	assert.Assert(t, secret.GetTlsCertificate().GetPrivateKey() == nil)
+	assert.DeepEqual(t, secret.GetTlsCertificate().GetPrivateKeyProvider(), &auth.PrivateKeyProvider{
+		ProviderName: "cryptomb",
+		ConfigType: &auth.PrivateKeyProvider_TypedConfig{
+			TypedConfig: msg,
+		},
+	}, protocmp.Transform())
+}
+
+func TestNewSecretWithUnsupportedPrivateKeyProvider(t *testing.T) {
+	_, err := NewSecret(&Certificate{PrivateKeyProvider: "foo"})
+	assert.Error(t, err, "Unsupported private key provider: foo")
+}
+
+func TestNewValidationContextSecret(t *testing.T) {
+	secret := NewValidationContextSecret("trust-bundle", []byte("some_ca"))
+
+	assert.Equal(t, secret.GetName(), "trust-bundle")
+	assert.DeepEqual(t, secret.GetValidationContext().GetTrustedCa().GetInlineBytes(), []byte("some_ca"))
+}
@@ -20,6 +20,7 @@ import (
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	extAuthService "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
@@ -39,13 +40,16 @@ func NewVirtualHostWithExtAuthz(
 	domains []string,
 	routes []*route.Route,
 ) *route.VirtualHost {
-	filter, _ := anypb.New(&extAuthService.ExtAuthzPerRoute{
+	// The context extensions are a map, so marshal them deterministically to keep
+	// the hash of the virtual host stable.
+	filter := &anypb.Any{}
+	_ = anypb.MarshalFrom(filter, &extAuthService.ExtAuthzPerRoute{
 		Override: &extAuthService.ExtAuthzPerRoute_CheckSettings{
 			CheckSettings: &extAuthService.CheckSettings{
 				ContextExtensions: contextExtensions,
 			},
 		},
-	})
+	}, proto.MarshalOptions{Deterministic: true})
 
 	return &route.VirtualHost{
 		Name:    name,
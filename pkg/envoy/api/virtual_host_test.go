@@ -57,3 +57,13 @@ func TestVirtualHostWithExtAuthz(t *testing.T) {
 	assert.DeepEqual(t, got.Routes, want.Routes, protocmp.Transform())
 	assert.Assert(t, got.TypedPerFilterConfig[wellknown.HTTPExternalAuthorization] != nil)
 }
+
+func TestVirtualHostWithExtAuthzIsDeterministic(t *testing.T) {
+	contextExtensions := map[string]string{"a": "1", "b": "2", "c": "3", "d": "4", "e": "5"}
+
+	want := NewVirtualHostWithExtAuthz("test", contextExtensions, nil, nil).TypedPerFilterConfig[wellknown.HTTPExternalAuthorization].GetValue()
+	for range 10 {
+		got := NewVirtualHostWithExtAuthz("test", contextExtensions, nil, nil).TypedPerFilterConfig[wellknown.HTTPExternalAuthorization].GetValue()
+		assert.DeepEqual(t, got, want)
+	}
+}
@@ -0,0 +1,450 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+)
+
+// AckTracker tracks which snapshot every xDS stream of the connected gateways has
+// acknowledged. Its methods are meant to be called from the callbacks of the xDS
+// server.
+//
+// Snapshot versions are content hashes, so they carry no order. Every published
+// snapshot is therefore assigned a generation by the caller, which must grow with
+// every change the snapshot contains.
+//
+// All types of a snapshot share its version, but every type is sent and
+// acknowledged on its own, and delta streams don't send types again whose
+// resources didn't change. A stream has therefore applied a snapshot once every
+// type it subscribed to acknowledged either that snapshot or one with the same
+// resources of that type.
+type AckTracker struct {
+	// onChange is called whenever the acknowledged generation might have changed.
+	onChange func()
+	metrics  *metrics
+
+	mu      sync.Mutex
+	streams map[streamKey]*ackStream
+	// generations maps snapshot versions to the latest generation they were
+	// published as.
+	generations map[string]uint64
+	// contents maps snapshot versions to a fingerprint of the resources of every
+	// type they contain. Types without resources have an empty fingerprint.
+	contents map[string]map[string]string
+}
+
+// streamKey identifies a stream. State-of-the-world and delta streams are numbered
+// independently.
+type streamKey struct {
+	id    int64
+	delta bool
+}
+
+type ackStream struct {
+	nodeID string
+	types  map[string]*ackStreamType
+	// synced is the version of the latest snapshot the stream has applied
+	// completely, i.e. with every subscribed type at that snapshot and no response
+	// left unacknowledged.
+	synced string
+}
+
+type ackStreamType struct {
+	acked string
+	// inFlight holds the responses sent but not acknowledged yet, in the order they
+	// were sent.
+	inFlight []sentResponse
+}
+
+type sentResponse struct {
+	nonce   string
+	version string
+	sent    time.Time
+}
+
+// GatewayStatus is the acknowledgement state of a single xDS stream.
+type GatewayStatus struct {
+	NodeID string `json:"nodeID"`
+	// Version is the latest snapshot version the stream has applied completely.
+	Version string `json:"version"`
+}
+
+// NewAckTracker creates an AckTracker calling onChange whenever the acknowledged
+// generation might have changed.
+func NewAckTracker(onChange func()) *AckTracker {
+	return &AckTracker{
+		onChange:    onChange,
+		metrics:     newMetrics(nil),
+		streams:     make(map[streamKey]*ackStream),
+		generations: make(map[string]uint64),
+		contents:    make(map[string]map[string]string),
+	}
+}
+
+// Published records that the given snapshots, one per fleet of gateways, are
+// about to be published as the given generation. It must be called before the
+// snapshots are handed to the xDS server.
+func (t *AckTracker) Published(generation uint64, snapshots ...*cache.Snapshot) {
+	published := make(map[string]map[string]string, len(snapshots))
+	for _, snapshot := range snapshots {
+		published[snapshot.GetVersion(resource.ListenerType)] = fingerprints(snapshot)
+	}
+
+	t.mu.Lock()
+	// Only keep around the versions that are still referenced by a stream.
+	generations := make(map[string]uint64, len(published))
+	contents := make(map[string]map[string]string, len(published))
+	for version, fingerprints := range published {
+		generations[version] = generation
+		contents[version] = fingerprints
+	}
+	for _, stream := range t.streams {
+		for _, v := range stream.versions() {
+			if _, ok := generations[v]; ok {
+				continue
+			}
+			if g, ok := t.generations[v]; ok {
+				generations[v] = g
+				contents[v] = t.contents[v]
+			}
+		}
+	}
+	t.generations = generations
+	t.contents = contents
+	// Streams that already applied the same resources are now at the new
+	// generation, either at the very same version or at an equivalent one.
+	for _, stream := range t.streams {
+		stream.sync(t.generations, t.contents)
+	}
+	t.mu.Unlock()
+
+	t.onChange()
+}
+
+// fingerprints returns a fingerprint of the resources of every type of the
+// snapshot. It returns nil if the resources can't be hashed, in which case types
+// only match the snapshot if they acknowledged its very version.
+func fingerprints(snapshot *cache.Snapshot) map[string]string {
+	if err := snapshot.ConstructVersionMap(); err != nil {
+		return nil
+	}
+	fingerprints := make(map[string]string, len(snapshot.Resources))
+	for i := range snapshot.Resources {
+		typeURL, err := cache.GetResponseTypeURL(cachetypes.ResponseType(i))
+		if err != nil {
+			return nil
+		}
+		versions := snapshot.GetVersionMap(typeURL)
+		if len(versions) == 0 {
+			fingerprints[typeURL] = ""
+			continue
+		}
+		hash := sha256.New()
+		for _, name := range slices.Sorted(maps.Keys(versions)) {
+			hash.Write([]byte(name))
+			hash.Write([]byte{0})
+			hash.Write([]byte(versions[name]))
+			hash.Write([]byte{0})
+		}
+		fingerprints[typeURL] = hex.EncodeToString(hash.Sum(nil))
+	}
+	return fingerprints
+}
+
+// AckedGeneration returns the latest generation all connected streams have
+// applied completely. It returns false if no gateway is connected.
+func (t *AckTracker) AckedGeneration() (uint64, bool) {
+	return t.ackedGeneration(func(*ackStream) bool { return true })
+}
+
+// FleetAckedGeneration returns the latest generation all connected streams of the
+// given fleet of gateways have applied completely. Gateways identify their fleet by
+// their node ID. It returns false if no gateway of the fleet is connected.
+func (t *AckTracker) FleetAckedGeneration(fleet string) (uint64, bool) {
+	return t.ackedGeneration(func(stream *ackStream) bool { return stream.nodeID == fleet })
+}
+
+// ackedGeneration returns the latest generation all connected streams matching
+// the given filter have applied completely, and whether any stream matched.
+func (t *AckTracker) ackedGeneration(filter func(*ackStream) bool) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var generation uint64
+	found := false
+	for _, stream := range t.streams {
+		if !filter(stream) {
+			continue
+		}
+		g := t.generations[stream.synced]
+		if !found || g < generation {
+			generation = g
+			found = true
+		}
+	}
+	return generation, found
+}
+
+// Gateways returns the acknowledgement state of all connected streams.
+func (t *AckTracker) Gateways() []GatewayStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gateways := make([]GatewayStatus, 0, len(t.streams))
+	for _, stream := range t.streams {
+		gateways = append(gateways, GatewayStatus{NodeID: stream.nodeID, Version: stream.synced})
+	}
+	slices.SortFunc(gateways, func(a, b GatewayStatus) int {
+		return cmp.Or(cmp.Compare(a.NodeID, b.NodeID), cmp.Compare(a.Version, b.Version))
+	})
+	return gateways
+}
+
+// OnStreamOpen is to be called when a state-of-the-world stream is opened.
+func (t *AckTracker) OnStreamOpen(_ context.Context, id int64, _ string) error {
+	t.open(streamKey{id: id})
+	return nil
+}
+
+// OnStreamClosed is to be called when a state-of-the-world stream is closed.
+func (t *AckTracker) OnStreamClosed(id int64, _ *core.Node) {
+	t.close(streamKey{id: id})
+}
+
+// OnDeltaStreamOpen is to be called when a delta stream is opened.
+func (t *AckTracker) OnDeltaStreamOpen(_ context.Context, id int64, _ string) error {
+	t.open(streamKey{id: id, delta: true})
+	return nil
+}
+
+// OnDeltaStreamClosed is to be called when a delta stream is closed.
+func (t *AckTracker) OnDeltaStreamClosed(id int64, _ *core.Node) {
+	t.close(streamKey{id: id, delta: true})
+}
+
+// OnStreamRequest is to be called for every request on a state-of-the-world
+// stream.
+func (t *AckTracker) OnStreamRequest(id int64, req *discovery.DiscoveryRequest) error {
+	t.request(streamKey{id: id}, req.GetNode(), req.GetTypeUrl(), req.GetResponseNonce(), req.GetErrorDetail())
+	return nil
+}
+
+// OnStreamResponse is to be called for every response on a state-of-the-world
+// stream.
+func (t *AckTracker) OnStreamResponse(_ context.Context, id int64, _ *discovery.DiscoveryRequest, resp *discovery.DiscoveryResponse) {
+	t.response(streamKey{id: id}, resp.GetTypeUrl(), resp.GetNonce(), resp.GetVersionInfo())
+}
+
+// OnStreamDeltaRequest is to be called for every request on a delta stream.
+func (t *AckTracker) OnStreamDeltaRequest(id int64, req *discovery.DeltaDiscoveryRequest) error {
+	t.request(streamKey{id: id, delta: true}, req.GetNode(), req.GetTypeUrl(), req.GetResponseNonce(), req.GetErrorDetail())
+	return nil
+}
+
+// OnStreamDeltaResponse is to be called for every response on a delta stream.
+func (t *AckTracker) OnStreamDeltaResponse(id int64, _ *discovery.DeltaDiscoveryRequest, resp *discovery.DeltaDiscoveryResponse) {
+	t.response(streamKey{id: id, delta: true}, resp.GetTypeUrl(), resp.GetNonce(), resp.GetSystemVersionInfo())
+}
+
+func (t *AckTracker) open(key streamKey) {
+	t.mu.Lock()
+	t.streams[key] = &ackStream{types: make(map[string]*ackStreamType)}
+	t.mu.Unlock()
+}
+
+func (t *AckTracker) close(key streamKey) {
+	t.mu.Lock()
+	if stream, ok := t.streams[key]; ok {
+		for typeURL := range stream.types {
+			t.metrics.streamSubscribed(typeURL, -1)
+		}
+	}
+	delete(t.streams, key)
+	t.mu.Unlock()
+
+	// The closed stream might have been the last one lagging behind.
+	t.onChange()
+}
+
+func (t *AckTracker) response(key streamKey, typeURL, nonce, version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stream, ok := t.streams[key]
+	if !ok {
+		return
+	}
+	streamType := t.streamType(stream, typeURL)
+	streamType.inFlight = append(streamType.inFlight, sentResponse{nonce: nonce, version: version, sent: time.Now()})
+}
+
+func (t *AckTracker) request(key streamKey, node *core.Node, typeURL, nonce string, errorDetail *rpcstatus.Status) {
+	changed := func() bool {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		stream, ok := t.streams[key]
+		if !ok {
+			return false
+		}
+		if node != nil {
+			stream.nodeID = node.GetId()
+		}
+		streamType := t.streamType(stream, typeURL)
+		if nonce == "" {
+			// An initial request, acknowledging nothing.
+			return false
+		}
+
+		i := slices.IndexFunc(streamType.inFlight, func(sent sentResponse) bool { return sent.nonce == nonce })
+		if i < 0 {
+			return false
+		}
+		if errorDetail == nil {
+			streamType.acked = streamType.inFlight[i].version
+			t.metrics.recordACK(typeURL, time.Since(streamType.inFlight[i].sent))
+		} else {
+			t.metrics.recordNACK(typeURL, errorDetail)
+		}
+		// Acknowledging a response supersedes all responses sent before it.
+		streamType.inFlight = streamType.inFlight[i+1:]
+
+		return stream.sync(t.generations, t.contents)
+	}()
+
+	if changed {
+		t.onChange()
+	}
+}
+
+func (t *AckTracker) streamType(stream *ackStream, typeURL string) *ackStreamType {
+	streamType, ok := stream.types[typeURL]
+	if !ok {
+		streamType = &ackStreamType{}
+		stream.types[typeURL] = streamType
+		t.metrics.streamSubscribed(typeURL, 1)
+	}
+	return streamType
+}
+
+// sync updates the synced version to the most recent snapshot every subscribed
+// type has applied, as long as no response is in flight anymore. It returns
+// whether the synced version changed.
+func (s *ackStream) sync(generations map[string]uint64, contents map[string]map[string]string) bool {
+	if len(s.types) == 0 {
+		return false
+	}
+	candidates := slices.Collect(maps.Keys(generations))
+	for _, streamType := range s.types {
+		if len(streamType.inFlight) > 0 {
+			return false
+		}
+		candidates = append(candidates, streamType.acked)
+	}
+
+	// The synced version never moves back to an older generation.
+	synced := s.synced
+	for _, version := range candidates {
+		if version == synced || generations[version] < generations[synced] {
+			continue
+		}
+		if generations[version] == generations[synced] && !s.preferred(version, synced) {
+			continue
+		}
+		if s.applied(version, contents) {
+			synced = version
+		}
+	}
+	if synced == s.synced {
+		return false
+	}
+	s.synced = synced
+	return true
+}
+
+// applied returns whether every subscribed type acknowledged either the given
+// version or a version with the same resources of that type.
+func (s *ackStream) applied(version string, contents map[string]map[string]string) bool {
+	want := contents[version]
+	for typeURL, streamType := range s.types {
+		if streamType.acked == version {
+			continue
+		}
+		if want == nil {
+			return false
+		}
+		got := ""
+		if streamType.acked != "" {
+			acked := contents[streamType.acked]
+			if acked == nil {
+				return false
+			}
+			got = acked[typeURL]
+		}
+		if got != want[typeURL] {
+			return false
+		}
+	}
+	return true
+}
+
+// preferred returns whether the version a is preferred over the version b of the
+// same generation: acknowledged versions come first, then the lowest one, so
+// that the choice doesn't depend on the order versions are looked at.
+func (s *ackStream) preferred(a, b string) bool {
+	if acked, other := s.acked(a), s.acked(b); acked != other {
+		return acked
+	}
+	return a < b
+}
+
+// acked returns whether any type acknowledged the given version.
+func (s *ackStream) acked(version string) bool {
+	for _, streamType := range s.types {
+		if streamType.acked == version {
+			return true
+		}
+	}
+	return false
+}
+
+// versions returns all versions the stream refers to.
+func (s *ackStream) versions() []string {
+	versions := []string{s.synced}
+	for _, streamType := range s.types {
+		versions = append(versions, streamType.acked)
+		for _, sent := range streamType.inFlight {
+			versions = append(versions, sent.version)
+		}
+	}
+	return versions
+}
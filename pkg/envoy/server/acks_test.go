@@ -0,0 +1,224 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestAckTracker(t *testing.T) {
+	tracker := NewAckTracker(func() {})
+	xdsServer := NewXdsServer(context.Background(), 0, &xds.CallbackFuncs{
+		StreamOpenFunc:          tracker.OnStreamOpen,
+		StreamClosedFunc:        tracker.OnStreamClosed,
+		DeltaStreamOpenFunc:     tracker.OnDeltaStreamOpen,
+		DeltaStreamClosedFunc:   tracker.OnDeltaStreamClosed,
+		StreamRequestFunc:       tracker.OnStreamRequest,
+		StreamResponseFunc:      tracker.OnStreamResponse,
+		StreamDeltaRequestFunc:  tracker.OnStreamDeltaRequest,
+		StreamDeltaResponseFunc: tracker.OnStreamDeltaResponse,
+	})
+	publish := func(generation uint64, version string, clusterNames ...string) {
+		snapshot := newTestSnapshot(t, version, clusterNames...)
+		tracker.Published(generation, snapshot)
+		assert.NilError(t, xdsServer.SetSnapshot(testNodeID, snapshot))
+	}
+	publish(1, "1", "foo")
+
+	client := startTestServer(t, xdsServer)
+
+	_, ok := tracker.AckedGeneration()
+	assert.Assert(t, !ok, "No gateway is connected yet")
+
+	t.Run("state of the world", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		stream, err := client.StreamAggregatedResources(ctx)
+		assert.NilError(t, err)
+		assert.NilError(t, stream.Send(&discovery.DiscoveryRequest{
+			Node:    &core.Node{Id: testNodeID},
+			TypeUrl: resource.ClusterType,
+		}))
+		resp, err := stream.Recv()
+		assert.NilError(t, err)
+		waitForAckedGeneration(t, tracker, 0)
+
+		assert.NilError(t, stream.Send(&discovery.DiscoveryRequest{
+			TypeUrl:       resource.ClusterType,
+			VersionInfo:   resp.GetVersionInfo(),
+			ResponseNonce: resp.GetNonce(),
+		}))
+		waitForAckedGeneration(t, tracker, 1)
+		assert.DeepEqual(t, tracker.Gateways(), []GatewayStatus{{NodeID: testNodeID, Version: "1"}})
+
+		// A rejected snapshot leaves the stream at the last acknowledged generation.
+		publish(2, "2", "bar")
+		resp, err = stream.Recv()
+		assert.NilError(t, err)
+		assert.NilError(t, stream.Send(&discovery.DiscoveryRequest{
+			TypeUrl:       resource.ClusterType,
+			VersionInfo:   "1",
+			ResponseNonce: resp.GetNonce(),
+			ErrorDetail:   &rpcstatus.Status{Message: "boom"},
+		}))
+
+		publish(3, "3", "baz")
+		resp, err = stream.Recv()
+		assert.NilError(t, err)
+		assert.Equal(t, resp.GetVersionInfo(), "3")
+		waitForAckedGeneration(t, tracker, 1)
+
+		assert.NilError(t, stream.Send(&discovery.DiscoveryRequest{
+			TypeUrl:       resource.ClusterType,
+			VersionInfo:   resp.GetVersionInfo(),
+			ResponseNonce: resp.GetNonce(),
+		}))
+		waitForAckedGeneration(t, tracker, 3)
+
+		// Publishing identical contents again moves the stream along.
+		publish(4, "3", "baz")
+		waitForAckedGeneration(t, tracker, 4)
+	})
+
+	// The state-of-the-world stream is closed by now.
+	waitForDisconnect(t, tracker)
+
+	t.Run("delta", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		stream, err := client.DeltaAggregatedResources(ctx)
+		assert.NilError(t, err)
+		assert.NilError(t, stream.Send(&discovery.DeltaDiscoveryRequest{
+			Node:    &core.Node{Id: testNodeID},
+			TypeUrl: resource.ClusterType,
+		}))
+		resp, err := stream.Recv()
+		assert.NilError(t, err)
+		waitForAckedGeneration(t, tracker, 0)
+
+		assert.NilError(t, stream.Send(&discovery.DeltaDiscoveryRequest{
+			TypeUrl:       resource.ClusterType,
+			ResponseNonce: resp.GetNonce(),
+		}))
+		waitForAckedGeneration(t, tracker, 4)
+
+		publish(5, "5", "baz", "qux")
+		resp, err = stream.Recv()
+		assert.NilError(t, err)
+		assert.NilError(t, stream.Send(&discovery.DeltaDiscoveryRequest{
+			TypeUrl:       resource.ClusterType,
+			ResponseNonce: resp.GetNonce(),
+		}))
+		waitForAckedGeneration(t, tracker, 5)
+	})
+
+	waitForDisconnect(t, tracker)
+}
+
+func TestAckTrackerInterleavedTypes(t *testing.T) {
+	tracker := NewAckTracker(func() {})
+	ctx := context.Background()
+
+	snapshot := func(version, cluster, listener string) *cache.Snapshot {
+		snapshot, err := cache.NewSnapshot(version, map[resource.Type][]cachetypes.Resource{
+			resource.ClusterType:  {&clusterv3.Cluster{Name: cluster, ConnectTimeout: durationpb.New(time.Second)}},
+			resource.ListenerType: {&listenerv3.Listener{Name: listener}},
+		})
+		assert.NilError(t, err)
+		return snapshot
+	}
+	send := func(typeURL, version, nonce string) {
+		tracker.OnStreamResponse(ctx, 1, nil, &discovery.DiscoveryResponse{TypeUrl: typeURL, VersionInfo: version, Nonce: nonce})
+	}
+	ack := func(typeURL, version, nonce string) {
+		assert.NilError(t, tracker.OnStreamRequest(1, &discovery.DiscoveryRequest{TypeUrl: typeURL, VersionInfo: version, ResponseNonce: nonce}))
+	}
+	acked := func() uint64 {
+		generation, ok := tracker.AckedGeneration()
+		assert.Assert(t, ok)
+		return generation
+	}
+
+	tracker.Published(1, snapshot("v1", "foo", "http"))
+	assert.NilError(t, tracker.OnStreamOpen(ctx, 1, resource.AnyType))
+	for _, typeURL := range []string{resource.ClusterType, resource.ListenerType} {
+		assert.NilError(t, tracker.OnStreamRequest(1, &discovery.DiscoveryRequest{Node: &core.Node{Id: testNodeID}, TypeUrl: typeURL}))
+	}
+	send(resource.ClusterType, "v1", "a")
+	send(resource.ListenerType, "v1", "b")
+	ack(resource.ClusterType, "v1", "a")
+	assert.Equal(t, acked(), uint64(0), "listeners are still in flight")
+	ack(resource.ListenerType, "v1", "b")
+	assert.Equal(t, acked(), uint64(1))
+
+	// The clusters of the next snapshot are acknowledged before its listeners are
+	// even sent.
+	tracker.Published(2, snapshot("v2", "bar", "https"))
+	send(resource.ClusterType, "v2", "c")
+	ack(resource.ClusterType, "v2", "c")
+	assert.Equal(t, acked(), uint64(1), "listeners were not sent yet")
+	assert.DeepEqual(t, tracker.Gateways(), []GatewayStatus{{NodeID: testNodeID, Version: "v1"}})
+	send(resource.ListenerType, "v2", "d")
+	ack(resource.ListenerType, "v2", "d")
+	assert.Equal(t, acked(), uint64(2))
+
+	// Types whose resources didn't change are not sent again.
+	tracker.Published(3, snapshot("v3", "baz", "https"))
+	send(resource.ClusterType, "v3", "e")
+	ack(resource.ClusterType, "v3", "e")
+	assert.Equal(t, acked(), uint64(3))
+	assert.DeepEqual(t, tracker.Gateways(), []GatewayStatus{{NodeID: testNodeID, Version: "v3"}})
+}
+
+func waitForAckedGeneration(t *testing.T, tracker *AckTracker, want uint64) {
+	t.Helper()
+
+	var got uint64
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		generation, ok := tracker.AckedGeneration()
+		got = generation
+		return ok && generation == want, nil
+	})
+	assert.NilError(t, err, "acked generation is %d, want %d", got, want)
+}
+
+func waitForDisconnect(t *testing.T, tracker *AckTracker) {
+	t.Helper()
+
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		_, ok := tracker.AckedGeneration()
+		return !ok, nil
+	})
+	assert.NilError(t, err, "stream was not closed")
+}
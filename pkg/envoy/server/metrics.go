@@ -0,0 +1,131 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"knative.dev/pkg/observability/attributekey"
+)
+
+const scopeName = "knative.dev/net-kourier/pkg/envoy/server"
+
+var (
+	// TypeURLAttr is the type URL of the xDS resources a measurement refers to.
+	TypeURLAttr = attributekey.String("kourier.xds.type_url")
+
+	// NACKClassAttr is the class of the error a gateway rejected a response with.
+	NACKClassAttr = attributekey.String("kourier.xds.nack.class")
+)
+
+type metrics struct {
+	streams    metric.Int64UpDownCounter
+	nacks      metric.Int64Counter
+	ackLatency metric.Float64Histogram
+}
+
+func newMetrics(provider metric.MeterProvider) *metrics {
+	var (
+		m   metrics
+		err error
+	)
+
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+
+	meter := provider.Meter(scopeName)
+
+	m.streams, err = meter.Int64UpDownCounter(
+		"kourier.xds.streams",
+		metric.WithDescription("The number of connected xDS streams subscribed to a type URL."),
+		metric.WithUnit("{stream}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	m.nacks, err = meter.Int64Counter(
+		"kourier.xds.nacks",
+		metric.WithDescription("The number of xDS responses rejected by gateways."),
+		metric.WithUnit("{nack}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	m.ackLatency, err = meter.Float64Histogram(
+		"kourier.xds.ack.latency",
+		metric.WithDescription("The time from pushing an xDS response to a gateway until it was acknowledged."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return &m
+}
+
+func (m *metrics) streamSubscribed(typeURL string, delta int64) {
+	m.streams.Add(context.Background(), delta, metric.WithAttributes(TypeURLAttr.With(typeURL)))
+}
+
+func (m *metrics) recordNACK(typeURL string, detail *rpcstatus.Status) {
+	m.nacks.Add(context.Background(), 1, metric.WithAttributes(
+		TypeURLAttr.With(typeURL),
+		NACKClassAttr.With(nackClass(detail)),
+	))
+}
+
+func (m *metrics) recordACK(typeURL string, d time.Duration) {
+	m.ackLatency.Record(context.Background(), d.Seconds(), metric.WithAttributes(TypeURLAttr.With(typeURL)))
+}
+
+// nackClass classifies the error a response was rejected with. Error messages
+// contain resource names, so they're mapped to a small set of classes to keep the
+// cardinality of the NACK metric bounded.
+func nackClass(detail *rpcstatus.Status) string {
+	message := detail.GetMessage()
+	switch {
+	case strings.Contains(message, "unknown weighted cluster"), strings.Contains(message, "unknown cluster"):
+		return "unknown_cluster"
+	case strings.Contains(message, "Proto constraint validation failed"):
+		return "validation"
+	case strings.Contains(message, "duplicate"):
+		return "duplicate"
+	case strings.Contains(message, "certificate"), strings.Contains(message, "private key"):
+		return "tls"
+	default:
+		// e.g. "invalid_argument" for InvalidArgument.
+		var class strings.Builder
+		for i, r := range codes.Code(detail.GetCode()).String() {
+			if unicode.IsUpper(r) && i > 0 {
+				class.WriteByte('_')
+			}
+			class.WriteRune(unicode.ToLower(r))
+		}
+		return class.String()
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"gotest.tools/v3/assert"
+)
+
+func TestAckTrackerMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	tracker := NewAckTracker(func() {})
+	tracker.metrics = newMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	ctx := context.Background()
+	respond := func(id int64, version, nonce string) {
+		tracker.OnStreamResponse(ctx, id, nil, &discovery.DiscoveryResponse{
+			TypeUrl:     resource.ClusterType,
+			VersionInfo: version,
+			Nonce:       nonce,
+		})
+	}
+	request := func(id int64, nonce string, errorDetail *rpcstatus.Status) {
+		assert.NilError(t, tracker.OnStreamRequest(id, &discovery.DiscoveryRequest{
+			TypeUrl:       resource.ClusterType,
+			ResponseNonce: nonce,
+			ErrorDetail:   errorDetail,
+		}))
+	}
+
+	for _, id := range []int64{1, 2} {
+		assert.NilError(t, tracker.OnStreamOpen(ctx, id, resource.AnyType))
+		request(id, "", nil)
+	}
+	respond(1, "v1", "a")
+	request(1, "a", nil)
+	respond(1, "v2", "b")
+	request(1, "b", &rpcstatus.Status{Message: "route: unknown weighted cluster 'ns/name'"})
+	respond(2, "v2", "a")
+	request(2, "a", &rpcstatus.Status{Code: int32(codes.InvalidArgument), Message: "boom"})
+	tracker.OnStreamClosed(2, nil)
+
+	metrics := collectMetrics(t, reader)
+
+	streams := metrics["kourier.xds.streams"].(metricdata.Sum[int64])
+	assert.Equal(t, len(streams.DataPoints), 1)
+	assert.Equal(t, streams.DataPoints[0].Value, int64(1))
+	assert.Equal(t, attrValue(streams.DataPoints[0].Attributes, TypeURLAttr), resource.ClusterType)
+
+	nacks := map[string]int64{}
+	for _, point := range metrics["kourier.xds.nacks"].(metricdata.Sum[int64]).DataPoints {
+		nacks[attrValue(point.Attributes, NACKClassAttr)] = point.Value
+	}
+	assert.DeepEqual(t, nacks, map[string]int64{"unknown_cluster": 1, "invalid_argument": 1})
+
+	latency := metrics["kourier.xds.ack.latency"].(metricdata.Histogram[float64])
+	assert.Equal(t, len(latency.DataPoints), 1)
+	assert.Equal(t, latency.DataPoints[0].Count, uint64(1))
+}
+
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	assert.NilError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func attrValue[K ~string](set attribute.Set, key K) string {
+	value, _ := set.Value(attribute.Key(key))
+	return value.AsString()
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// snapshotFile is the format snapshots are persisted in.
+type snapshotFile struct {
+	// Snapshots are keyed by the node ID they are served to.
+	Snapshots map[string]persistedSnapshot `json:"snapshots"`
+}
+
+type persistedSnapshot struct {
+	// Versions holds the version of the resources, keyed by their type URL.
+	Versions map[string]string `json:"versions"`
+	// ResourceVersions holds the version of every resource, as used by delta xDS,
+	// keyed by type URL and resource name.
+	ResourceVersions map[string]map[string]string `json:"resourceVersions,omitempty"`
+	// Resources holds the marshaled resources, keyed by their type URL.
+	Resources map[string][][]byte `json:"resources"`
+}
+
+// WriteSnapshotsFile persists the given snapshots, keyed by node ID, to the given
+// file. The file is replaced atomically and is only readable by its owner, as the
+// snapshots carry the private keys of the certificates served by the gateways.
+func WriteSnapshotsFile(path string, snapshots map[string]*cache.Snapshot) error {
+	file := snapshotFile{Snapshots: make(map[string]persistedSnapshot, len(snapshots))}
+	for nodeID, snapshot := range snapshots {
+		persisted := persistedSnapshot{
+			Versions:  make(map[string]string, len(snapshot.Resources)),
+			Resources: make(map[string][][]byte, len(snapshot.Resources)),
+		}
+		for i, resources := range snapshot.Resources {
+			typeURL, err := cache.GetResponseTypeURL(cachetypes.ResponseType(i))
+			if err != nil {
+				return err
+			}
+			persisted.Versions[typeURL] = resources.Version
+			if versions := snapshot.GetVersionMap(typeURL); versions != nil {
+				if persisted.ResourceVersions == nil {
+					persisted.ResourceVersions = make(map[string]map[string]string, len(snapshot.Resources))
+				}
+				persisted.ResourceVersions[typeURL] = versions
+			}
+			for _, item := range resources.Items {
+				marshaled, err := proto.MarshalOptions{Deterministic: true}.Marshal(item.Resource)
+				if err != nil {
+					return fmt.Errorf("failed to marshal resource %q: %w", cache.GetResourceName(item.Resource), err)
+				}
+				persisted.Resources[typeURL] = append(persisted.Resources[typeURL], marshaled)
+			}
+		}
+		file.Snapshots[nodeID] = persisted
+	}
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadSnapshotsFile reads the snapshots persisted with WriteSnapshotsFile. It
+// returns an error satisfying os.IsNotExist if no snapshots were persisted yet.
+func ReadSnapshotsFile(path string) (map[string]*cache.Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file snapshotFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	snapshots := make(map[string]*cache.Snapshot, len(file.Snapshots))
+	for nodeID, persisted := range file.Snapshots {
+		resources := make(map[resource.Type][]cachetypes.Resource, len(persisted.Resources))
+		for typeURL, marshaled := range persisted.Resources {
+			messageType, err := protoregistry.GlobalTypes.FindMessageByURL(typeURL)
+			if err != nil {
+				return nil, fmt.Errorf("unknown resource type %q: %w", typeURL, err)
+			}
+			for _, b := range marshaled {
+				message := messageType.New().Interface()
+				if err := proto.Unmarshal(b, message); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal resource of type %q: %w", typeURL, err)
+				}
+				resources[typeURL] = append(resources[typeURL], message)
+			}
+		}
+
+		snapshot, err := cache.NewSnapshot("", resources)
+		if err != nil {
+			return nil, err
+		}
+		for i := range snapshot.Resources {
+			typeURL, err := cache.GetResponseTypeURL(cachetypes.ResponseType(i))
+			if err != nil {
+				return nil, err
+			}
+			snapshot.Resources[i].Version = persisted.Versions[typeURL]
+		}
+		// The resources keep the versions they were published with, so gateways
+		// reconnecting with delta xDS aren't sent unchanged resources again once they
+		// are generated again. Files written without them fall back to hashing the
+		// resources.
+		if persisted.ResourceVersions != nil {
+			snapshot.VersionMap = persisted.ResourceVersions
+		}
+		if err := snapshot.ConstructVersionMap(); err != nil {
+			return nil, err
+		}
+		if err := snapshot.Consistent(); err != nil {
+			return nil, fmt.Errorf("inconsistent snapshot for node %q: %w", nodeID, err)
+		}
+		snapshots[nodeID] = snapshot
+	}
+	return snapshots, nil
+}
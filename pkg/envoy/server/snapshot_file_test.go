@@ -0,0 +1,77 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestSnapshotsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.json")
+
+	_, err := ReadSnapshotsFile(path)
+	assert.Assert(t, errors.Is(err, fs.ErrNotExist), err)
+
+	snapshots := map[string]*cache.Snapshot{
+		"gateway-a": newTestSnapshot(t, "v1", "cluster-1", "cluster-2"),
+		"gateway-b": newTestSnapshot(t, "v2"),
+	}
+	// The resources of gateway-a are versioned by the generator, those of gateway-b
+	// aren't.
+	snapshots["gateway-a"].VersionMap = map[string]map[string]string{
+		resource.ClusterType: {"cluster-1": "a", "cluster-2": "b"},
+	}
+	assert.NilError(t, WriteSnapshotsFile(path, snapshots))
+
+	info, err := os.Stat(path)
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o600))
+
+	restored, err := ReadSnapshotsFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(restored), len(snapshots))
+	for nodeID, snapshot := range snapshots {
+		assert.Equal(t, restored[nodeID].GetVersion(resource.ClusterType), snapshot.GetVersion(resource.ClusterType))
+		assert.DeepEqual(t, restored[nodeID].GetResources(resource.ClusterType), snapshot.GetResources(resource.ClusterType), protocmp.Transform(), cmpopts.EquateEmpty())
+
+		// The resources keep their versions, or are versioned by content if they had
+		// none.
+		assert.NilError(t, snapshot.ConstructVersionMap())
+		assert.DeepEqual(t, restored[nodeID].GetVersionMap(resource.ClusterType), snapshot.GetVersionMap(resource.ClusterType))
+	}
+
+	// Writing again replaces the file.
+	assert.NilError(t, WriteSnapshotsFile(path, map[string]*cache.Snapshot{"gateway-a": newTestSnapshot(t, "v3")}))
+	restored, err = ReadSnapshotsFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(restored), 1)
+	assert.Equal(t, restored["gateway-a"].GetVersion(resource.ClusterType), "v3")
+
+	assert.NilError(t, os.WriteFile(path, []byte("garbage"), 0o600))
+	_, err = ReadSnapshotsFile(path)
+	assert.ErrorContains(t, err, "failed to parse")
+}
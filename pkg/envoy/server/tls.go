@@ -0,0 +1,173 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync/atomic"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// TLSConfig configures TLS on the management server. It is read from the
+// environment with the KOURIER_XDS_TLS prefix.
+type TLSConfig struct {
+	// CertFile and KeyFile hold the serving certificate. TLS is disabled if they're
+	// not set. They're read again on every handshake, so that rotated certificates
+	// are picked up without a restart.
+	CertFile string `envconfig:"CERT_FILE"`
+	KeyFile  string `envconfig:"KEY_FILE"`
+
+	// ClientCAFile holds the CA bundle client certificates are verified against. If
+	// set, gateways must present a certificate issued by one of the CAs, and the
+	// node ID they announce must match one of the identities of the certificate.
+	ClientCAFile string `envconfig:"CLIENT_CA_FILE"`
+
+	// HealthPort is the port the gRPC health service is additionally served on
+	// without TLS, as kubelet can't probe gRPC services over TLS. 0 disables it.
+	HealthPort uint `envconfig:"HEALTH_PORT"`
+}
+
+// Enabled returns whether TLS is to be used at all.
+func (c *TLSConfig) Enabled() bool {
+	return c != nil && c.CertFile != ""
+}
+
+// Validate makes sure the configured files can be loaded.
+func (c *TLSConfig) Validate() error {
+	if !c.Enabled() {
+		if c != nil && (c.KeyFile != "" || c.ClientCAFile != "") {
+			return errors.New("a certificate must be configured to enable TLS")
+		}
+		return nil
+	}
+	_, err := c.load()
+	return err
+}
+
+// serverConfig returns the TLS config to serve with.
+func (c *TLSConfig) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return c.load()
+		},
+	}
+}
+
+func (c *TLSConfig) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load xDS serving certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		// gRPC requires HTTP/2 to be negotiated.
+		NextProtos: []string{"h2"},
+	}
+
+	if c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read xDS client CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in xDS client CA bundle %s", c.ClientCAFile)
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = pool
+	}
+	return cfg, nil
+}
+
+// nodeIdentityInterceptor rejects discovery requests whose node ID doesn't match
+// the identity of the client certificate the stream was opened with.
+func nodeIdentityInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	identities, err := peerIdentities(ss.Context())
+	if err != nil {
+		return err
+	}
+	stream := &nodeIdentityStream{ServerStream: ss, identities: identities}
+	err = handler(srv, stream)
+	// The xDS server ends the stream on any receive error without passing it on.
+	if denied := stream.denied.Load(); denied != nil {
+		return *denied
+	}
+	return err
+}
+
+type nodeIdentityStream struct {
+	grpc.ServerStream
+	identities []string
+	denied     atomic.Pointer[error]
+}
+
+// RecvMsg implements grpc.ServerStream.
+func (s *nodeIdentityStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	req, ok := m.(interface{ GetNode() *core.Node })
+	if !ok || req.GetNode() == nil {
+		// Only the first request of a stream is required to carry the node.
+		return nil
+	}
+	if id := req.GetNode().GetId(); !slices.Contains(s.identities, id) {
+		err := status.Errorf(codes.PermissionDenied, "node ID %q does not match the client certificate", id)
+		s.denied.Store(&err)
+		return err
+	}
+	return nil
+}
+
+// peerIdentities returns the identities of the verified client certificate: its
+// DNS and URI SANs, and its common name.
+func peerIdentities(ctx context.Context) ([]string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "no peer information")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "no verified client certificate")
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	identities := make([]string, 0, len(cert.DNSNames)+len(cert.URIs)+1)
+	identities = append(identities, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	return identities, nil
+}
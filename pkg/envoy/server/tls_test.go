@@ -0,0 +1,174 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"gotest.tools/v3/assert"
+)
+
+func TestXdsServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, nil, nil, "ca")
+	writeTestPEM(t, filepath.Join(dir, "ca.crt"), "CERTIFICATE", ca.Raw)
+	serving, servingKey := newTestCertificate(t, ca, caKey, "localhost")
+	writeTestPEM(t, filepath.Join(dir, "tls.crt"), "CERTIFICATE", serving.Raw)
+	writeTestKey(t, filepath.Join(dir, "tls.key"), servingKey)
+
+	tlsConfig := &TLSConfig{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	assert.NilError(t, tlsConfig.Validate())
+
+	xdsServer := NewXdsServer(context.Background(), 0, &xds.CallbackFuncs{}, WithTLS(tlsConfig))
+	assert.NilError(t, xdsServer.SetSnapshot(testNodeID, newTestSnapshot(t, "1", "foo")))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	grpcServer := xdsServer.newGRPCServer()
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	tests := []struct {
+		name       string
+		clientName string
+		nodeID     string
+		wantCode   codes.Code
+	}{{
+		name:       "matching node ID",
+		clientName: testNodeID,
+		nodeID:     testNodeID,
+		wantCode:   codes.OK,
+	}, {
+		name:       "mismatching node ID",
+		clientName: "other-gateway",
+		nodeID:     testNodeID,
+		wantCode:   codes.PermissionDenied,
+	}, {
+		name:     "no client certificate",
+		nodeID:   testNodeID,
+		wantCode: codes.Unavailable,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clientTLS := &tls.Config{RootCAs: roots, ServerName: "localhost", MinVersion: tls.VersionTLS12}
+			if test.clientName != "" {
+				cert, key := newTestCertificate(t, ca, caKey, test.clientName)
+				clientTLS.Certificates = []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}}
+			}
+
+			conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+			assert.NilError(t, err)
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			stream, err := discovery.NewAggregatedDiscoveryServiceClient(conn).StreamAggregatedResources(ctx)
+			if err == nil {
+				err = stream.Send(&discovery.DiscoveryRequest{
+					Node:    &core.Node{Id: test.nodeID},
+					TypeUrl: resource.ClusterType,
+				})
+			}
+			if err == nil {
+				var resp *discovery.DiscoveryResponse
+				resp, err = stream.Recv()
+				if err == nil {
+					assert.Equal(t, resp.GetVersionInfo(), "1")
+				}
+			}
+			assert.Equal(t, status.Code(err), test.wantCode, "error: %v", err)
+		})
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	assert.NilError(t, (*TLSConfig)(nil).Validate())
+	assert.NilError(t, (&TLSConfig{}).Validate())
+	assert.ErrorContains(t, (&TLSConfig{ClientCAFile: "ca.crt"}).Validate(), "certificate must be configured")
+	assert.ErrorContains(t, (&TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"}).Validate(), "failed to load")
+}
+
+func newTestCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NilError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	return cert, key
+}
+
+func writeTestPEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	assert.NilError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+}
+
+func writeTestKey(t *testing.T, path string, key *ecdsa.PrivateKey) {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+	writeTestPEM(t, path, "EC PRIVATE KEY", der)
+}
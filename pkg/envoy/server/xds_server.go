@@ -20,90 +20,335 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	cluster "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
+	secret "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
 	health "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 )
 
 const (
 	grpcMaxConcurrentStreams = 1000000
+
+	// defaultShutdownGracePeriod is how long existing streams are served when the
+	// server shuts down.
+	defaultShutdownGracePeriod = 5 * time.Second
+
+	// streamCloseTimeout is how long ended streams get to close before their
+	// connections are cut.
+	streamCloseTimeout = time.Second
+
+	// degradedAfterFailures is the number of consecutive failures to generate a
+	// snapshot after which the server reports itself as not serving.
+	degradedAfterFailures = 3
 )
 
+// discoveryServices are the discovery services the health of is reported
+// individually.
+var discoveryServices = []string{
+	discovery.AggregatedDiscoveryService_ServiceDesc.ServiceName,
+	cluster.ClusterDiscoveryService_ServiceDesc.ServiceName,
+	endpoint.EndpointDiscoveryService_ServiceDesc.ServiceName,
+	listener.ListenerDiscoveryService_ServiceDesc.ServiceName,
+	route.RouteDiscoveryService_ServiceDesc.ServiceName,
+	secret.SecretDiscoveryService_ServiceDesc.ServiceName,
+}
+
 type XdsServer struct {
 	managementPort uint
-	ctx            context.Context
-	server         xds.Server
-	snapshotCache  cache.SnapshotCache
+	// ctx is the context the server is shut down with.
+	ctx context.Context
+	// cancelStreams ends all streams.
+	cancelStreams       context.CancelFunc
+	shutdownGracePeriod time.Duration
+	server              xds.Server
+	snapshotCache       cache.SnapshotCache
+	tls                 *TLSConfig
+
+	// health reports the state of the control plane. See updateHealth.
+	health *grpchealth.Server
+
+	// nodeIDs holds the nodes snapshots were set for.
+	mu      sync.Mutex
+	nodeIDs map[string]struct{}
+	// seeded is true once the snapshots were primed. See MarkSeeded.
+	seeded bool
+	// failures counts the consecutive failures to generate a snapshot.
+	failures int
+}
+
+// Option configures an XdsServer.
+type Option func(*XdsServer)
+
+// WithTLS serves the management server over TLS as configured.
+func WithTLS(tlsConfig *TLSConfig) Option {
+	return func(s *XdsServer) {
+		s.tls = tlsConfig
+	}
 }
 
-func NewXdsServer(managementPort uint, callbacks xds.Callbacks) *XdsServer {
-	ctx := context.Background()
+// WithShutdownGracePeriod sets how long existing streams are served when the
+// server shuts down.
+func WithShutdownGracePeriod(d time.Duration) Option {
+	return func(s *XdsServer) {
+		s.shutdownGracePeriod = d
+	}
+}
+
+// NewXdsServer creates a new management server. The server answers both
+// state-of-the-world and incremental (delta) xDS requests from the same snapshot
+// cache, so gateways can opt into delta updates by setting the `api_type` of
+// their ADS config to DELTA_GRPC. The server shuts down gracefully once the given
+// context is done.
+func NewXdsServer(ctx context.Context, managementPort uint, callbacks xds.Callbacks, opts ...Option) *XdsServer {
+	// The streams outlive the given context for the grace period of the shutdown.
+	streamCtx, cancelStreams := context.WithCancel(context.WithoutCancel(ctx))
 	snapshotCache := cache.NewSnapshotCache(true, cache.IDHash{}, nil)
-	srv := xds.NewServer(ctx, snapshotCache, callbacks)
+	srv := xds.NewServer(streamCtx, snapshotCache, callbacks)
 
-	return &XdsServer{
-		managementPort: managementPort,
-		ctx:            ctx,
-		server:         srv,
-		snapshotCache:  snapshotCache,
+	s := &XdsServer{
+		managementPort:      managementPort,
+		ctx:                 ctx,
+		cancelStreams:       cancelStreams,
+		shutdownGracePeriod: defaultShutdownGracePeriod,
+		server:              srv,
+		snapshotCache:       snapshotCache,
+		health:              grpchealth.NewServer(),
+		nodeIDs:             make(map[string]struct{}),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.updateHealth()
+	return s
 }
 
-type healthServer struct {
-	health.UnimplementedHealthServer
+// SnapshotFailed records a failure to generate a snapshot. The server reports
+// itself as not serving once generating snapshots keeps failing, until a snapshot
+// is set again.
+func (envoyXdsServer *XdsServer) SnapshotFailed() {
+	envoyXdsServer.mu.Lock()
+	defer envoyXdsServer.mu.Unlock()
+
+	envoyXdsServer.failures++
+	envoyXdsServer.updateHealth()
 }
 
-// Check implements the HealthServer interface.
-func (healthServer) Check(context.Context, *health.HealthCheckRequest) (*health.HealthCheckResponse, error) {
-	return &health.HealthCheckResponse{Status: health.HealthCheckResponse_SERVING}, nil
+// MarkSeeded records that the snapshots set are primed, i.e. generated from a
+// complete view of the config or restored from a previous run. Snapshots set
+// before, like the empty ones published while the informers sync, don't make the
+// server report itself as serving.
+func (envoyXdsServer *XdsServer) MarkSeeded() {
+	envoyXdsServer.mu.Lock()
+	defer envoyXdsServer.mu.Unlock()
+
+	envoyXdsServer.seeded = true
+	envoyXdsServer.updateHealth()
 }
 
-// RunManagementServer starts an xDS server at the given Port.
+// updateHealth updates the reported health. Everything is reported as not serving
+// until the server is marked as seeded. The overall health of the server, reported for
+// the empty service name, is not serving while generating snapshots keeps failing.
+// The discovery services keep serving the last snapshot in that case.
+func (envoyXdsServer *XdsServer) updateHealth() {
+	servingStatus := func(serving bool) health.HealthCheckResponse_ServingStatus {
+		if serving {
+			return health.HealthCheckResponse_SERVING
+		}
+		return health.HealthCheckResponse_NOT_SERVING
+	}
+
+	seeded := envoyXdsServer.seeded
+	envoyXdsServer.health.SetServingStatus("", servingStatus(seeded && envoyXdsServer.failures < degradedAfterFailures))
+	for _, service := range discoveryServices {
+		envoyXdsServer.health.SetServingStatus(service, servingStatus(seeded))
+	}
+}
+
+// RunManagementServer starts an xDS server at the given Port. It returns once the
+// context the server was created with is done and the server was shut down.
 func (envoyXdsServer *XdsServer) RunManagementServer() error {
 	port := envoyXdsServer.managementPort
-	server := envoyXdsServer.server
 
-	grpcServer := grpc.NewServer(
-		grpc.MaxConcurrentStreams(grpcMaxConcurrentStreams),
-		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 20 * time.Second, PermitWithoutStream: true}),
-	)
 	//nolint:noctx // context.Done is handled below explicitly
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	// register services
-	discovery.RegisterAggregatedDiscoveryServiceServer(grpcServer, server)
-	health.RegisterHealthServer(grpcServer, healthServer{})
-	cluster.RegisterClusterDiscoveryServiceServer(grpcServer, server)
-	listener.RegisterListenerDiscoveryServiceServer(grpcServer, server)
-	route.RegisterRouteDiscoveryServiceServer(grpcServer, server)
+	var healthLis net.Listener
+	if envoyXdsServer.tls.Enabled() && envoyXdsServer.tls.HealthPort != 0 {
+		//nolint:noctx // context.Done is handled below explicitly
+		healthLis, err = net.Listen("tcp", fmt.Sprintf(":%d", envoyXdsServer.tls.HealthPort))
+		if err != nil {
+			lis.Close()
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+	}
+	return envoyXdsServer.serve(lis, healthLis)
+}
+
+// serve serves the discovery services on the given listener and, if not nil, the
+// health service on healthLis.
+func (envoyXdsServer *XdsServer) serve(lis, healthLis net.Listener) error {
+	grpcServer := envoyXdsServer.newGRPCServer()
 
-	errCh := make(chan error)
+	errCh := make(chan error, 2)
 	go func() {
-		if err = grpcServer.Serve(lis); err != nil {
+		if err := grpcServer.Serve(lis); err != nil {
 			errCh <- err
 		}
 	}()
 
+	if healthLis != nil {
+		healthServer := envoyXdsServer.newHealthGRPCServer()
+		defer healthServer.Stop()
+
+		go func() {
+			if err := healthServer.Serve(healthLis); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
 	select {
 	case <-envoyXdsServer.ctx.Done():
-		grpcServer.GracefulStop()
+		envoyXdsServer.shutdown(grpcServer)
 		return nil
 	case err := <-errCh:
+		grpcServer.Stop()
+		envoyXdsServer.cancelStreams()
 		return fmt.Errorf("failed to serve: %w", err)
 	}
 }
 
+// shutdown stops the given server gracefully. New connections and streams are
+// refused right away and the connected gateways get a GOAWAY, so that they
+// reconnect to another replica. Existing streams are still served for the grace
+// period and ended cleanly afterwards.
+func (envoyXdsServer *XdsServer) shutdown(grpcServer *grpc.Server) {
+	envoyXdsServer.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return
+	case <-time.After(envoyXdsServer.shutdownGracePeriod):
+	}
+
+	// Ending the streams lets the gateways see them closed with an OK status
+	// rather than their connection being cut.
+	envoyXdsServer.cancelStreams()
+	select {
+	case <-stopped:
+	case <-time.After(streamCloseTimeout):
+		grpcServer.Stop()
+		<-stopped
+	}
+}
+
+// newGRPCServer creates a gRPC server with all the discovery services registered.
+// Every discovery service carries both its state-of-the-world and its delta
+// streaming methods.
+func (envoyXdsServer *XdsServer) newGRPCServer() *grpc.Server {
+	server := envoyXdsServer.server
+
+	opts := []grpc.ServerOption{
+		grpc.MaxConcurrentStreams(grpcMaxConcurrentStreams),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 20 * time.Second, PermitWithoutStream: true}),
+	}
+	if tlsConfig := envoyXdsServer.tls; tlsConfig.Enabled() {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig.serverConfig())))
+		if tlsConfig.ClientCAFile != "" {
+			opts = append(opts, grpc.StreamInterceptor(nodeIdentityInterceptor))
+		}
+	}
+	grpcServer := grpc.NewServer(opts...)
+
+	// register services
+	discovery.RegisterAggregatedDiscoveryServiceServer(grpcServer, server)
+	health.RegisterHealthServer(grpcServer, envoyXdsServer.health)
+	cluster.RegisterClusterDiscoveryServiceServer(grpcServer, server)
+	endpoint.RegisterEndpointDiscoveryServiceServer(grpcServer, server)
+	listener.RegisterListenerDiscoveryServiceServer(grpcServer, server)
+	route.RegisterRouteDiscoveryServiceServer(grpcServer, server)
+	secret.RegisterSecretDiscoveryServiceServer(grpcServer, server)
+
+	return grpcServer
+}
+
+// newHealthGRPCServer creates a gRPC server with only the health service
+// registered, to be served without TLS.
+func (envoyXdsServer *XdsServer) newHealthGRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer()
+	health.RegisterHealthServer(grpcServer, envoyXdsServer.health)
+	return grpcServer
+}
+
 func (envoyXdsServer *XdsServer) SetSnapshot(nodeID string, snapshot cache.ResourceSnapshot) error {
-	return envoyXdsServer.snapshotCache.SetSnapshot(context.Background(), nodeID, snapshot)
+	envoyXdsServer.mu.Lock()
+	defer envoyXdsServer.mu.Unlock()
+
+	return envoyXdsServer.setSnapshot(nodeID, snapshot)
+}
+
+func (envoyXdsServer *XdsServer) setSnapshot(nodeID string, snapshot cache.ResourceSnapshot) error {
+	if err := envoyXdsServer.snapshotCache.SetSnapshot(context.Background(), nodeID, snapshot); err != nil {
+		return err
+	}
+	envoyXdsServer.nodeIDs[nodeID] = struct{}{}
+	envoyXdsServer.failures = 0
+	envoyXdsServer.updateHealth()
+	return nil
+}
+
+// SetSnapshots sets the snapshots of all nodes at once, keyed by node ID. The
+// snapshots of nodes that were set before but are not part of the given ones are
+// cleared.
+func (envoyXdsServer *XdsServer) SetSnapshots(snapshots map[string]*cache.Snapshot) error {
+	envoyXdsServer.mu.Lock()
+	defer envoyXdsServer.mu.Unlock()
+
+	for nodeID := range envoyXdsServer.nodeIDs {
+		if _, ok := snapshots[nodeID]; !ok {
+			envoyXdsServer.snapshotCache.ClearSnapshot(nodeID)
+			delete(envoyXdsServer.nodeIDs, nodeID)
+		}
+	}
+	for nodeID, snapshot := range snapshots {
+		if err := envoyXdsServer.setSnapshot(nodeID, snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshots returns the current snapshots of all nodes, keyed by node ID.
+func (envoyXdsServer *XdsServer) Snapshots() map[string]cache.ResourceSnapshot {
+	envoyXdsServer.mu.Lock()
+	defer envoyXdsServer.mu.Unlock()
+
+	snapshots := make(map[string]cache.ResourceSnapshot, len(envoyXdsServer.nodeIDs))
+	for nodeID := range envoyXdsServer.nodeIDs {
+		if snapshot, err := envoyXdsServer.snapshotCache.GetSnapshot(nodeID); err == nil {
+			snapshots[nodeID] = snapshot
+		}
+	}
+	return snapshots
 }
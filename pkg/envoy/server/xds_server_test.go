@@ -0,0 +1,227 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"gotest.tools/v3/assert"
+)
+
+const testNodeID = "test-gateway"
+
+func TestXdsServerSotWAndDelta(t *testing.T) {
+	xdsServer := NewXdsServer(context.Background(), 0, &xds.CallbackFuncs{})
+	assert.NilError(t, xdsServer.SetSnapshot(testNodeID, newTestSnapshot(t, "1", "foo", "bar")))
+
+	client := startTestServer(t, xdsServer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("state of the world", func(t *testing.T) {
+		stream, err := client.StreamAggregatedResources(ctx)
+		assert.NilError(t, err)
+		assert.NilError(t, stream.Send(&discovery.DiscoveryRequest{
+			Node:    &core.Node{Id: testNodeID},
+			TypeUrl: resource.ClusterType,
+		}))
+
+		resp, err := stream.Recv()
+		assert.NilError(t, err)
+		assert.Equal(t, resp.GetVersionInfo(), "1")
+		assert.Equal(t, len(resp.GetResources()), 2)
+	})
+
+	t.Run("delta", func(t *testing.T) {
+		stream, err := client.DeltaAggregatedResources(ctx)
+		assert.NilError(t, err)
+		assert.NilError(t, stream.Send(&discovery.DeltaDiscoveryRequest{
+			Node:    &core.Node{Id: testNodeID},
+			TypeUrl: resource.ClusterType,
+		}))
+
+		resp, err := stream.Recv()
+		assert.NilError(t, err)
+		assert.Equal(t, len(resp.GetResources()), 2)
+
+		// ACK the initial response and only change one of the clusters.
+		assert.NilError(t, stream.Send(&discovery.DeltaDiscoveryRequest{
+			Node:          &core.Node{Id: testNodeID},
+			TypeUrl:       resource.ClusterType,
+			ResponseNonce: resp.GetNonce(),
+		}))
+		snapshot := newTestSnapshot(t, "2", "foo", "baz")
+		assert.NilError(t, xdsServer.SetSnapshot(testNodeID, snapshot))
+
+		resp, err = stream.Recv()
+		assert.NilError(t, err)
+		assert.Equal(t, len(resp.GetResources()), 1)
+		assert.Equal(t, resp.GetResources()[0].GetName(), "baz")
+		assert.DeepEqual(t, resp.GetRemovedResources(), []string{"bar"})
+	})
+}
+
+func TestXdsServerHealth(t *testing.T) {
+	xdsServer := NewXdsServer(context.Background(), 0, &xds.CallbackFuncs{})
+	client := health.NewHealthClient(dialTestServer(t, xdsServer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	check := func(service string) health.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := client.Check(ctx, &health.HealthCheckRequest{Service: service})
+		assert.NilError(t, err)
+		return resp.GetStatus()
+	}
+	lds := listener.ListenerDiscoveryService_ServiceDesc.ServiceName
+
+	// Nothing is served before the snapshots are primed.
+	assert.Equal(t, check(""), health.HealthCheckResponse_NOT_SERVING)
+	assert.Equal(t, check(lds), health.HealthCheckResponse_NOT_SERVING)
+
+	assert.NilError(t, xdsServer.SetSnapshot(testNodeID, newTestSnapshot(t, "1", "foo")))
+	assert.Equal(t, check(""), health.HealthCheckResponse_NOT_SERVING)
+	assert.Equal(t, check(lds), health.HealthCheckResponse_NOT_SERVING)
+
+	xdsServer.MarkSeeded()
+	assert.Equal(t, check(""), health.HealthCheckResponse_SERVING)
+	assert.Equal(t, check(lds), health.HealthCheckResponse_SERVING)
+
+	// Single failures are tolerated.
+	for range degradedAfterFailures - 1 {
+		xdsServer.SnapshotFailed()
+	}
+	assert.Equal(t, check(""), health.HealthCheckResponse_SERVING)
+
+	// The last snapshot is still served once generating snapshots keeps failing.
+	xdsServer.SnapshotFailed()
+	assert.Equal(t, check(""), health.HealthCheckResponse_NOT_SERVING)
+	assert.Equal(t, check(lds), health.HealthCheckResponse_SERVING)
+
+	assert.NilError(t, xdsServer.SetSnapshots(map[string]*cache.Snapshot{testNodeID: newTestSnapshot(t, "2", "foo")}))
+	assert.Equal(t, check(""), health.HealthCheckResponse_SERVING)
+}
+
+func TestXdsServerGracefulShutdown(t *testing.T) {
+	const gracePeriod = 200 * time.Millisecond
+
+	serverCtx, shutdown := context.WithCancel(context.Background())
+	xdsServer := NewXdsServer(serverCtx, 0, &xds.CallbackFuncs{}, WithShutdownGracePeriod(gracePeriod))
+	assert.NilError(t, xdsServer.SetSnapshot(testNodeID, newTestSnapshot(t, "1", "foo")))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- xdsServer.serve(lis, nil) }()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NilError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := discovery.NewAggregatedDiscoveryServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamAggregatedResources(ctx)
+	assert.NilError(t, err)
+	assert.NilError(t, stream.Send(&discovery.DiscoveryRequest{
+		Node:    &core.Node{Id: testNodeID},
+		TypeUrl: resource.ClusterType,
+	}))
+	_, err = stream.Recv()
+	assert.NilError(t, err)
+
+	start := time.Now()
+	shutdown()
+
+	// The open stream is served for the grace period and then ended cleanly.
+	_, err = stream.Recv()
+	assert.Equal(t, err, io.EOF)
+	assert.Assert(t, time.Since(start) >= gracePeriod)
+
+	select {
+	case err := <-served:
+		assert.NilError(t, err)
+	case <-ctx.Done():
+		t.Fatal("server did not shut down")
+	}
+
+	// New streams are refused.
+	newStream, err := client.StreamAggregatedResources(ctx)
+	if err == nil {
+		_, err = newStream.Recv()
+	}
+	assert.Assert(t, err != nil)
+}
+
+func startTestServer(t *testing.T, xdsServer *XdsServer) discovery.AggregatedDiscoveryServiceClient {
+	t.Helper()
+	return discovery.NewAggregatedDiscoveryServiceClient(dialTestServer(t, xdsServer))
+}
+
+func dialTestServer(t *testing.T, xdsServer *XdsServer) *grpc.ClientConn {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+
+	grpcServer := xdsServer.newGRPCServer()
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NilError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newTestSnapshot(t *testing.T, version string, clusterNames ...string) *cache.Snapshot {
+	t.Helper()
+
+	clusters := make([]cachetypes.Resource, 0, len(clusterNames))
+	for _, name := range clusterNames {
+		clusters = append(clusters, &clusterv3.Cluster{
+			Name:           name,
+			ConnectTimeout: durationpb.New(time.Second),
+		})
+	}
+
+	snapshot, err := cache.NewSnapshot(version, map[resource.Type][]cachetypes.Resource{
+		resource.ClusterType:  clusters,
+		resource.ListenerType: nil,
+	})
+	assert.NilError(t, err)
+	return snapshot
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// resourceNameRegexp matches the ingress encoded in the names of the virtual hosts
+// and routes, e.g. "(namespace/name).Domain[example.com]".
+var resourceNameRegexp = regexp.MustCompile(`\(([^()/\s]+)/([^()/\s]+)\)\.Domain\[`)
+
+// IngressesForNACK returns the ingresses the resources named in the error message
+// of a rejected snapshot were generated for. Resources are matched by the ingress
+// encoded in route and virtual host names, by cluster name, by domain and by the
+// server names of filter chains.
+func (caches *Caches) IngressesForNACK(message string) []types.NamespacedName {
+	caches.mu.Lock()
+	defer caches.mu.Unlock()
+
+	ingresses := sets.New[types.NamespacedName]()
+	for _, match := range resourceNameRegexp.FindAllStringSubmatch(message, -1) {
+		key := types.NamespacedName{Namespace: match[1], Name: match[2]}
+		if _, ok := caches.translatedIngresses[key]; ok {
+			ingresses.Insert(key)
+		}
+	}
+
+	tokens := messageTokens(message)
+	for key, translated := range caches.translatedIngresses {
+		if ingresses.Has(key) {
+			continue
+		}
+		if slices.ContainsFunc(translated.resourceNames(), func(name string) bool { return tokens.Has(name) }) {
+			ingresses.Insert(key)
+		}
+	}
+	return slices.SortedFunc(maps.Keys(ingresses), compareNamespacedNames)
+}
+
+// messageTokens splits the given error message into the words that might name a
+// resource.
+func messageTokens(message string) sets.Set[string] {
+	tokens := sets.New[string]()
+	for _, field := range strings.FieldsFunc(message, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("'\"`,;()[]{}", r)
+	}) {
+		if token := strings.TrimRight(field, ".:"); token != "" {
+			tokens.Insert(token)
+		}
+	}
+	return tokens
+}
+
+// resourceNames returns the names of the clusters, domains and server names the
+// translation generates or references.
+func (t *translatedIngress) resourceNames() []string {
+	var names []string
+	for _, cluster := range t.clusters {
+		names = append(names, cluster.GetName())
+	}
+	for _, virtualHosts := range [][]*route.VirtualHost{
+		t.externalVirtualHosts, t.externalTLSVirtualHosts,
+		t.localVirtualHosts, t.localTLSVirtualHosts,
+	} {
+		for _, virtualHost := range virtualHosts {
+			names = append(names, virtualHost.GetDomains()...)
+			for _, r := range virtualHost.GetRoutes() {
+				names = append(names, routeClusters(r)...)
+			}
+		}
+	}
+	for _, match := range slices.Concat(t.externalSNIMatches, t.localSNIMatches) {
+		names = append(names, match.Hosts...)
+	}
+	return names
+}
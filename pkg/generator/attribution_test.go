@@ -0,0 +1,75 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+)
+
+func TestIngressesForNACK(t *testing.T) {
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+	caches, err := NewCaches(ctx, fake.NewSimpleClientset())
+	assert.NilError(t, err)
+
+	// Ingress 0 serves TLS, see benchmarkTranslatedIngress.
+	tls := benchmarkTranslatedIngress(0, "rev-1")
+	plain := benchmarkTranslatedIngress(1, "rev-1")
+	assert.NilError(t, caches.UpdateIngress(ctx, tls))
+	assert.NilError(t, caches.UpdateIngress(ctx, plain))
+
+	tests := []struct {
+		name    string
+		message string
+		want    []types.NamespacedName
+	}{{
+		name:    "route name",
+		message: "Proto constraint validation failed (RouteValidationError.Name: value length must be at least 1 runes): (ns-1/ingress-1).Domain[ingress-1.ns-1.example.com].Paths[/]",
+		want:    []types.NamespacedName{plain.name},
+	}, {
+		name:    "unknown weighted cluster",
+		message: "route: unknown weighted cluster 'ns-1/rev-1'",
+		want:    []types.NamespacedName{plain.name},
+	}, {
+		name:    "duplicate domain",
+		message: "Only unique values for domains are permitted. Duplicate entry of domain ingress-0.ns-0.example.com in route external_services",
+		want:    []types.NamespacedName{tls.name},
+	}, {
+		name:    "server name",
+		message: "error adding listener '0.0.0.0:8443': multiple filter chains with overlapping matching rules are defined: server name 'ingress-0.ns-0.example.com'",
+		want:    []types.NamespacedName{tls.name},
+	}, {
+		name:    "unknown ingress",
+		message: "route: unknown weighted cluster 'other/service'",
+	}, {
+		name:    "deleted ingress in route name",
+		message: "invalid route (ns-2/ingress-2).Domain[ingress-2.ns-2.example.com].Paths[/]",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := caches.IngressesForNACK(test.message)
+			assert.DeepEqual(t, got, test.want, cmpopts.EquateEmpty())
+		})
+	}
+}
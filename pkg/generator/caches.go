@@ -17,17 +17,23 @@ limitations under the License.
 package generator
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
 
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	httpconnmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	cache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
-	"github.com/google/uuid"
+	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -35,6 +41,7 @@ import (
 	envoy "knative.dev/net-kourier/pkg/envoy/api"
 	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
 	"knative.dev/networking/pkg/certificates"
+	"knative.dev/pkg/logging"
 	"knative.dev/pkg/system"
 )
 
@@ -55,6 +62,17 @@ type Caches struct {
 	domainsInUse        sets.Set[string]
 	statusVirtualHost   *route.VirtualHost
 
+	// fleets holds the per-fleet state, keyed by the node ID of the gateways.
+	fleets map[string]*gatewayFleet
+
+	// revision is incremented on every change to the caches.
+	revision uint64
+
+	// rejected holds the ingresses evicted because their config failed validation,
+	// so that the same translation is not admitted again.
+	rejected   map[types.NamespacedName]*rejection
+	onRejected func(types.NamespacedName, error)
+
 	kubeClient kubeclient.Interface
 }
 
@@ -64,11 +82,14 @@ func NewCaches(ctx context.Context, kubernetesClient kubeclient.Interface) (*Cac
 		clusters:            newClustersCache(),
 		domainsInUse:        sets.New[string](),
 		statusVirtualHost:   statusVHost(),
+		fleets:              make(map[string]*gatewayFleet),
+		rejected:            make(map[types.NamespacedName]*rejection),
 		kubeClient:          kubernetesClient,
 	}
 
 	if config.FromContext(ctx).Kourier.ExternalAuthz.Enabled {
-		c.clusters.set(config.FromContext(ctx).Kourier.ExternalAuthz.Cluster(), "__extAuthZCluster", "_internal")
+		// The external authorization cluster is served to all fleets.
+		c.clusters.set(config.FromContext(ctx).Kourier.ExternalAuthz.Cluster(), nil, "", "__extAuthZCluster", "_internal")
 	}
 	return c, nil
 }
@@ -79,10 +100,36 @@ func (caches *Caches) UpdateIngress(_ context.Context, ingressTranslation *trans
 	caches.mu.Lock()
 	defer caches.mu.Unlock()
 
+	if rejected, ok := caches.rejected[ingressTranslation.name]; ok {
+		if rejected.translated.equal(ingressTranslation) {
+			return rejected.err
+		}
+		delete(caches.rejected, ingressTranslation.name)
+	}
+
+	caches.revision++
 	caches.deleteTranslatedIngress(ingressTranslation.name.Name, ingressTranslation.name.Namespace)
 	return caches.addTranslatedIngress(ingressTranslation)
 }
 
+// Revision returns the current revision of the caches. It grows with every change,
+// so a snapshot generated after reading a revision contains all changes up to it.
+func (caches *Caches) Revision() uint64 {
+	caches.mu.Lock()
+	defer caches.mu.Unlock()
+	return caches.revision
+}
+
+// GatewayFleet returns the fleet of gateways the given ingress is served by.
+func (caches *Caches) GatewayFleet(key types.NamespacedName) string {
+	caches.mu.Lock()
+	defer caches.mu.Unlock()
+	if translated, ok := caches.translatedIngresses[key]; ok && translated.fleet != "" {
+		return translated.fleet
+	}
+	return config.DefaultGatewayFleet
+}
+
 func (caches *Caches) validateIngress(translatedIngress *translatedIngress) error {
 	for _, vhost := range translatedIngress.localVirtualHosts {
 		if caches.domainsInUse.HasAny(vhost.GetDomains()...) {
@@ -98,82 +145,204 @@ func (caches *Caches) addTranslatedIngress(translatedIngress *translatedIngress)
 		return err
 	}
 
+	fleetName := cmp.Or(translatedIngress.fleet, config.DefaultGatewayFleet)
+	fleet := caches.fleet(fleetName)
+	if err := fleet.setRouteTables(translatedIngress); err != nil {
+		fleet.deleteRouteTables(translatedIngress.name)
+		return err
+	}
+
 	for _, vhost := range translatedIngress.localVirtualHosts {
 		caches.domainsInUse.Insert(vhost.GetDomains()...)
 	}
 
 	caches.translatedIngresses[translatedIngress.name] = translatedIngress
+	fleet.addIngress(translatedIngress)
 
+	loadAssignments := make(map[string]*endpoint.ClusterLoadAssignment, len(translatedIngress.loadAssignments))
+	for _, loadAssignment := range translatedIngress.loadAssignments {
+		loadAssignments[loadAssignment.GetClusterName()] = loadAssignment
+	}
 	for _, cluster := range translatedIngress.clusters {
-		caches.clusters.set(cluster, translatedIngress.name.Name, translatedIngress.name.Namespace)
+		caches.clusters.set(cluster, loadAssignments[cluster.GetName()], fleetName, translatedIngress.name.Name, translatedIngress.name.Namespace)
 	}
 
 	return nil
 }
 
+// fleet returns the state of the given fleet, creating it if needed.
+func (caches *Caches) fleet(name string) *gatewayFleet {
+	if name == "" {
+		name = config.DefaultGatewayFleet
+	}
+	fleet, ok := caches.fleets[name]
+	if !ok {
+		fleet = newGatewayFleet()
+		caches.fleets[name] = fleet
+	}
+	return fleet
+}
+
 // SetOnEvicted allows to set a function that will be executed when any key on the cache expires.
 func (caches *Caches) SetOnEvicted(f func(types.NamespacedName, interface{})) {
-	caches.clusters.clusters.OnEvicted(func(key string, val interface{}) {
+	caches.clusters.setOnEvicted(func(key string, entry *clusterEntry) {
 		_, name, namespace := explodeKey(key)
 		f(types.NamespacedName{
 			Namespace: namespace,
 			Name:      name,
-		}, val)
+		}, entry)
 	})
 }
 
+// SetOnRejected allows to set a function that will be executed when an ingress is
+// evicted from the caches because its config failed validation.
+func (caches *Caches) SetOnRejected(f func(types.NamespacedName, error)) {
+	caches.mu.Lock()
+	defer caches.mu.Unlock()
+	caches.onRejected = f
+}
+
+// ToEnvoySnapshot returns the snapshot for the default fleet of gateways. Unlike
+// ToEnvoySnapshots, the snapshot is not validated.
 func (caches *Caches) ToEnvoySnapshot(ctx context.Context) (*cache.Snapshot, error) {
 	caches.mu.Lock()
 	defer caches.mu.Unlock()
 
-	localVHosts := make([]*route.VirtualHost, 0, len(caches.translatedIngresses)+1)
-	localTLSVHosts := make([]*route.VirtualHost, 0, len(caches.translatedIngresses)+1)
-	externalVHosts := make([]*route.VirtualHost, 0, len(caches.translatedIngresses))
-	externalTLSVHosts := make([]*route.VirtualHost, 0, len(caches.translatedIngresses))
+	snapshot, _, err := caches.toEnvoySnapshot(ctx, config.DefaultGatewayFleet)
+	return snapshot, err
+}
+
+// ToEnvoySnapshots returns the snapshots for all fleets of gateways, keyed by the
+// node ID of their gateways, along with the revision of the caches they were
+// generated from. Every snapshot only holds the ingresses selected for its fleet.
+//
+// The snapshots are validated before they're returned. Ingresses generating
+// invalid config are evicted from the caches and reported through the function
+// set with SetOnRejected. If a problem can't be attributed to an ingress, an error
+// is returned so that nothing is published. Evicting ingresses changes the caches,
+// so the returned revision can be later than the one read before the call.
+func (caches *Caches) ToEnvoySnapshots(ctx context.Context) (map[string]*cache.Snapshot, uint64, error) {
+	caches.mu.Lock()
+
+	names := config.FromContextOrDefaults(ctx).Kourier.GatewayFleetNames()
+	for name, fleet := range caches.fleets {
+		// Drop the state of fleets that were removed from the config, once all of
+		// their ingresses are gone too.
+		if !slices.Contains(names, name) && fleet.empty() {
+			delete(caches.fleets, name)
+		}
+	}
+
+	var rejected []snapshotProblem
+	snapshots, err := caches.validatedSnapshots(ctx, names, &rejected)
+	revision := caches.revision
+	onRejected := caches.onRejected
+	caches.mu.Unlock()
+
+	if onRejected != nil {
+		for _, problem := range rejected {
+			onRejected(problem.ingress, problem.err)
+		}
+	}
+	return snapshots, revision, err
+}
+
+// validatedSnapshots generates the snapshots of the given fleets until they pass
+// validation, evicting the ingresses the problems are attributed to along the way.
+// The evicted ingresses are appended to rejected.
+func (caches *Caches) validatedSnapshots(ctx context.Context, names []string, rejected *[]snapshotProblem) (map[string]*cache.Snapshot, error) {
+	for {
+		snapshots := make(map[string]*cache.Snapshot, len(names))
+		var problems []snapshotProblem
+		for _, name := range names {
+			snapshot, owners, err := caches.toEnvoySnapshot(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate snapshot for gateway fleet %q: %w", name, err)
+			}
+			for _, problem := range validateSnapshot(snapshot, owners) {
+				if problem.ingress == (types.NamespacedName{}) {
+					return nil, fmt.Errorf("invalid snapshot for gateway fleet %q: %w", name, problem.err)
+				}
+				problems = append(problems, problem)
+			}
+			snapshots[name] = snapshot
+		}
+		if len(problems) == 0 {
+			return snapshots, nil
+		}
+
+		caches.revision++
+		for _, problem := range problems {
+			translated, ok := caches.translatedIngresses[problem.ingress]
+			if !ok {
+				// Already evicted for another problem.
+				continue
+			}
+			logging.FromContext(ctx).Warnw("Rejecting ingress generating invalid config",
+				zap.Stringer("ingress", problem.ingress), zap.Error(problem.err))
+			caches.deleteTranslatedIngress(problem.ingress.Name, problem.ingress.Namespace)
+			caches.rejected[problem.ingress] = &rejection{translated: translated, err: problem.err}
+			*rejected = append(*rejected, problem)
+		}
+	}
+}
+
+// toEnvoySnapshot returns the snapshot of the given fleet, along with the owners of
+// its resources.
+func (caches *Caches) toEnvoySnapshot(ctx context.Context, fleetName string) (*cache.Snapshot, *resourceOwners, error) {
+	fleet := caches.fleet(fleetName)
+	// The owners only keep the ingresses of this snapshot, while the fleet is
+	// patched as ingresses are evicted.
+	owners := newResourceOwners(slices.Clone(fleet.ingresses))
+
 	localSNIs := sniMatches{}
 	externalSNIs := sniMatches{}
+	var upstreamSecrets []cachetypes.Resource
 
-	for _, translatedIngress := range caches.translatedIngresses {
-		localVHosts = append(localVHosts, translatedIngress.localVirtualHosts...)
-		localTLSVHosts = append(localTLSVHosts, translatedIngress.localTLSVirtualHosts...)
-		externalVHosts = append(externalVHosts, translatedIngress.externalVirtualHosts...)
-		externalTLSVHosts = append(externalTLSVHosts, translatedIngress.externalTLSVirtualHosts...)
-
+	// Iterate in a stable order so identical state always generates identical resources.
+	for _, translatedIngress := range fleet.ingresses {
 		for _, match := range translatedIngress.localSNIMatches {
 			localSNIs.consume(match)
 		}
 		for _, match := range translatedIngress.externalSNIMatches {
 			externalSNIs.consume(match)
 		}
+		if translatedIngress.upstreamTrustBundle != nil {
+			upstreamSecrets = append(upstreamSecrets, translatedIngress.upstreamTrustBundle)
+		}
 	}
 
-	// Append the statusHost too.
-	localVHosts = append(localVHosts, caches.statusVirtualHost)
-
-	listeners, routes, clusters, err := generateListenersAndRouteConfigsAndClusters(
+	listeners, routes, clusters, secrets, err := caches.generateListenersAndRouteConfigsAndClusters(
 		ctx,
-		externalVHosts,
-		externalTLSVHosts,
-		localVHosts,
-		localTLSVHosts,
+		fleet,
 		localSNIs.list(),
 		externalSNIs.list(),
-		caches.kubeClient,
 	)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	fleet.filterChains.commit()
 
-	clusters = append(caches.clusters.list(), clusters...)
+	clusters = append(caches.clusters.list(fleetName), clusters...)
+	secrets = append(secrets, upstreamSecrets...)
 
-	return cache.NewSnapshot(
-		uuid.NewString(),
+	snapshot, err := cache.NewSnapshot(
+		"",
 		map[resource.Type][]cachetypes.Resource{
 			resource.ClusterType:  clusters,
+			resource.EndpointType: caches.clusters.listLoadAssignments(fleetName),
 			resource.RouteType:    routes,
-			resource.ListenerType: listeners,
+			resource.ListenerType: dropEmptyListeners(listeners),
+			resource.SecretType:   secrets,
 		},
 	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := fleet.setSnapshotVersion(snapshot); err != nil {
+		return nil, nil, err
+	}
+	return snapshot, owners, nil
 }
 
 // DeleteIngressInfo removes an ingress from the caches.
@@ -184,7 +353,14 @@ func (caches *Caches) DeleteIngressInfo(_ context.Context, ingressName string, i
 	caches.mu.Lock()
 	defer caches.mu.Unlock()
 
+	caches.revision++
 	caches.deleteTranslatedIngress(ingressName, ingressNamespace)
+	delete(caches.rejected, types.NamespacedName{Namespace: ingressNamespace, Name: ingressName})
+	// Conflicts might be resolved by the removal, so give the ingresses rejected for
+	// one another chance.
+	maps.DeleteFunc(caches.rejected, func(_ types.NamespacedName, rejected *rejection) bool {
+		return errors.Is(rejected.err, ErrDomainConflict)
+	})
 	return nil
 }
 
@@ -204,29 +380,42 @@ func (caches *Caches) deleteTranslatedIngress(ingressName, ingressNamespace stri
 			caches.domainsInUse.Delete(vhost.GetDomains()...)
 		}
 
+		fleet := caches.fleet(translated.fleet)
+		fleet.deleteRouteTables(key)
+		fleet.deleteIngress(key)
 		delete(caches.translatedIngresses, key)
 	}
 }
 
-func generateListenersAndRouteConfigsAndClusters(
+// generateListenersAndRouteConfigsAndClusters generates the listeners, route configs and
+// clusters that are not specific to a single ingress, along with the SDS secrets
+// carrying the certificates the listeners refer to.
+func (caches *Caches) generateListenersAndRouteConfigsAndClusters(
 	ctx context.Context,
-	externalVirtualHosts []*route.VirtualHost,
-	externalTLSVirtualHosts []*route.VirtualHost,
-	localVirtualHosts []*route.VirtualHost,
-	localTLSVirtualHosts []*route.VirtualHost,
+	fleet *gatewayFleet,
 	localSNIMatches []*envoy.SNIMatch,
 	externalSNIMatches []*envoy.SNIMatch,
-	kubeclient kubeclient.Interface,
-) ([]cachetypes.Resource, []cachetypes.Resource, []cachetypes.Resource, error) {
+) ([]cachetypes.Resource, []cachetypes.Resource, []cachetypes.Resource, []cachetypes.Resource, error) {
 	// This has to be "OrDefaults" because this path is called before the informers are
 	// running when booting the controller up and prefilling the config before making it
 	// ready.
 	cfg := config.FromContextOrDefaults(ctx)
+	kubeclient := caches.kubeClient
 
-	// First, we save the RouteConfigs with the proper name and all the virtualhosts etc. into the cache.
-	externalRouteConfig := envoy.NewRouteConfig(externalRouteConfigName, externalVirtualHosts)
-	externalTLSRouteConfig := envoy.NewRouteConfig(externalTLSRouteConfigName, externalTLSVirtualHosts)
-	localRouteConfig := envoy.NewRouteConfig(localRouteConfigName, localVirtualHosts)
+	// First, we get the RouteConfigs with the proper name and all the virtualhosts etc. from the route tables.
+	externalRouteConfig, err := fleet.routeConfig(fleet.externalRouteTable, externalRouteConfigName)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	externalTLSRouteConfig, err := fleet.routeConfig(fleet.externalTLSRouteTable, externalTLSRouteConfigName)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	// Append the statusHost too.
+	localRouteConfig, err := fleet.routeConfig(fleet.localRouteTable, localRouteConfigName, caches.statusVirtualHost)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
 
 	// Now we setup connection managers, that reference the routeconfigs via RDS.
 	externalManager := envoy.NewHTTPConnectionManager(externalRouteConfig.GetName(), cfg.Kourier)
@@ -235,21 +424,22 @@ func generateListenersAndRouteConfigsAndClusters(
 
 	externalHTTPEnvoyListener, err := envoy.NewHTTPListener(externalManager, config.HTTPPortExternal, cfg.Kourier.EnableProxyProtocol, cfg.Kourier.ListenIPAddresses)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 	localEnvoyListener, err := envoy.NewHTTPListener(localManager, config.HTTPPortLocal, false, cfg.Kourier.ListenIPAddresses)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	listeners := []cachetypes.Resource{externalHTTPEnvoyListener, localEnvoyListener}
 	routes := []cachetypes.Resource{externalRouteConfig, localRouteConfig}
 	clusters := make([]cachetypes.Resource, 0, 1)
+	secrets := make([]cachetypes.Resource, 0, len(localSNIMatches)+len(externalSNIMatches)+2)
 
 	// create probe listeners
 	probHTTPListener, err := envoy.NewHTTPListener(externalManager, config.HTTPPortProb, false, cfg.Kourier.ListenIPAddresses)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 	listeners = append(listeners, probHTTPListener)
 
@@ -257,37 +447,49 @@ func generateListenersAndRouteConfigsAndClusters(
 	// If there's at least one ingress that contains the TLS field, that takes precedence.
 	// If there is not, TLS will be configured using a single cert for all the services when the certificate is configured.
 	if len(localSNIMatches) > 0 {
-		localTLSRouteConfig := envoy.NewRouteConfig(localTLSRouteConfigName, localTLSVirtualHosts)
+		localSecrets, err := fleet.filterChains.secrets(localSNIMatches)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		for _, secret := range localSecrets {
+			secrets = append(secrets, secret)
+		}
+
+		localTLSRouteConfig, err := fleet.routeConfig(fleet.localTLSRouteTable, localTLSRouteConfigName)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
 		localTLSManager := envoy.NewHTTPConnectionManager(localTLSRouteConfig.GetName(), cfg.Kourier)
 
-		localHTTPSEnvoyListener, err := envoy.NewHTTPSListenerWithSNI(
+		localHTTPSEnvoyListener, err := fleet.newHTTPSListenerWithSNI(
 			localTLSManager, config.HTTPSPortLocal,
 			localSNIMatches, cfg.Kourier,
 		)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		probeConfig := cfg.Kourier
 		probeConfig.EnableProxyProtocol = false // Disable proxy protocol for prober.
 
 		// create https prob listener with SNI
-		probHTTPSListener, err := envoy.NewHTTPSListenerWithSNI(
+		probHTTPSListener, err := fleet.newHTTPSListenerWithSNI(
 			localManager, config.HTTPSPortProb,
 			localSNIMatches, probeConfig,
 		)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		// if a single certificate is additionally configured, add a new filter chain to TLS listener
 		if cfg.Kourier.ClusterCertSecret != "" {
-			localHTTPSEnvoyListenerWithOneCertFilterChain, err := newLocalEnvoyListenerWithOneCertFilterChain(
+			localHTTPSEnvoyListenerWithOneCertFilterChain, secret, err := newLocalEnvoyListenerWithOneCertFilterChain(
 				ctx, localTLSManager, kubeclient, cfg.Kourier,
 			)
 			if err != nil {
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
+			secrets = append(secrets, secret)
 
 			localHTTPSEnvoyListener.FilterChains = append(localHTTPSEnvoyListener.FilterChains,
 				localHTTPSEnvoyListenerWithOneCertFilterChain)
@@ -298,16 +500,20 @@ func generateListenersAndRouteConfigsAndClusters(
 		listeners = append(listeners, localHTTPSEnvoyListener, probHTTPSListener)
 		routes = append(routes, localTLSRouteConfig)
 	} else if cfg.Kourier.ClusterCertSecret != "" {
-		localTLSRouteConfig := envoy.NewRouteConfig(localTLSRouteConfigName, localVirtualHosts)
+		localTLSRouteConfig, err := fleet.routeConfig(fleet.localRouteTable, localTLSRouteConfigName, caches.statusVirtualHost)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
 		localTLSManager := envoy.NewHTTPConnectionManager(localTLSRouteConfig.GetName(), cfg.Kourier)
 
-		localHTTPSEnvoyListener, err := newLocalEnvoyListenerWithOneCert(
+		localHTTPSEnvoyListener, secret, err := newLocalEnvoyListenerWithOneCert(
 			ctx, localTLSManager, kubeclient,
 			cfg.Kourier,
 		)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
+		secrets = append(secrets, secret)
 
 		listeners = append(listeners, localHTTPSEnvoyListener)
 		routes = append(routes, localTLSRouteConfig)
@@ -317,34 +523,43 @@ func generateListenersAndRouteConfigsAndClusters(
 	// TLS field, that takes precedence. If there is not, TLS will be configured
 	// using a single cert for all the services if the creds are given via ENV.
 	if len(externalSNIMatches) > 0 {
-		externalHTTPSEnvoyListener, err := envoy.NewHTTPSListenerWithSNI(
+		externalSecrets, err := fleet.filterChains.secrets(externalSNIMatches)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		for _, secret := range externalSecrets {
+			secrets = append(secrets, secret)
+		}
+
+		externalHTTPSEnvoyListener, err := fleet.newHTTPSListenerWithSNI(
 			externalTLSManager, config.HTTPSPortExternal,
 			externalSNIMatches, cfg.Kourier,
 		)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		probeConfig := cfg.Kourier
 		probeConfig.EnableProxyProtocol = false // Disable proxy protocol for prober.
 
 		// create https prob listener with SNI
-		probHTTPSListener, err := envoy.NewHTTPSListenerWithSNI(
+		probHTTPSListener, err := fleet.newHTTPSListenerWithSNI(
 			externalManager, config.HTTPSPortProb,
 			externalSNIMatches, probeConfig,
 		)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		// if a single certificate is additionally configured, add a new filter chain to TLS listener
 		if cfg.Kourier.UseHTTPSListenerWithOneCert() {
-			externalHTTPSEnvoyListenerWithOneCertFilterChain, err := newExternalEnvoyListenerWithOneCertFilterChain(
+			externalHTTPSEnvoyListenerWithOneCertFilterChain, secret, err := newExternalEnvoyListenerWithOneCertFilterChain(
 				ctx, externalTLSManager, kubeclient, cfg.Kourier,
 			)
 			if err != nil {
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
+			secrets = append(secrets, secret)
 
 			externalHTTPSEnvoyListener.FilterChains = append(externalHTTPSEnvoyListener.FilterChains,
 				externalHTTPSEnvoyListenerWithOneCertFilterChain)
@@ -355,18 +570,19 @@ func generateListenersAndRouteConfigsAndClusters(
 		listeners = append(listeners, externalHTTPSEnvoyListener, probHTTPSListener)
 		routes = append(routes, externalTLSRouteConfig)
 	} else if cfg.Kourier.UseHTTPSListenerWithOneCert() {
-		externalHTTPSEnvoyListener, err := newExternalEnvoyListenerWithOneCert(
+		externalHTTPSEnvoyListener, secret, err := newExternalEnvoyListenerWithOneCert(
 			ctx, externalTLSManager, kubeclient,
 			cfg.Kourier,
 		)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
+		secrets = append(secrets, secret)
 
 		// create https prob listener
 		probHTTPSListener, err := envoy.NewHTTPSListener(config.HTTPSPortProb, externalHTTPSEnvoyListener.GetFilterChains(), false, cfg.Kourier.ListenIPAddresses)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		listeners = append(listeners, externalHTTPSEnvoyListener, probHTTPSListener)
@@ -377,7 +593,7 @@ func generateListenersAndRouteConfigsAndClusters(
 		clusters = append(clusters, cluster)
 	}
 
-	return listeners, routes, clusters, nil
+	return listeners, routes, clusters, secrets, nil
 }
 
 func sslCreds(ctx context.Context, kubeClient kubeclient.Interface, secretNamespace string, secretName string) (certificateChain []byte, privateKey []byte, err error) {
@@ -389,50 +605,60 @@ func sslCreds(ctx context.Context, kubeClient kubeclient.Interface, secretNamesp
 	return secret.Data[certificates.CertName], secret.Data[certificates.PrivateKeyName], nil
 }
 
-func newExternalEnvoyListenerWithOneCertFilterChain(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, cfg *config.Kourier) (*v3.FilterChain, error) {
-	certificateChain, privateKey, err := sslCreds(
-		ctx, kubeClient, cfg.CertsSecretNamespace, cfg.CertsSecretName,
-	)
+func newExternalEnvoyListenerWithOneCertFilterChain(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, cfg *config.Kourier) (*v3.FilterChain, *tlsv3.Secret, error) {
+	source := types.NamespacedName{Namespace: cfg.CertsSecretNamespace, Name: cfg.CertsSecretName}
+	return newFilterChainWithOneCert(ctx, manager, kubeClient, source, cfg)
+}
+
+func newExternalEnvoyListenerWithOneCert(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, cfg *config.Kourier) (*v3.Listener, *tlsv3.Secret, error) {
+	filterChain, secret, err := newExternalEnvoyListenerWithOneCertFilterChain(ctx, manager, kubeClient, cfg)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return envoy.CreateFilterChainFromCertificateAndPrivateKey(manager, &envoy.Certificate{
-		Certificate:        certificateChain,
-		PrivateKey:         privateKey,
-		PrivateKeyProvider: privateKeyProvider(cfg.EnableCryptoMB),
-		CipherSuites:       sets.List(cfg.CipherSuites),
-	})
+	listener, err := envoy.NewHTTPSListener(config.HTTPSPortExternal, []*v3.FilterChain{filterChain}, cfg.EnableProxyProtocol, cfg.ListenIPAddresses)
+	return listener, secret, err
 }
 
-func newExternalEnvoyListenerWithOneCert(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, cfg *config.Kourier) (*v3.Listener, error) {
-	filterChain, err := newExternalEnvoyListenerWithOneCertFilterChain(ctx, manager, kubeClient, cfg)
+func newLocalEnvoyListenerWithOneCertFilterChain(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, cfg *config.Kourier) (*v3.FilterChain, *tlsv3.Secret, error) {
+	source := types.NamespacedName{Namespace: system.Namespace(), Name: cfg.ClusterCertSecret}
+	return newFilterChainWithOneCert(ctx, manager, kubeClient, source, cfg)
+}
+
+func newLocalEnvoyListenerWithOneCert(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, cfg *config.Kourier) (*v3.Listener, *tlsv3.Secret, error) {
+	filterChain, secret, err := newLocalEnvoyListenerWithOneCertFilterChain(ctx, manager, kubeClient, cfg)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return envoy.NewHTTPSListener(config.HTTPSPortExternal, []*v3.FilterChain{filterChain}, cfg.EnableProxyProtocol, cfg.ListenIPAddresses)
+	listener, err := envoy.NewHTTPSListener(config.HTTPSPortLocal, []*v3.FilterChain{filterChain}, cfg.EnableProxyProtocol, cfg.ListenIPAddresses)
+	return listener, secret, err
 }
 
-func newLocalEnvoyListenerWithOneCertFilterChain(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, cfg *config.Kourier) (*v3.FilterChain, error) {
-	certificateChain, privateKey, err := sslCreds(ctx, kubeClient, system.Namespace(), cfg.ClusterCertSecret)
+// newFilterChainWithOneCert creates a filter chain serving the certificate of the given
+// Kubernetes Secret, along with the SDS secret the filter chain refers to.
+func newFilterChainWithOneCert(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, source types.NamespacedName, cfg *config.Kourier) (*v3.FilterChain, *tlsv3.Secret, error) {
+	certificateChain, privateKey, err := sslCreds(ctx, kubeClient, source.Namespace, source.Name)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return envoy.CreateFilterChainFromCertificateAndPrivateKey(manager, &envoy.Certificate{
+
+	cert := &envoy.Certificate{
+		SecretName:         envoy.SecretName(source, privateKeyProvider(cfg.EnableCryptoMB)),
 		Certificate:        certificateChain,
 		PrivateKey:         privateKey,
 		PrivateKeyProvider: privateKeyProvider(cfg.EnableCryptoMB),
 		CipherSuites:       sets.List(cfg.CipherSuites),
-	})
-}
-
-func newLocalEnvoyListenerWithOneCert(ctx context.Context, manager *httpconnmanagerv3.HttpConnectionManager, kubeClient kubeclient.Interface, cfg *config.Kourier) (*v3.Listener, error) {
-	filterChain, err := newLocalEnvoyListenerWithOneCertFilterChain(ctx, manager, kubeClient, cfg)
+	}
+	secret, err := envoy.NewSecret(cert)
+	if err != nil {
+		return nil, nil, err
+	}
+	filterChain, err := envoy.CreateFilterChainFromCertificateAndPrivateKey(manager, cert)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return envoy.NewHTTPSListener(config.HTTPSPortLocal, []*v3.FilterChain{filterChain}, cfg.EnableProxyProtocol, cfg.ListenIPAddresses)
+	return filterChain, secret, nil
 }
 
 func privateKeyProvider(mbEnabled bool) string {
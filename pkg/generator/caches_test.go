@@ -18,13 +18,22 @@ package generator
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	http_connection_managerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	cache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"google.golang.org/protobuf/proto"
@@ -36,7 +45,9 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 	envoy "knative.dev/net-kourier/pkg/envoy/api"
+	"knative.dev/net-kourier/pkg/envoy/server"
 	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
 	"knative.dev/networking/pkg/certificates"
 	netconfig "knative.dev/networking/pkg/config"
 	"knative.dev/pkg/observability/metrics"
@@ -216,6 +227,11 @@ func runExternalTLSListenerTests(t *testing.T, ctx context.Context, caches *Cach
 
 		assert.Check(t, filterChainsByServerName["foo.example.com"] != nil)
 		assert.Check(t, filterChainsByServerName[""] != nil) // filter chain without server name, "default" one
+
+		// The certificate is served via SDS rather than inlined into the listener.
+		secret := snapshot.GetResources(resource.SecretType)["secretns/secretname1"].(*tlsv3.Secret)
+		assert.DeepEqual(t, secret.GetTlsCertificate().GetCertificateChain().GetInlineBytes(), []byte("cert1"))
+		assert.Check(t, !strings.Contains(filterChainsByServerName["foo.example.com"].String(), "privateKey1"))
 	})
 
 	t.Run("with multiple SNI matches", func(t *testing.T) {
@@ -568,6 +584,254 @@ func TestValidateIngress(t *testing.T) {
 	assert.Error(t, err, ErrDomainConflict.Error())
 }
 
+func TestEndpointsServedViaEDS(t *testing.T) {
+	kubeClient := fake.Clientset{}
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+
+	caches, err := NewCaches(ctx, &kubeClient)
+	assert.NilError(t, err)
+
+	endpoints := []*endpoint.LbEndpoint{envoy.NewLBEndpoint("1.1.1.1", 8080)}
+	err = caches.addTranslatedIngress(&translatedIngress{
+		name: types.NamespacedName{
+			Namespace: "ingressns",
+			Name:      "ingressname",
+		},
+		clusters:        []*v3.Cluster{envoy.NewEDSCluster("servicens/servicename", 5*time.Second, false, nil)},
+		loadAssignments: []*endpoint.ClusterLoadAssignment{envoy.NewClusterLoadAssignment("servicens/servicename", endpoints)},
+	})
+	assert.NilError(t, err)
+
+	snapshot, err := caches.ToEnvoySnapshot(ctx)
+	assert.NilError(t, err)
+
+	cluster := snapshot.GetResources(resource.ClusterType)["servicens/servicename"].(*v3.Cluster)
+	assert.Assert(t, cluster.GetLoadAssignment() == nil)
+
+	loadAssignment := snapshot.GetResources(resource.EndpointType)["servicens/servicename"].(*endpoint.ClusterLoadAssignment)
+	assert.DeepEqual(t, loadAssignment.GetEndpoints()[0].GetLbEndpoints(), endpoints, protocmp.Transform())
+
+	// Endpoints are kept around as long as their cluster, even after the ingress is gone.
+	caches.DeleteIngressInfo(ctx, "ingressname", "ingressns")
+	snapshot, err = caches.ToEnvoySnapshot(ctx)
+	assert.NilError(t, err)
+	assert.Assert(t, snapshot.GetResources(resource.EndpointType)["servicens/servicename"] != nil)
+}
+
+func TestSnapshotVersionIsContentHashed(t *testing.T) {
+	kubeClient := fake.Clientset{}
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+
+	newCaches := func(ingresses ...string) *Caches {
+		caches, err := NewCaches(ctx, &kubeClient)
+		assert.NilError(t, err)
+		for _, ingress := range ingresses {
+			createTestDataForIngress(caches, ingress, "ns", "cluster_"+ingress,
+				"internal_"+ingress, "external_"+ingress, "external_tls_"+ingress)
+		}
+		return caches
+	}
+	version := func(caches *Caches) string {
+		snapshot, err := caches.ToEnvoySnapshot(ctx)
+		assert.NilError(t, err)
+		return snapshot.GetVersion(resource.ListenerType)
+	}
+
+	caches := newCaches("ingress_1", "ingress_2", "ingress_3")
+	first := version(caches)
+
+	// A resync of unchanged state yields the same version.
+	assert.Equal(t, version(caches), first)
+
+	// So does another replica with the same state, regardless of insertion order.
+	assert.Equal(t, version(newCaches("ingress_3", "ingress_1", "ingress_2")), first)
+
+	// All resource types share the snapshot's version.
+	snapshot, err := caches.ToEnvoySnapshot(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, snapshot.GetVersion(resource.ClusterType), first)
+	assert.Equal(t, snapshot.GetVersion(resource.RouteType), first)
+
+	// Per-resource versions are available for delta xDS.
+	assert.Assert(t, snapshot.GetVersionMap(resource.ClusterType)["cluster_ingress_1"] != "")
+
+	// Any change yields a new version.
+	assert.Assert(t, version(newCaches("ingress_1", "ingress_2")) != first)
+}
+
+func TestSnapshotVersionWithHeaderMatches(t *testing.T) {
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+	kubeclient := fake.NewSimpleClientset(svc("servicens", "servicename"), eps("servicens", "servicename"))
+	translator := newTestIngressTranslator(ctx, kubeclient)
+
+	version := func() string {
+		caches, err := NewCaches(ctx, kubeclient)
+		assert.NilError(t, err)
+		ingress := ing("ns", "name", func(ing *v1alpha1.Ingress) {
+			ing.Spec.Rules[0].HTTP.Paths[0].Headers = map[string]v1alpha1.HeaderMatch{
+				"x-first":  {Exact: "1"},
+				"x-second": {Exact: "2"},
+				"x-third":  {Exact: "3"},
+			}
+		})
+		assert.NilError(t, UpdateInfoForIngress(ctx, caches, ingress, &translator))
+		snapshot, err := caches.ToEnvoySnapshot(ctx)
+		assert.NilError(t, err)
+		return snapshot.GetVersion(resource.RouteType)
+	}
+
+	// Header matches are kept in a map, which must not leak into the version.
+	first := version()
+	for range 20 {
+		assert.Equal(t, version(), first)
+	}
+}
+
+func TestPersistedSnapshotVersions(t *testing.T) {
+	kubeClient := fake.Clientset{}
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+
+	generate := func() map[string]*cache.Snapshot {
+		caches, err := NewCaches(ctx, &kubeClient)
+		assert.NilError(t, err)
+		for _, ingress := range []string{"ingress_1", "ingress_2"} {
+			createTestDataForIngress(caches, ingress, "ns", "cluster_"+ingress,
+				"internal_"+ingress, "external_"+ingress, "external_tls_"+ingress)
+		}
+		snapshots, _, err := caches.ToEnvoySnapshots(ctx)
+		assert.NilError(t, err)
+		return snapshots
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshots.json")
+	assert.NilError(t, server.WriteSnapshotsFile(path, generate()))
+	restored, err := server.ReadSnapshotsFile(path)
+	assert.NilError(t, err)
+
+	// A restarted controller generates the same state with the same versions, so
+	// the restored resources aren't sent to the gateways again.
+	node := config.DefaultGatewayFleet
+	regenerated := generate()[node]
+	for _, typeURL := range []string{resource.ClusterType, resource.EndpointType, resource.RouteType, resource.ListenerType, resource.SecretType} {
+		assert.Equal(t, restored[node].GetVersion(typeURL), regenerated.GetVersion(typeURL), typeURL)
+		assert.DeepEqual(t, restored[node].GetVersionMap(typeURL), regenerated.GetVersionMap(typeURL))
+	}
+	assert.Assert(t, len(regenerated.GetVersionMap(resource.RouteType)) > 0)
+}
+
+func TestSnapshotsPerGatewayFleet(t *testing.T) {
+	kubeClient := fake.Clientset{}
+	cfg := config.FromContextOrDefaults(context.Background()).DeepCopy()
+	cfg.Kourier.GatewayFleets = map[string]config.GatewayFleet{
+		"tenant-gateway": {ExternalService: "tenant", InternalService: "tenant-internal"},
+	}
+	ctx := config.ToContext(context.Background(), cfg)
+
+	caches, err := NewCaches(ctx, &kubeClient)
+	assert.NilError(t, err)
+
+	newIngress := func(name, fleet string) *translatedIngress {
+		return &translatedIngress{
+			name:                 types.NamespacedName{Namespace: "ns", Name: name},
+			clusters:             []*v3.Cluster{{Name: "cluster_" + name}},
+			externalVirtualHosts: []*route.VirtualHost{{Name: "external_" + name, Domains: []string{"external_" + name}}},
+			localVirtualHosts:    []*route.VirtualHost{{Name: "internal_" + name, Domains: []string{"internal_" + name}}},
+			fleet:                fleet,
+		}
+	}
+	assert.NilError(t, caches.UpdateIngress(ctx, newIngress("ingress_1", config.DefaultGatewayFleet)))
+	assert.NilError(t, caches.UpdateIngress(ctx, newIngress("ingress_2", "tenant-gateway")))
+
+	snapshots, _, err := caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snapshots), 2)
+
+	clusters := func(snapshot *cache.Snapshot) []string {
+		return slices.Sorted(maps.Keys(snapshot.GetResources(resource.ClusterType)))
+	}
+	assert.DeepEqual(t, clusters(snapshots[config.DefaultGatewayFleet]), []string{"cluster_ingress_1"})
+	assert.DeepEqual(t, clusters(snapshots["tenant-gateway"]), []string{"cluster_ingress_2"})
+	assert.Equal(t, caches.GatewayFleet(types.NamespacedName{Namespace: "ns", Name: "ingress_2"}), "tenant-gateway")
+
+	// Ingresses can move between fleets.
+	assert.NilError(t, caches.UpdateIngress(ctx, newIngress("ingress_2", config.DefaultGatewayFleet)))
+	snapshots, _, err = caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+	externalVHosts := func(snapshot *cache.Snapshot) []string {
+		routeConfig := snapshot.GetResources(resource.RouteType)[externalRouteConfigName].(*route.RouteConfiguration)
+		return getVHostsNames([]*route.RouteConfiguration{routeConfig})
+	}
+	assert.Assert(t, slices.Contains(externalVHosts(snapshots[config.DefaultGatewayFleet]), "external_ingress_2"))
+	assert.Assert(t, !slices.Contains(externalVHosts(snapshots["tenant-gateway"]), "external_ingress_2"))
+	assert.Assert(t, len(clusters(snapshots["tenant-gateway"])) == 0)
+}
+
+// BenchmarkToEnvoySnapshot measures building a snapshot after a single ingress
+// changed, with 10k ingresses in the caches. For reference, the same workload takes
+// ~30-35ms/op and ~49k allocs/op with ToEnvoySnapshot assembling everything from
+// scratch, as it did before route tables and cluster lists were patched in place.
+func BenchmarkToEnvoySnapshot(b *testing.B) {
+	kubeClient := fake.Clientset{}
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+
+	caches, err := NewCaches(ctx, &kubeClient)
+	assert.NilError(b, err)
+
+	const ingresses = 10000
+	for i := range ingresses {
+		assert.NilError(b, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(i, "v1")))
+	}
+	// Build the initial snapshot, so only the change is measured.
+	_, err = caches.ToEnvoySnapshot(ctx)
+	assert.NilError(b, err)
+
+	i := 0
+	for b.Loop() {
+		assert.NilError(b, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(i%ingresses, fmt.Sprint("v", i))))
+		_, err := caches.ToEnvoySnapshot(ctx)
+		assert.NilError(b, err)
+		i++
+	}
+}
+
+func benchmarkTranslatedIngress(i int, revision string) *translatedIngress {
+	name := types.NamespacedName{Namespace: fmt.Sprint("ns-", i), Name: fmt.Sprint("ingress-", i)}
+	clusterName := name.Namespace + "/" + revision
+	host := name.Name + "." + name.Namespace + ".example.com"
+
+	vhosts := func() []*route.VirtualHost {
+		return []*route.VirtualHost{envoy.NewVirtualHost(
+			"("+name.String()+").Domain["+host+"]",
+			[]string{host, host + ":*"},
+			[]*route.Route{envoy.NewRoute(
+				"("+name.String()+").Domain["+host+"].Paths[/]",
+				nil, "/",
+				[]*route.WeightedCluster_ClusterWeight{envoy.NewWeightedCluster(clusterName, 100, map[string]string{"Knative-Serving-Revision": revision})},
+				0, map[string]string{"K-Network-Hash": revision}, ""),
+			},
+		)}
+	}
+
+	translated := &translatedIngress{
+		name:                 name,
+		clusters:             []*v3.Cluster{envoy.NewEDSCluster(clusterName, 5*time.Second, true, nil)},
+		loadAssignments:      []*endpoint.ClusterLoadAssignment{envoy.NewClusterLoadAssignment(clusterName, []*endpoint.LbEndpoint{envoy.NewLBEndpoint("10.0.0.1", 8012)})},
+		externalVirtualHosts: vhosts(),
+		localVirtualHosts:    vhosts(),
+	}
+	// Every tenth ingress serves TLS with its own certificate.
+	if i%10 == 0 {
+		translated.externalTLSVirtualHosts = vhosts()
+		translated.externalSNIMatches = []*envoy.SNIMatch{{
+			Hosts:            []string{host},
+			CertSource:       types.NamespacedName{Namespace: name.Namespace, Name: "tls"},
+			CertificateChain: secretCert,
+			PrivateKey:       privateKey,
+		}}
+	}
+	return translated
+}
+
 func getVHostsNames(routeConfigs []*route.RouteConfiguration) []string {
 	var res []string
 
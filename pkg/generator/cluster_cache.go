@@ -37,10 +37,14 @@ limitations under the License.
 package generator
 
 import (
+	"cmp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	gocache "github.com/patrickmn/go-cache"
 )
@@ -50,9 +54,39 @@ const (
 	defaultCleanupInterval = 1 * time.Minute
 )
 
+// clusterEntry is a cached cluster together with its endpoints. The endpoints are
+// only set for clusters that discover them via EDS and are kept around for as
+// long as the cluster itself.
+type clusterEntry struct {
+	cluster        *v3.Cluster
+	loadAssignment *endpoint.ClusterLoadAssignment
+	// fleet is the node ID of the gateways the cluster is served to. Clusters
+	// without a fleet are served to all of them.
+	fleet string
+}
+
 type ClustersCache struct {
 	clusterExpiration time.Duration
 	clusters          *gocache.Cache
+
+	// The cached entries are also indexed by cluster name, so that listing them
+	// doesn't need to copy and sort all of them for every snapshot. The index is
+	// updated as entries are set and evicted, which happens on the janitor
+	// goroutine of the underlying cache, hence the lock.
+	mu sync.Mutex
+	// names holds the names of all cached clusters, sorted.
+	names []string
+	// byName holds the entries of every cluster name, sorted by key.
+	byName map[string][]indexedCluster
+	// onEvicted is called when an entry expired.
+	onEvicted func(key string, entry *clusterEntry)
+}
+
+type indexedCluster struct {
+	key   string
+	entry *clusterEntry
+	// expiration is zero for entries that don't expire.
+	expiration time.Time
 }
 
 func newClustersCache() *ClustersCache {
@@ -61,30 +95,149 @@ func newClustersCache() *ClustersCache {
 
 func newClustersCacheWithExpAndCleanupIntervals(expiration time.Duration, cleanupInterval time.Duration) *ClustersCache {
 	goCache := gocache.New(gocache.NoExpiration, cleanupInterval)
-	return &ClustersCache{clusters: goCache, clusterExpiration: expiration}
+	cc := &ClustersCache{clusters: goCache, clusterExpiration: expiration, byName: make(map[string][]indexedCluster)}
+	goCache.OnEvicted(cc.evicted)
+	return cc
 }
 
-func (cc *ClustersCache) set(cluster *v3.Cluster, ingressName string, ingressNamespace string) {
+func (cc *ClustersCache) set(cluster *v3.Cluster, loadAssignment *endpoint.ClusterLoadAssignment, fleet string, ingressName string, ingressNamespace string) {
 	key := key(cluster.GetName(), ingressName, ingressNamespace)
-	cc.clusters.Set(key, cluster, gocache.NoExpiration)
+	entry := &clusterEntry{cluster: cluster, loadAssignment: loadAssignment, fleet: fleet}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.clusters.Set(key, entry, gocache.NoExpiration)
+	cc.index(indexedCluster{key: key, entry: entry})
 }
 
 func (cc *ClustersCache) setExpiration(clusterName string, ingressName string, ingressNamespace string) {
 	key := key(clusterName, ingressName, ingressNamespace)
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
 	if cluster, ok := cc.clusters.Get(key); ok {
 		cc.clusters.Set(key, cluster, cc.clusterExpiration)
+		cc.index(indexedCluster{key: key, entry: cluster.(*clusterEntry), expiration: time.Now().Add(cc.clusterExpiration)})
+	}
+}
+
+// setOnEvicted sets the function called with the key and the entry of every
+// entry that expired.
+func (cc *ClustersCache) setOnEvicted(f func(key string, entry *clusterEntry)) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.onEvicted = f
+}
+
+func (cc *ClustersCache) evicted(key string, value interface{}) {
+	entry := value.(*clusterEntry)
+
+	cc.mu.Lock()
+	name := entry.cluster.GetName()
+	indexed := cc.byName[name]
+	// The key might have been set again since it expired.
+	if i, found := slices.BinarySearchFunc(indexed, key, compareIndexedClusterKey); found && indexed[i].entry == entry {
+		cc.byName[name] = slices.Delete(indexed, i, i+1)
+		if len(cc.byName[name]) == 0 {
+			delete(cc.byName, name)
+			if j, found := slices.BinarySearch(cc.names, name); found {
+				cc.names = slices.Delete(cc.names, j, j+1)
+			}
+		}
+	}
+	onEvicted := cc.onEvicted
+	cc.mu.Unlock()
+
+	if onEvicted != nil {
+		onEvicted(key, entry)
+	}
+}
+
+// index adds or replaces the given entry in the index. It must be called with the
+// lock held.
+func (cc *ClustersCache) index(cluster indexedCluster) {
+	name := cluster.entry.cluster.GetName()
+	indexed, ok := cc.byName[name]
+	if !ok {
+		if j, found := slices.BinarySearch(cc.names, name); !found {
+			cc.names = slices.Insert(cc.names, j, name)
+		}
 	}
+	if i, found := slices.BinarySearchFunc(indexed, cluster.key, compareIndexedClusterKey); found {
+		indexed[i] = cluster
+	} else {
+		cc.byName[name] = slices.Insert(indexed, i, cluster)
+	}
+}
+
+func compareIndexedClusterKey(cluster indexedCluster, key string) int {
+	return cmp.Compare(cluster.key, key)
 }
 
-func (cc *ClustersCache) list() []cachetypes.Resource {
-	res := make([]cachetypes.Resource, 0, cc.clusters.ItemCount())
-	for _, cluster := range cc.clusters.Items() {
-		res = append(res, cluster.Object.(cachetypes.Resource))
+// list returns the clusters served to the given fleet.
+func (cc *ClustersCache) list(fleet string) []cachetypes.Resource {
+	entries := cc.entries(fleet)
+	res := make([]cachetypes.Resource, 0, len(entries))
+	for _, entry := range entries {
+		res = append(res, entry.cluster)
 	}
 
 	return res
 }
 
+// listLoadAssignments returns the endpoints of the clusters served to the given
+// fleet that use EDS.
+func (cc *ClustersCache) listLoadAssignments(fleet string) []cachetypes.Resource {
+	entries := cc.entries(fleet)
+	res := make([]cachetypes.Resource, 0, len(entries))
+	for _, entry := range entries {
+		if entry.loadAssignment != nil {
+			res = append(res, entry.loadAssignment)
+		}
+	}
+
+	return res
+}
+
+// entries returns the entries served to the given fleet, one per cluster name, in
+// the order of the names. Several ingresses can produce a cluster with the same
+// name, so the entry picked for a name must not depend on the order the entries
+// were set in, or the content hash of the snapshots would change from one build
+// to the next. Entries that don't expire win over expiring ones, then the entry
+// with the smallest key wins. Expired entries are left out even before they're
+// evicted.
+func (cc *ClustersCache) entries(fleet string) []*clusterEntry {
+	now := time.Now()
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	entries := make([]*clusterEntry, 0, len(cc.names))
+	for _, name := range cc.names {
+		var picked *clusterEntry
+		for _, indexed := range cc.byName[name] {
+			if !indexed.entry.servedTo(fleet) {
+				continue
+			}
+			if indexed.expiration.IsZero() {
+				picked = indexed.entry
+				break
+			}
+			if picked == nil && now.Before(indexed.expiration) {
+				picked = indexed.entry
+			}
+		}
+		if picked != nil {
+			entries = append(entries, picked)
+		}
+	}
+	return entries
+}
+
+func (e *clusterEntry) servedTo(fleet string) bool {
+	return e.fleet == "" || e.fleet == fleet
+}
+
 // Using only the cluster name is not enough to ensure uniqueness, that's why we
 // use also the ingress info.
 func key(clusterName, ingressName, ingressNamespace string) string {
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	envoy_api_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -38,9 +39,9 @@ var testCluster2 = envoy_api_v3.Cluster{
 
 func TestSetCluster(t *testing.T) {
 	cache := newClustersCache()
-	cache.set(&testCluster1, "some_ingress_name", "some_ingress_namespace")
+	cache.set(&testCluster1, nil, "gateway", "some_ingress_name", "some_ingress_namespace")
 
-	list := cache.list()
+	list := cache.list("gateway")
 
 	assert.Assert(t, is.Len(list, 1))
 	assert.Equal(t, testCluster1.Name, list[0].(*envoy_api_v3.Cluster).Name)
@@ -48,10 +49,10 @@ func TestSetCluster(t *testing.T) {
 
 func TestSetSeveralClusters(t *testing.T) {
 	cache := newClustersCache()
-	cache.set(&testCluster1, "some_ingress_name", "some_ingress_namespace")
-	cache.set(&testCluster2, "some_ingress_name", "some_ingress_namespace")
+	cache.set(&testCluster1, nil, "gateway", "some_ingress_name", "some_ingress_namespace")
+	cache.set(&testCluster2, nil, "gateway", "some_ingress_name", "some_ingress_namespace")
 
-	list := cache.list()
+	list := cache.list("gateway")
 	names := make([]string, 0, len(list))
 	for _, cluster := range list {
 		names = append(names, cluster.(*envoy_api_v3.Cluster).Name)
@@ -68,24 +69,97 @@ func TestSetSeveralClusters(t *testing.T) {
 func TestClustersExpire(t *testing.T) {
 	interval := 10 * time.Millisecond
 	cache := newClustersCacheWithExpAndCleanupIntervals(interval, interval)
-	cache.set(&testCluster1, "some_ingress_name", "some_ingress_namespace")
-	assert.Assert(t, is.Len(cache.list(), 1))
+	cache.set(&testCluster1, nil, "gateway", "some_ingress_name", "some_ingress_namespace")
+	assert.Assert(t, is.Len(cache.list("gateway"), 1))
 
 	// Wait for twice the interval and assert that the cluster is still there.
 	time.Sleep(2 * interval)
-	assert.Assert(t, is.Len(cache.list(), 1))
+	assert.Assert(t, is.Len(cache.list("gateway"), 1))
 
 	// Mark the cluster to be expired.
 	cache.setExpiration(testCluster1.Name, "some_ingress_name", "some_ingress_namespace")
 
 	// The cluster should eventually disappear.
 	wait.PollUntilContextTimeout(context.Background(), interval, 5*time.Second, true, func(_ context.Context) (bool, error) {
-		return len(cache.list()) == 0, nil
+		return len(cache.list("gateway")) == 0, nil
 	})
-	assert.Assert(t, is.Len(cache.list(), 0))
+	assert.Assert(t, is.Len(cache.list("gateway"), 0))
+}
+
+func TestListLoadAssignments(t *testing.T) {
+	cache := newClustersCache()
+	cache.set(&testCluster1, nil, "gateway", "some_ingress_name", "some_ingress_namespace")
+	cache.set(&testCluster2, &endpoint.ClusterLoadAssignment{ClusterName: testCluster2.Name}, "gateway", "some_ingress_name", "some_ingress_namespace")
+
+	list := cache.listLoadAssignments("gateway")
+
+	assert.Assert(t, is.Len(list, 1))
+	assert.Equal(t, testCluster2.Name, list[0].(*endpoint.ClusterLoadAssignment).ClusterName)
+}
+
+func TestListByFleet(t *testing.T) {
+	cache := newClustersCache()
+	cache.set(&testCluster1, &endpoint.ClusterLoadAssignment{ClusterName: testCluster1.Name}, "gateway", "some_ingress_name", "some_ingress_namespace")
+	cache.set(&testCluster2, nil, "", "__extAuthZCluster", "_internal")
+
+	assert.Assert(t, is.Len(cache.list("gateway"), 2))
+	assert.Assert(t, is.Len(cache.listLoadAssignments("gateway"), 1))
+
+	// Clusters without a fleet are served to all fleets.
+	list := cache.list("other-gateway")
+	assert.Assert(t, is.Len(list, 1))
+	assert.Equal(t, testCluster2.Name, list[0].(*envoy_api_v3.Cluster).Name)
+	assert.Assert(t, is.Len(cache.listLoadAssignments("other-gateway"), 0))
 }
 
 func TestListWhenThereAreNoClusters(t *testing.T) {
 	cache := newClustersCache()
-	assert.Assert(t, is.Len(cache.list(), 0))
+	assert.Assert(t, is.Len(cache.list("gateway"), 0))
+}
+
+func TestListDeduplicatesClusterNames(t *testing.T) {
+	cache := newClustersCache()
+	expiring := &envoy_api_v3.Cluster{Name: "shared", AltStatName: "expiring"}
+	live := &envoy_api_v3.Cluster{Name: "shared", AltStatName: "live"}
+	other := &envoy_api_v3.Cluster{Name: "shared", AltStatName: "other"}
+	cache.set(expiring, nil, "gateway", "a", "ns")
+	cache.setExpiration(expiring.Name, "a", "ns")
+	cache.set(other, nil, "gateway", "c", "ns")
+	cache.set(live, nil, "gateway", "b", "ns")
+
+	// The entry that doesn't expire and has the smallest key wins, whatever the
+	// order of the underlying map.
+	for range 20 {
+		list := cache.list("gateway")
+		assert.Assert(t, is.Len(list, 1))
+		assert.Equal(t, list[0].(*envoy_api_v3.Cluster).GetAltStatName(), "live")
+	}
+}
+
+func TestEvictedClustersLeaveTheIndex(t *testing.T) {
+	interval := 10 * time.Millisecond
+	cache := newClustersCacheWithExpAndCleanupIntervals(interval, interval)
+	cache.set(&testCluster2, nil, "gateway", "a", "ns")
+	cache.set(&testCluster1, nil, "gateway", "a", "ns")
+	cache.set(&testCluster1, nil, "gateway", "b", "ns")
+
+	// The clusters are listed by name.
+	list := cache.list("gateway")
+	assert.Assert(t, is.Len(list, 2))
+	assert.Equal(t, list[0].(*envoy_api_v3.Cluster).Name, testCluster1.Name)
+	assert.Equal(t, list[1].(*envoy_api_v3.Cluster).Name, testCluster2.Name)
+
+	cache.setExpiration(testCluster1.Name, "a", "ns")
+	cache.setExpiration(testCluster2.Name, "a", "ns")
+	err := wait.PollUntilContextTimeout(context.Background(), interval, 5*time.Second, true, func(_ context.Context) (bool, error) {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return len(cache.names) == 1, nil
+	})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, cache.names, []string{testCluster1.Name})
+	assert.Assert(t, is.Len(cache.byName[testCluster1.Name], 1))
+	assert.Equal(t, cache.byName[testCluster1.Name][0].key, key(testCluster1.Name, "b", "ns"))
+	assert.Assert(t, is.Len(cache.list("gateway"), 1))
 }
@@ -0,0 +1,186 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	// Register the types only referenced by URL in typed configs, so that they can
+	// be dumped and parsed back.
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/tls_inspector/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/types"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+)
+
+// IngressDump is the translation of a single ingress, for debugging.
+type IngressDump struct {
+	Name                    string            `json:"name"`
+	Fleet                   string            `json:"fleet"`
+	Clusters                []json.RawMessage `json:"clusters"`
+	LoadAssignments         []json.RawMessage `json:"loadAssignments"`
+	ExternalVirtualHosts    []json.RawMessage `json:"externalVirtualHosts"`
+	ExternalTLSVirtualHosts []json.RawMessage `json:"externalTLSVirtualHosts"`
+	LocalVirtualHosts       []json.RawMessage `json:"localVirtualHosts"`
+	LocalTLSVirtualHosts    []json.RawMessage `json:"localTLSVirtualHosts"`
+	ExternalSNIMatches      []SNIMatchDump    `json:"externalSNIMatches"`
+	LocalSNIMatches         []SNIMatchDump    `json:"localSNIMatches"`
+}
+
+// SNIMatchDump describes an SNI match without the certificate and key it serves.
+type SNIMatchDump struct {
+	Hosts      []string `json:"hosts"`
+	CertSource string   `json:"certSource"`
+}
+
+// SnapshotDump holds the resources of a snapshot, for debugging. Secrets are left
+// out on purpose.
+type SnapshotDump struct {
+	Version   string                     `json:"version"`
+	Listeners map[string]json.RawMessage `json:"listeners"`
+	Routes    map[string]json.RawMessage `json:"routes"`
+	Clusters  map[string]json.RawMessage `json:"clusters"`
+	Endpoints map[string]json.RawMessage `json:"endpoints"`
+}
+
+// DumpIngress returns the translation of the given ingress. It returns false if the
+// ingress is not in the caches.
+func (caches *Caches) DumpIngress(key types.NamespacedName) (*IngressDump, bool, error) {
+	caches.mu.Lock()
+	defer caches.mu.Unlock()
+
+	translated, ok := caches.translatedIngresses[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	dump := &IngressDump{
+		Name:               key.String(),
+		Fleet:              translated.fleet,
+		ExternalSNIMatches: dumpSNIMatches(translated.externalSNIMatches),
+		LocalSNIMatches:    dumpSNIMatches(translated.localSNIMatches),
+	}
+	var err error
+	if dump.Clusters, err = marshalProtos(translated.clusters); err != nil {
+		return nil, false, err
+	}
+	if dump.LoadAssignments, err = marshalProtos(translated.loadAssignments); err != nil {
+		return nil, false, err
+	}
+	if dump.ExternalVirtualHosts, err = marshalProtos(translated.externalVirtualHosts); err != nil {
+		return nil, false, err
+	}
+	if dump.ExternalTLSVirtualHosts, err = marshalProtos(translated.externalTLSVirtualHosts); err != nil {
+		return nil, false, err
+	}
+	if dump.LocalVirtualHosts, err = marshalProtos(translated.localVirtualHosts); err != nil {
+		return nil, false, err
+	}
+	if dump.LocalTLSVirtualHosts, err = marshalProtos(translated.localTLSVirtualHosts); err != nil {
+		return nil, false, err
+	}
+	return dump, true, nil
+}
+
+// DumpSnapshot returns the resources of the given snapshot.
+func DumpSnapshot(snapshot cache.ResourceSnapshot) (*SnapshotDump, error) {
+	dump := &SnapshotDump{Version: snapshot.GetVersion(resource.ListenerType)}
+	var err error
+	if dump.Listeners, err = marshalResources(snapshot.GetResources(resource.ListenerType)); err != nil {
+		return nil, err
+	}
+	if dump.Routes, err = marshalResources(snapshot.GetResources(resource.RouteType)); err != nil {
+		return nil, err
+	}
+	if dump.Clusters, err = marshalResources(snapshot.GetResources(resource.ClusterType)); err != nil {
+		return nil, err
+	}
+	if dump.Endpoints, err = marshalResources(snapshot.GetResources(resource.EndpointType)); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// Snapshot parses the resources of the dump back into a snapshot, e.g. to inspect
+// a dump taken from the debug endpoint. The snapshot has no secrets.
+func (d *SnapshotDump) Snapshot() (*cache.Snapshot, error) {
+	resources := make(map[resource.Type][]cachetypes.Resource, 4)
+	for _, r := range []struct {
+		typeURL  resource.Type
+		dumped   map[string]json.RawMessage
+		newEmpty func() proto.Message
+	}{
+		{resource.ListenerType, d.Listeners, func() proto.Message { return &listener.Listener{} }},
+		{resource.RouteType, d.Routes, func() proto.Message { return &route.RouteConfiguration{} }},
+		{resource.ClusterType, d.Clusters, func() proto.Message { return &cluster.Cluster{} }},
+		{resource.EndpointType, d.Endpoints, func() proto.Message { return &endpoint.ClusterLoadAssignment{} }},
+	} {
+		resources[r.typeURL] = make([]cachetypes.Resource, 0, len(r.dumped))
+		for _, name := range slices.Sorted(maps.Keys(r.dumped)) {
+			message := r.newEmpty()
+			if err := protojson.Unmarshal(r.dumped[name], message); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+			}
+			resources[r.typeURL] = append(resources[r.typeURL], message)
+		}
+	}
+	return cache.NewSnapshot(d.Version, resources)
+}
+
+func dumpSNIMatches(matches []*envoy.SNIMatch) []SNIMatchDump {
+	dumps := make([]SNIMatchDump, 0, len(matches))
+	for _, match := range matches {
+		dumps = append(dumps, SNIMatchDump{Hosts: match.Hosts, CertSource: match.CertSource.String()})
+	}
+	return dumps
+}
+
+func marshalProtos[T proto.Message](messages []T) ([]json.RawMessage, error) {
+	res := make([]json.RawMessage, 0, len(messages))
+	for _, message := range messages {
+		marshaled, err := protojson.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, marshaled)
+	}
+	return res, nil
+}
+
+func marshalResources(resources map[string]cachetypes.Resource) (map[string]json.RawMessage, error) {
+	res := make(map[string]json.RawMessage, len(resources))
+	for name, r := range resources {
+		marshaled, err := protojson.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = marshaled
+	}
+	return res, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+)
+
+func TestDumpIngress(t *testing.T) {
+	kubeClient := fake.Clientset{}
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+
+	caches, err := NewCaches(ctx, &kubeClient)
+	assert.NilError(t, err)
+	createTestDataForIngress(caches, "ingress_1", "ns", "cluster_ingress_1",
+		"internal_ingress_1", "external_ingress_1", "external_tls_ingress_1")
+
+	_, ok, err := caches.DumpIngress(types.NamespacedName{Namespace: "ns", Name: "missing"})
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+
+	dump, ok, err := caches.DumpIngress(types.NamespacedName{Namespace: "ns", Name: "ingress_1"})
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	assert.Equal(t, dump.Name, "ns/ingress_1")
+	assert.Equal(t, len(dump.Clusters), 1)
+	assert.Equal(t, len(dump.ExternalVirtualHosts), 1)
+	assert.DeepEqual(t, dump.ExternalSNIMatches, []SNIMatchDump{{Hosts: []string{"foo.example.com"}, CertSource: "secretns/secretname"}})
+
+	// Certificates and keys are never exposed.
+	marshaled, err := json.Marshal(dump)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(marshaled), "PRIVATE KEY"))
+
+	snapshot, err := caches.ToEnvoySnapshot(ctx)
+	assert.NilError(t, err)
+	snapshotDump, err := DumpSnapshot(snapshot)
+	assert.NilError(t, err)
+	assert.Equal(t, snapshotDump.Version, snapshot.GetVersion(resource.ListenerType))
+	assert.Assert(t, snapshotDump.Clusters["cluster_ingress_1"] != nil)
+}
+
+func TestSnapshotDumpRoundTrip(t *testing.T) {
+	kubeClient := fake.Clientset{}
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+
+	caches, err := NewCaches(ctx, &kubeClient)
+	assert.NilError(t, err)
+	createTestDataForIngress(caches, "ingress_1", "ns", "cluster_ingress_1",
+		"internal_ingress_1", "external_ingress_1", "external_tls_ingress_1")
+	snapshot, err := caches.ToEnvoySnapshot(ctx)
+	assert.NilError(t, err)
+	dump, err := DumpSnapshot(snapshot)
+	assert.NilError(t, err)
+
+	// Dumps are read back from their JSON form.
+	marshaled, err := json.Marshal(dump)
+	assert.NilError(t, err)
+	var parsed SnapshotDump
+	assert.NilError(t, json.Unmarshal(marshaled, &parsed))
+	got, err := parsed.Snapshot()
+	assert.NilError(t, err)
+
+	for _, typeURL := range []string{resource.ListenerType, resource.RouteType, resource.ClusterType, resource.EndpointType} {
+		want := snapshot.GetResources(typeURL)
+		assert.Equal(t, len(got.GetResources(typeURL)), len(want), typeURL)
+		for name, r := range got.GetResources(typeURL) {
+			assert.Assert(t, proto.Equal(r, want[name]), "%s %s", typeURL, name)
+		}
+	}
+	assert.Equal(t, len(got.GetResources(resource.SecretType)), 0)
+}
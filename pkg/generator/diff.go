@@ -0,0 +1,350 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ChangeType is how a resource differs between two snapshots.
+type ChangeType string
+
+const (
+	ResourceAdded   ChangeType = "added"
+	ResourceRemoved ChangeType = "removed"
+	ResourceChanged ChangeType = "changed"
+)
+
+// ResourceChange is a resource that differs between two snapshots.
+type ResourceChange struct {
+	Name string `json:"name"`
+	// Parent is the route config of a virtual host, or the route config and the
+	// virtual host of a route, joined with a slash.
+	Parent string     `json:"parent,omitempty"`
+	Change ChangeType `json:"change"`
+	// Ingresses are the ingresses the resource is generated for, as far as they can
+	// be told from the snapshots.
+	Ingresses []string `json:"ingresses,omitempty"`
+}
+
+// SnapshotDiff holds the resources that differ between two snapshots. Routes are
+// only listed individually for virtual hosts that exist in both snapshots, and
+// virtual hosts only differ by their own fields, not by their routes. Endpoints
+// and secrets are left out: endpoints change whenever a revision scales and
+// secrets must not be exposed.
+type SnapshotDiff struct {
+	Listeners    []ResourceChange `json:"listeners,omitempty"`
+	VirtualHosts []ResourceChange `json:"virtualHosts,omitempty"`
+	Routes       []ResourceChange `json:"routes,omitempty"`
+	Clusters     []ResourceChange `json:"clusters,omitempty"`
+}
+
+// Empty returns true if no resource differs.
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.Listeners) == 0 && len(d.VirtualHosts) == 0 && len(d.Routes) == 0 && len(d.Clusters) == 0
+}
+
+// Ingresses returns the ingresses tied to any of the changes.
+func (d *SnapshotDiff) Ingresses() []string {
+	ingresses := sets.New[string]()
+	for _, change := range slices.Concat(d.Listeners, d.VirtualHosts, d.Routes, d.Clusters) {
+		ingresses.Insert(change.Ingresses...)
+	}
+	return sets.List(ingresses)
+}
+
+// DiffSnapshots returns the resources that differ between the old and the new
+// snapshot. A nil snapshot is empty. The changes are tied to the ingresses that
+// own the virtual hosts and routes they are generated for or referenced by.
+func DiffSnapshots(old, new cache.ResourceSnapshot) *SnapshotDiff {
+	owners := newSnapshotOwners()
+	owners.add(old)
+	owners.add(new)
+
+	diff := &SnapshotDiff{}
+	diffResources(old, new, resource.ClusterType, func(name string, change ChangeType, _, _ cachetypes.Resource) {
+		diff.Clusters = append(diff.Clusters, ResourceChange{
+			Name:      name,
+			Change:    change,
+			Ingresses: sets.List(owners.clusters[name]),
+		})
+	})
+	diffResources(old, new, resource.ListenerType, func(name string, change ChangeType, oldRes, newRes cachetypes.Resource) {
+		oldListener, _ := oldRes.(*listener.Listener)
+		newListener, _ := newRes.(*listener.Listener)
+		diff.Listeners = append(diff.Listeners, ResourceChange{
+			Name:      name,
+			Change:    change,
+			Ingresses: owners.ingressesForServerNames(changedServerNames(oldListener, newListener)),
+		})
+	})
+	diffResources(old, new, resource.RouteType, func(name string, _ ChangeType, oldRes, newRes cachetypes.Resource) {
+		oldConfig, _ := oldRes.(*route.RouteConfiguration)
+		newConfig, _ := newRes.(*route.RouteConfiguration)
+		diff.diffVirtualHosts(name, oldConfig.GetVirtualHosts(), newConfig.GetVirtualHosts())
+	})
+
+	for _, changes := range [][]ResourceChange{diff.Listeners, diff.VirtualHosts, diff.Routes, diff.Clusters} {
+		slices.SortFunc(changes, func(a, b ResourceChange) int {
+			return cmp.Or(cmp.Compare(a.Parent, b.Parent), cmp.Compare(a.Name, b.Name))
+		})
+	}
+	return diff
+}
+
+// LogSnapshotDiffs logs the resources that changed for every node whose snapshot
+// was replaced. The first snapshot of a node isn't logged, it would list every
+// resource.
+func LogSnapshotDiffs(logger *zap.SugaredLogger, previous map[string]cache.ResourceSnapshot, snapshots map[string]*cache.Snapshot) {
+	for node, snapshot := range snapshots {
+		old, ok := previous[node]
+		if !ok || old.GetVersion(resource.ListenerType) == snapshot.GetVersion(resource.ListenerType) {
+			continue
+		}
+		diff := DiffSnapshots(old, snapshot)
+		if diff.Empty() {
+			continue
+		}
+		logger.Infow("Published snapshot",
+			zap.String("node", node),
+			zap.String("version", snapshot.GetVersion(resource.ListenerType)),
+			zap.String("previousVersion", old.GetVersion(resource.ListenerType)),
+			zap.Strings("ingresses", diff.Ingresses()),
+			zap.Any("changes", diff))
+	}
+}
+
+// diffResources calls f for every resource of the given type that differs between
+// the snapshots. Resources with the same version are equal, and resources are
+// compared otherwise.
+func diffResources(old, new cache.ResourceSnapshot, typeURL string, f func(name string, change ChangeType, oldRes, newRes cachetypes.Resource)) {
+	oldResources, newResources := snapshotResources(old, typeURL), snapshotResources(new, typeURL)
+	oldVersions, newVersions := snapshotVersions(old, typeURL), snapshotVersions(new, typeURL)
+
+	for name, newRes := range newResources {
+		oldRes, ok := oldResources[name]
+		switch {
+		case !ok:
+			f(name, ResourceAdded, nil, newRes)
+		case oldVersions[name] != "" && oldVersions[name] == newVersions[name]:
+		case !proto.Equal(oldRes, newRes):
+			f(name, ResourceChanged, oldRes, newRes)
+		}
+	}
+	for name, oldRes := range oldResources {
+		if _, ok := newResources[name]; !ok {
+			f(name, ResourceRemoved, oldRes, nil)
+		}
+	}
+}
+
+func snapshotResources(snapshot cache.ResourceSnapshot, typeURL string) map[string]cachetypes.Resource {
+	if snapshot == nil {
+		return nil
+	}
+	return snapshot.GetResources(typeURL)
+}
+
+func snapshotVersions(snapshot cache.ResourceSnapshot, typeURL string) map[string]string {
+	if snapshot == nil {
+		return nil
+	}
+	return snapshot.GetVersionMap(typeURL)
+}
+
+// diffVirtualHosts adds the virtual hosts and routes that differ between two
+// versions of the given route config.
+func (d *SnapshotDiff) diffVirtualHosts(routeConfig string, old, new []*route.VirtualHost) {
+	oldByName := virtualHostsByName(old)
+	for _, newHost := range new {
+		oldHost, ok := oldByName[newHost.GetName()]
+		delete(oldByName, newHost.GetName())
+		if !ok {
+			d.VirtualHosts = append(d.VirtualHosts, virtualHostChange(routeConfig, newHost, ResourceAdded))
+			continue
+		}
+		if !proto.Equal(withoutRoutes(oldHost), withoutRoutes(newHost)) {
+			d.VirtualHosts = append(d.VirtualHosts, virtualHostChange(routeConfig, newHost, ResourceChanged))
+		}
+		d.diffRoutes(routeConfig+"/"+newHost.GetName(), oldHost.GetRoutes(), newHost.GetRoutes())
+	}
+	for _, oldHost := range oldByName {
+		d.VirtualHosts = append(d.VirtualHosts, virtualHostChange(routeConfig, oldHost, ResourceRemoved))
+	}
+}
+
+// diffRoutes adds the routes that differ between two versions of the given
+// virtual host. Routes sharing a name are compared as a whole.
+func (d *SnapshotDiff) diffRoutes(parent string, old, new []*route.Route) {
+	oldByName, newByName := routesByName(old), routesByName(new)
+	for _, name := range sets.List(sets.KeySet(oldByName).Union(sets.KeySet(newByName))) {
+		oldRoutes, inOld := oldByName[name]
+		newRoutes, inNew := newByName[name]
+		var change ChangeType
+		switch {
+		case !inOld:
+			change = ResourceAdded
+		case !inNew:
+			change = ResourceRemoved
+		case !slices.EqualFunc(oldRoutes, newRoutes, func(a, b *route.Route) bool { return proto.Equal(a, b) }):
+			change = ResourceChanged
+		default:
+			continue
+		}
+		d.Routes = append(d.Routes, ResourceChange{
+			Name:      name,
+			Parent:    parent,
+			Change:    change,
+			Ingresses: ingressesInName(name + " " + parent),
+		})
+	}
+}
+
+func virtualHostChange(routeConfig string, vhost *route.VirtualHost, change ChangeType) ResourceChange {
+	return ResourceChange{
+		Name:      vhost.GetName(),
+		Parent:    routeConfig,
+		Change:    change,
+		Ingresses: ingressesInName(vhost.GetName()),
+	}
+}
+
+func virtualHostsByName(vhosts []*route.VirtualHost) map[string]*route.VirtualHost {
+	byName := make(map[string]*route.VirtualHost, len(vhosts))
+	for _, vhost := range vhosts {
+		byName[vhost.GetName()] = vhost
+	}
+	return byName
+}
+
+func routesByName(routes []*route.Route) map[string][]*route.Route {
+	byName := make(map[string][]*route.Route, len(routes))
+	for _, r := range routes {
+		byName[r.GetName()] = append(byName[r.GetName()], r)
+	}
+	return byName
+}
+
+func withoutRoutes(vhost *route.VirtualHost) *route.VirtualHost {
+	clone := proto.Clone(vhost).(*route.VirtualHost)
+	clone.Routes = nil
+	return clone
+}
+
+// changedServerNames returns the server names of the filter chains that differ
+// between two versions of a listener.
+func changedServerNames(old, new *listener.Listener) []string {
+	oldChains, newChains := filterChainsByServerNames(old), filterChainsByServerNames(new)
+	changed := sets.New[string]()
+	for key, newChain := range newChains {
+		if oldChain, ok := oldChains[key]; !ok || !proto.Equal(oldChain, newChain) {
+			changed.Insert(newChain.GetFilterChainMatch().GetServerNames()...)
+		}
+	}
+	for key, oldChain := range oldChains {
+		if _, ok := newChains[key]; !ok {
+			changed.Insert(oldChain.GetFilterChainMatch().GetServerNames()...)
+		}
+	}
+	return sets.List(changed)
+}
+
+func filterChainsByServerNames(l *listener.Listener) map[string]*listener.FilterChain {
+	chains := make(map[string]*listener.FilterChain, len(l.GetFilterChains()))
+	for _, chain := range l.GetFilterChains() {
+		chains[strings.Join(chain.GetFilterChainMatch().GetServerNames(), ",")] = chain
+	}
+	return chains
+}
+
+// ingressesInName returns the ingresses encoded in the given resource names.
+func ingressesInName(name string) []string {
+	ingresses := sets.New[string]()
+	for _, match := range resourceNameRegexp.FindAllStringSubmatch(name, -1) {
+		ingresses.Insert(match[1] + "/" + match[2])
+	}
+	return sets.List(ingresses)
+}
+
+// snapshotOwners maps the clusters and domains of snapshots to the ingresses whose
+// virtual hosts and routes use them.
+type snapshotOwners struct {
+	clusters map[string]sets.Set[string]
+	domains  map[string]sets.Set[string]
+}
+
+func newSnapshotOwners() *snapshotOwners {
+	return &snapshotOwners{
+		clusters: make(map[string]sets.Set[string]),
+		domains:  make(map[string]sets.Set[string]),
+	}
+}
+
+func (o *snapshotOwners) add(snapshot cache.ResourceSnapshot) {
+	for _, res := range snapshotResources(snapshot, resource.RouteType) {
+		routeConfig, ok := res.(*route.RouteConfiguration)
+		if !ok {
+			continue
+		}
+		for _, vhost := range routeConfig.GetVirtualHosts() {
+			vhostIngresses := ingressesInName(vhost.GetName())
+			for _, domain := range vhost.GetDomains() {
+				insertOwners(o.domains, domain, vhostIngresses)
+			}
+			for _, r := range vhost.GetRoutes() {
+				ingresses := vhostIngresses
+				if fromRoute := ingressesInName(r.GetName()); len(fromRoute) > 0 {
+					ingresses = fromRoute
+				}
+				for _, cluster := range routeClusters(r) {
+					insertOwners(o.clusters, cluster, ingresses)
+				}
+			}
+		}
+	}
+}
+
+// ingressesForServerNames returns the ingresses serving the given server names.
+func (o *snapshotOwners) ingressesForServerNames(serverNames []string) []string {
+	ingresses := sets.New[string]()
+	for _, name := range serverNames {
+		ingresses = ingresses.Union(o.domains[name])
+	}
+	return slices.Sorted(maps.Keys(ingresses))
+}
+
+func insertOwners(owners map[string]sets.Set[string], key string, ingresses []string) {
+	if len(ingresses) == 0 {
+		return
+	}
+	if owners[key] == nil {
+		owners[key] = sets.New[string]()
+	}
+	owners[key].Insert(ingresses...)
+}
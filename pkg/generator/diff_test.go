@@ -0,0 +1,92 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/client-go/kubernetes/fake"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+	caches, err := NewCaches(ctx, fake.NewSimpleClientset())
+	assert.NilError(t, err)
+
+	assert.NilError(t, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(0, "rev-1")))
+	assert.NilError(t, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(1, "rev-1")))
+	assert.NilError(t, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(2, "rev-1")))
+	before, _, err := caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+
+	assert.NilError(t, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(0, "rev-2")))
+	assert.NilError(t, caches.DeleteIngressInfo(ctx, "ingress-1", "ns-1"))
+	assert.NilError(t, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(3, "rev-1")))
+	// Ingress 10 serves TLS, adding a filter chain to the HTTPS listener.
+	assert.NilError(t, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(10, "rev-1")))
+	after, _, err := caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+
+	node := config.DefaultGatewayFleet
+	diff := DiffSnapshots(before[node], after[node])
+
+	ingress := func(i int) string { return fmt.Sprintf("ns-%d/ingress-%d", i, i) }
+	vhost := func(i int) string {
+		return fmt.Sprintf("(ns-%d/ingress-%d).Domain[ingress-%d.ns-%d.example.com]", i, i, i, i)
+	}
+	assert.DeepEqual(t, diff.Listeners, []ResourceChange{
+		{Name: "listener_8443", Change: ResourceChanged, Ingresses: []string{ingress(10)}},
+		{Name: "listener_9443", Change: ResourceChanged, Ingresses: []string{ingress(10)}},
+	})
+	assert.DeepEqual(t, diff.VirtualHosts, []ResourceChange{
+		{Name: vhost(1), Parent: "external_services", Change: ResourceRemoved, Ingresses: []string{ingress(1)}},
+		{Name: vhost(10), Parent: "external_services", Change: ResourceAdded, Ingresses: []string{ingress(10)}},
+		{Name: vhost(3), Parent: "external_services", Change: ResourceAdded, Ingresses: []string{ingress(3)}},
+		{Name: vhost(10), Parent: "external_tls_services", Change: ResourceAdded, Ingresses: []string{ingress(10)}},
+		{Name: vhost(1), Parent: "internal_services", Change: ResourceRemoved, Ingresses: []string{ingress(1)}},
+		{Name: vhost(10), Parent: "internal_services", Change: ResourceAdded, Ingresses: []string{ingress(10)}},
+		{Name: vhost(3), Parent: "internal_services", Change: ResourceAdded, Ingresses: []string{ingress(3)}},
+	})
+	// Only the routes of ingress 0 changed, those of added and removed virtual
+	// hosts aren't listed.
+	route := vhost(0) + ".Paths[/]"
+	assert.DeepEqual(t, diff.Routes, []ResourceChange{
+		{Name: route, Parent: "external_services/" + vhost(0), Change: ResourceChanged, Ingresses: []string{ingress(0)}},
+		{Name: route, Parent: "external_tls_services/" + vhost(0), Change: ResourceChanged, Ingresses: []string{ingress(0)}},
+		{Name: route, Parent: "internal_services/" + vhost(0), Change: ResourceChanged, Ingresses: []string{ingress(0)}},
+	})
+	// Unused clusters are kept for a while, so only added clusters show up.
+	assert.DeepEqual(t, diff.Clusters, []ResourceChange{
+		{Name: "ns-0/rev-2", Change: ResourceAdded, Ingresses: []string{ingress(0)}},
+		{Name: "ns-10/rev-1", Change: ResourceAdded, Ingresses: []string{ingress(10)}},
+		{Name: "ns-3/rev-1", Change: ResourceAdded, Ingresses: []string{ingress(3)}},
+	})
+	assert.DeepEqual(t, diff.Ingresses(), []string{ingress(0), ingress(1), ingress(10), ingress(3)})
+
+	assert.Assert(t, DiffSnapshots(after[node], after[node]).Empty())
+
+	// Everything is added to an empty snapshot.
+	initial := DiffSnapshots(nil, before[node])
+	assert.Equal(t, len(initial.Clusters), 3)
+	for _, change := range initial.Listeners {
+		assert.Equal(t, change.Change, ResourceAdded)
+	}
+}
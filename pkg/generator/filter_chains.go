@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"bytes"
+	"slices"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	httpconnmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"k8s.io/apimachinery/pkg/types"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+)
+
+// filterChainCache keeps the SNI filter chains of the HTTPS listeners and the SDS
+// secrets they refer to across snapshots, so that only the ones whose SNI match
+// changed are rebuilt.
+type filterChainCache struct {
+	// cfg is the config the cached filter chains were built with. All of them are
+	// rebuilt once it changes.
+	cfg *config.Kourier
+
+	// current holds the entries of the last snapshot, next the ones of the snapshot
+	// being built. Entries that are not used anymore are dropped by only keeping
+	// next around once the snapshot is done.
+	current map[filterChainKey]cachedFilterChain
+	next    map[filterChainKey]cachedFilterChain
+
+	currentSecrets map[types.NamespacedName]cachedSecret
+	nextSecrets    map[types.NamespacedName]cachedSecret
+}
+
+type filterChainKey struct {
+	// routeConfigName identifies the connection manager of the filter chain.
+	routeConfigName string
+	certSource      types.NamespacedName
+}
+
+type cachedFilterChain struct {
+	hosts       []string
+	filterChain *listener.FilterChain
+}
+
+type cachedSecret struct {
+	certificateChain []byte
+	privateKey       []byte
+	secret           *tlsv3.Secret
+}
+
+func newFilterChainCache() *filterChainCache {
+	return &filterChainCache{
+		current:        make(map[filterChainKey]cachedFilterChain),
+		next:           make(map[filterChainKey]cachedFilterChain),
+		currentSecrets: make(map[types.NamespacedName]cachedSecret),
+		nextSecrets:    make(map[types.NamespacedName]cachedSecret),
+	}
+}
+
+// filterChains returns the filter chains for the given SNI matches, backed by the
+// given manager. Filter chains built for an earlier snapshot are reused if their
+// SNI match didn't change.
+func (c *filterChainCache) filterChains(manager *httpconnmanagerv3.HttpConnectionManager, sniMatches []*envoy.SNIMatch, cfg *config.Kourier) ([]*listener.FilterChain, error) {
+	if c.cfg != cfg {
+		c.cfg = cfg
+		clear(c.current)
+		clear(c.next)
+	}
+
+	filterChains := make([]*listener.FilterChain, 0, len(sniMatches))
+	for _, sniMatch := range sniMatches {
+		key := filterChainKey{
+			routeConfigName: manager.GetRds().GetRouteConfigName(),
+			certSource:      sniMatch.CertSource,
+		}
+
+		cached, ok := c.current[key]
+		if !ok || !slices.Equal(cached.hosts, sniMatch.Hosts) {
+			// Build the filter chain from a copy of the hosts, as the match might be
+			// modified after the fact.
+			match := *sniMatch
+			match.Hosts = slices.Clone(sniMatch.Hosts)
+			filterChain, err := envoy.NewSNIFilterChain(manager, &match, cfg)
+			if err != nil {
+				return nil, err
+			}
+			cached = cachedFilterChain{hosts: match.Hosts, filterChain: filterChain}
+		}
+
+		c.next[key] = cached
+		filterChains = append(filterChains, cached.filterChain)
+	}
+	return filterChains, nil
+}
+
+// secrets returns the SDS secrets carrying the certificates of the given SNI
+// matches. Secrets built for an earlier snapshot are reused if the certificate
+// didn't change.
+func (c *filterChainCache) secrets(sniMatches []*envoy.SNIMatch) ([]*tlsv3.Secret, error) {
+	secrets := make([]*tlsv3.Secret, 0, len(sniMatches))
+	for _, sniMatch := range sniMatches {
+		cached, ok := c.currentSecrets[sniMatch.CertSource]
+		if !ok || !bytes.Equal(cached.certificateChain, sniMatch.CertificateChain) || !bytes.Equal(cached.privateKey, sniMatch.PrivateKey) {
+			secret, err := envoy.NewSecret(&envoy.Certificate{
+				SecretName:  envoy.SecretName(sniMatch.CertSource, ""),
+				Certificate: sniMatch.CertificateChain,
+				PrivateKey:  sniMatch.PrivateKey,
+			})
+			if err != nil {
+				return nil, err
+			}
+			cached = cachedSecret{
+				certificateChain: sniMatch.CertificateChain,
+				privateKey:       sniMatch.PrivateKey,
+				secret:           secret,
+			}
+		}
+
+		c.nextSecrets[sniMatch.CertSource] = cached
+		secrets = append(secrets, cached.secret)
+	}
+	return secrets, nil
+}
+
+// commit drops the entries that were not used by the snapshot just built.
+func (c *filterChainCache) commit() {
+	c.current, c.next = c.next, c.current
+	clear(c.next)
+	c.currentSecrets, c.nextSecrets = c.nextSecrets, c.currentSecrets
+	clear(c.nextSecrets)
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"maps"
+	"slices"
+
+	v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	httpconnmanagerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"k8s.io/apimachinery/pkg/types"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+)
+
+// gatewayFleet holds what is assembled separately for every fleet of gateways
+// from the ingresses selected for it.
+type gatewayFleet struct {
+	// ingresses holds the ingresses selected for the fleet, sorted by name.
+	ingresses []*translatedIngress
+
+	// The route tables and filter chains are patched as ingresses change, rather
+	// than assembled from all translated ingresses for every snapshot.
+	externalRouteTable    *routeTable
+	externalTLSRouteTable *routeTable
+	localRouteTable       *routeTable
+	localTLSRouteTable    *routeTable
+	filterChains          *filterChainCache
+	// httpsListeners holds the HTTPS listeners of earlier snapshots by port, so
+	// that they're reused, and not hashed again, while their filter chains don't
+	// change.
+	httpsListeners map[uint32]cachedListener
+
+	// routeConfigVersions holds the versions of the route configs assembled since
+	// the last snapshot, which are known without hashing them.
+	routeConfigVersions map[cachetypes.Resource]string
+	// typeVersions holds the resources of the last snapshot and their versions, by
+	// type. Resources are never modified once built, so the ones carried over into
+	// the next snapshot don't need to be hashed again, and only the versions of the
+	// resources that changed are patched.
+	typeVersions [cachetypes.UnknownType]typeVersions
+}
+
+type typeVersions struct {
+	resources map[string]cachetypes.Resource
+	// versions is shared with the snapshots, so it's copied before being patched.
+	versions map[string]string
+	digests  map[string][4]uint64
+	// digest is the sum of the digests of all resources, so that the version of a
+	// snapshot doesn't depend on the order of its resources and they don't need
+	// sorting.
+	digest [4]uint64
+}
+
+type cachedListener struct {
+	cfg           *config.Kourier
+	proxyProtocol bool
+	filterChains  []*v3.FilterChain
+	listener      *v3.Listener
+}
+
+// resourceDigest returns the hash of the name and the version of a resource.
+func resourceDigest(name, version string) [4]uint64 {
+	hasher := sha256.New()
+	hasher.Write([]byte(name))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(version))
+	sum := hasher.Sum(nil)
+
+	var digest [4]uint64
+	for i := range digest {
+		digest[i] = binary.LittleEndian.Uint64(sum[i*8:])
+	}
+	return digest
+}
+
+func newGatewayFleet() *gatewayFleet {
+	return &gatewayFleet{
+		externalRouteTable:    newRouteTable(),
+		externalTLSRouteTable: newRouteTable(),
+		localRouteTable:       newRouteTable(),
+		localTLSRouteTable:    newRouteTable(),
+		filterChains:          newFilterChainCache(),
+		httpsListeners:        make(map[uint32]cachedListener),
+	}
+}
+
+// empty returns whether no ingress is selected for the fleet.
+func (f *gatewayFleet) empty() bool {
+	return f.externalRouteTable.len() == 0 && f.externalTLSRouteTable.len() == 0 &&
+		f.localRouteTable.len() == 0 && f.localTLSRouteTable.len() == 0
+}
+
+// addIngress adds the given ingress to the ingresses selected for the fleet, or
+// replaces it.
+func (f *gatewayFleet) addIngress(translated *translatedIngress) {
+	i, found := slices.BinarySearchFunc(f.ingresses, translated.name, compareTranslatedIngressName)
+	if found {
+		f.ingresses[i] = translated
+	} else {
+		f.ingresses = slices.Insert(f.ingresses, i, translated)
+	}
+}
+
+// deleteIngress removes the given ingress from the ingresses selected for the fleet.
+func (f *gatewayFleet) deleteIngress(key types.NamespacedName) {
+	if i, found := slices.BinarySearchFunc(f.ingresses, key, compareTranslatedIngressName); found {
+		f.ingresses = slices.Delete(f.ingresses, i, i+1)
+	}
+}
+
+func compareTranslatedIngressName(translated *translatedIngress, key types.NamespacedName) int {
+	return compareNamespacedNames(translated.name, key)
+}
+
+func (f *gatewayFleet) setRouteTables(translatedIngress *translatedIngress) error {
+	key := translatedIngress.name
+	if err := f.externalRouteTable.set(key, translatedIngress.externalVirtualHosts); err != nil {
+		return err
+	}
+	if err := f.externalTLSRouteTable.set(key, translatedIngress.externalTLSVirtualHosts); err != nil {
+		return err
+	}
+	if err := f.localRouteTable.set(key, translatedIngress.localVirtualHosts); err != nil {
+		return err
+	}
+	return f.localTLSRouteTable.set(key, translatedIngress.localTLSVirtualHosts)
+}
+
+func (f *gatewayFleet) deleteRouteTables(key types.NamespacedName) {
+	f.externalRouteTable.delete(key)
+	f.externalTLSRouteTable.delete(key)
+	f.localRouteTable.delete(key)
+	f.localTLSRouteTable.delete(key)
+}
+
+// routeConfig returns the route config with the given name assembled from the given
+// route table, and remembers its version so it isn't hashed again.
+func (f *gatewayFleet) routeConfig(table *routeTable, name string, extraVirtualHosts ...*route.VirtualHost) (*route.RouteConfiguration, error) {
+	routeConfig, version, err := table.routeConfig(name, extraVirtualHosts...)
+	if err != nil {
+		return nil, err
+	}
+	if f.routeConfigVersions == nil {
+		f.routeConfigVersions = make(map[cachetypes.Resource]string)
+	}
+	f.routeConfigVersions[routeConfig] = version
+	return routeConfig, nil
+}
+
+// newHTTPSListenerWithSNI is like envoy.NewHTTPSListenerWithSNI, but reuses the filter
+// chains of earlier snapshots, and the listener itself if none of them changed.
+func (f *gatewayFleet) newHTTPSListenerWithSNI(manager *httpconnmanagerv3.HttpConnectionManager, port uint32, sniMatches []*envoy.SNIMatch, kourierConfig *config.Kourier) (*v3.Listener, error) {
+	filterChains, err := f.filterChains.filterChains(manager, sniMatches, kourierConfig)
+	if err != nil {
+		return nil, err
+	}
+	cached, ok := f.httpsListeners[port]
+	if ok && cached.cfg == kourierConfig && cached.proxyProtocol == kourierConfig.EnableProxyProtocol && slices.Equal(cached.filterChains, filterChains) {
+		return cached.listener, nil
+	}
+
+	listener, err := envoy.NewHTTPSListenerWithSNIFilterChains(port, filterChains, kourierConfig)
+	if err != nil {
+		return nil, err
+	}
+	f.httpsListeners[port] = cachedListener{
+		cfg:           kourierConfig,
+		proxyProtocol: kourierConfig.EnableProxyProtocol,
+		filterChains:  filterChains,
+		listener:      listener,
+	}
+	return listener, nil
+}
+
+// setSnapshotVersion versions the given snapshot by a hash of its contents, so that
+// identical state results in identical versions across resyncs and controller
+// replicas. The per-resource versions used by delta xDS are computed along the way.
+func (f *gatewayFleet) setSnapshotVersion(snapshot *cache.Snapshot) error {
+	snapshot.VersionMap = make(map[string]map[string]string, len(snapshot.Resources))
+
+	hasher := sha256.New()
+	for i, resources := range snapshot.Resources {
+		typeURL, err := cache.GetResponseTypeURL(cachetypes.ResponseType(i))
+		if err != nil {
+			return err
+		}
+
+		state := &f.typeVersions[i]
+		if err := f.patchTypeVersions(state, resources.Items); err != nil {
+			// Start over for the next snapshot, as the state was partially patched.
+			*state = typeVersions{}
+			return err
+		}
+		snapshot.VersionMap[typeURL] = state.versions
+
+		if len(state.versions) == 0 {
+			continue
+		}
+		hasher.Write([]byte(typeURL))
+		for _, lane := range state.digest {
+			hasher.Write(binary.LittleEndian.AppendUint64(nil, lane))
+		}
+	}
+	version := hex.EncodeToString(hasher.Sum(nil))
+
+	for i := range snapshot.Resources {
+		snapshot.Resources[i].Version = version
+	}
+	clear(f.routeConfigVersions)
+	return nil
+}
+
+// patchTypeVersions updates the given state to the given resources of a type.
+func (f *gatewayFleet) patchTypeVersions(state *typeVersions, items map[string]cachetypes.ResourceWithTTL) error {
+	if state.resources == nil {
+		state.resources = make(map[string]cachetypes.Resource, len(items))
+		state.versions = make(map[string]string, len(items))
+		state.digests = make(map[string][4]uint64, len(items))
+	}
+
+	var changed []string
+	added := 0
+	for name, item := range items {
+		previous, ok := state.resources[name]
+		if !ok {
+			added++
+		}
+		if !ok || previous != item.Resource {
+			changed = append(changed, name)
+		}
+	}
+	removed := len(state.resources)+added != len(items)
+	if len(changed) == 0 && !removed {
+		return nil
+	}
+
+	state.versions = maps.Clone(state.versions)
+	if removed {
+		for name := range state.resources {
+			if _, ok := items[name]; !ok {
+				state.subtract(name)
+				delete(state.resources, name)
+				delete(state.versions, name)
+				delete(state.digests, name)
+			}
+		}
+	}
+	for _, name := range changed {
+		resource := items[name].Resource
+		version, ok := f.routeConfigVersions[resource]
+		if !ok {
+			marshaled, err := cache.MarshalResource(resource)
+			if err != nil {
+				return err
+			}
+			version = cache.HashResource(marshaled)
+		}
+
+		state.subtract(name)
+		digest := resourceDigest(name, version)
+		for j := range state.digest {
+			state.digest[j] += digest[j]
+		}
+		state.resources[name] = resource
+		state.versions[name] = version
+		state.digests[name] = digest
+	}
+	return nil
+}
+
+// subtract removes the digest of the given resource from the digest of its type.
+func (t *typeVersions) subtract(name string) {
+	digest := t.digests[name]
+	for j := range t.digest {
+		t.digest[j] -= digest[j]
+	}
+}
@@ -17,12 +17,14 @@ limitations under the License.
 package generator
 
 import (
+	"cmp"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"slices"
 	"strings"
@@ -48,6 +50,7 @@ import (
 	"knative.dev/networking/pkg/apis/networking/v1alpha1"
 	"knative.dev/networking/pkg/certificates"
 	netconfig "knative.dev/networking/pkg/config"
+	"knative.dev/networking/pkg/http/header"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/system"
@@ -59,17 +62,28 @@ type translatedIngress struct {
 	localSNIMatches         []*envoy.SNIMatch
 	externalSNIMatches      []*envoy.SNIMatch
 	clusters                []*v3.Cluster
+	loadAssignments         []*endpoint.ClusterLoadAssignment
 	externalVirtualHosts    []*route.VirtualHost
 	externalTLSVirtualHosts []*route.VirtualHost
 	localVirtualHosts       []*route.VirtualHost
 	localTLSVirtualHosts    []*route.VirtualHost
+	// upstreamTrustBundle is the SDS secret holding the CA bundle upstream TLS
+	// contexts validate against. It is nil unless system-internal-tls is enabled.
+	upstreamTrustBundle *tlsv3.Secret
+	// fleet is the node ID of the gateways serving the ingress.
+	fleet string
 }
 
+// upstreamTrustBundleSecretName is the name of the SDS secret holding the CA bundle
+// built by buildTrustChain.
+const upstreamTrustBundleSecretName = "upstream-trust-bundle"
+
 type IngressTranslator struct {
 	secretGetter         func(ns, name string) (*corev1.Secret, error)
 	nsConfigmapGetter    func(label string) ([]*corev1.ConfigMap, error)
 	endpointSlicesGetter func(ns, name string) ([]*discoveryv1.EndpointSlice, error)
 	serviceGetter        func(ns, name string) (*corev1.Service, error)
+	namespaceGetter      func(name string) (*corev1.Namespace, error)
 	tracker              tracker.Interface
 }
 
@@ -78,6 +92,7 @@ func NewIngressTranslator(
 	nsConfigmapGetter func(label string) ([]*corev1.ConfigMap, error),
 	endpointSlicesGetter func(ns, name string) ([]*discoveryv1.EndpointSlice, error),
 	serviceGetter func(ns, name string) (*corev1.Service, error),
+	namespaceGetter func(name string) (*corev1.Namespace, error),
 	tracker tracker.Interface,
 ) IngressTranslator {
 	return IngressTranslator{
@@ -85,6 +100,7 @@ func NewIngressTranslator(
 		nsConfigmapGetter:    nsConfigmapGetter,
 		endpointSlicesGetter: endpointSlicesGetter,
 		serviceGetter:        serviceGetter,
+		namespaceGetter:      namespaceGetter,
 		tracker:              tracker,
 	}
 }
@@ -92,6 +108,16 @@ func NewIngressTranslator(
 func (translator *IngressTranslator) translateIngress(ctx context.Context, ingress *v1alpha1.Ingress) (*translatedIngress, error) {
 	logger := logging.FromContext(ctx)
 
+	fleet, err := translator.gatewayFleet(ingress, config.FromContext(ctx).Kourier)
+	if err != nil {
+		return nil, err
+	}
+
+	pathOptions, err := routeOptions(ingress)
+	if err != nil {
+		return nil, err
+	}
+
 	localIngressTLS := ingress.GetIngressTLSForVisibility(v1alpha1.IngressVisibilityClusterLocal)
 	externalIngressTLS := ingress.GetIngressTLSForVisibility(v1alpha1.IngressVisibilityExternalIP)
 
@@ -119,11 +145,21 @@ func (translator *IngressTranslator) translateIngress(ctx context.Context, ingre
 	externalHosts := make(map[string]*route.VirtualHost)
 	externalTLSHosts := make(map[string]*route.VirtualHost)
 	clusters := make([]*v3.Cluster, 0, len(ingress.Spec.Rules))
+	var loadAssignments []*endpoint.ClusterLoadAssignment
 
 	cfg := config.FromContext(ctx)
 
-	var err error
+	retryPolicy, err := cfg.Kourier.RetryPolicy.WithAnnotations(ingress.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	timeouts, err := cfg.Kourier.RouteTimeouts.WithAnnotations(ingress.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
 	var trustChain []byte
+	var upstreamTrustBundle *tlsv3.Secret
 	if cfg.Network.SystemInternalTLSEnabled() {
 		trustChain, err = translator.buildTrustChain(logger)
 		if err != nil {
@@ -132,6 +168,7 @@ func (translator *IngressTranslator) translateIngress(ctx context.Context, ingre
 		if trustChain == nil {
 			return nil, errors.New("failed to build trust-chain, as no valid CA certificate was provided. Please make sure to provide a valid trust-bundle before enabling `system-internal-tls`")
 		}
+		upstreamTrustBundle = envoy.NewValidationContextSecret(upstreamTrustBundleSecretName, trustChain)
 	}
 
 	for _, rule := range ingress.Spec.Rules {
@@ -148,9 +185,16 @@ func (translator *IngressTranslator) translateIngress(ctx context.Context, ingre
 		tlsRoutes := make([]*route.Route, 0, len(rule.HTTP.Paths))
 		for _, httpPath := range rule.HTTP.Paths {
 			// Default the path to "/" if none is passed.
-			path := httpPath.Path
-			if path == "" {
-				path = "/"
+			path := ingressPath(httpPath)
+			if err := validateHeaderMatches(httpPath); err != nil {
+				return nil, fmt.Errorf("%w: path %q: %w", ErrInvalidConfig, path, err)
+			}
+			opts := []envoy.RouteOption{envoy.WithRetryPolicy(retryPolicy), envoy.WithIdleTimeout(timeouts.Idle)}
+			// The routes of the probes inserted by UpdateInfoForIngress keep matching the
+			// requests of the status prober, whatever the annotations restrict the routes
+			// of the path to.
+			if _, probe := httpPath.Headers[header.HashKey]; !probe {
+				opts = append(opts, pathOptions[path]...)
 			}
 
 			pathName := fmt.Sprintf("%s.Paths[%s]", routeNamePrefix, path)
@@ -201,7 +245,8 @@ func (translator *IngressTranslator) translateIngress(ctx context.Context, ingre
 					typ               v3.Cluster_DiscoveryType
 				)
 				if service.Spec.Type == corev1.ServiceTypeExternalName {
-					// If the service is of type ExternalName, we add a single endpoint.
+					// If the service is of type ExternalName, we add a single endpoint
+					// that's resolved by Envoy itself.
 					typ = v3.Cluster_LOGICAL_DNS
 					publicLbEndpoints = []*endpoint.LbEndpoint{
 						envoy.NewLBEndpoint(service.Spec.ExternalName, uint32(externalPort)), //#nosec G115
@@ -219,7 +264,9 @@ func (translator *IngressTranslator) translateIngress(ctx context.Context, ingre
 						return nil, nil
 					}
 
-					typ = v3.Cluster_STATIC
+					// The endpoints are served separately via EDS, so that endpoint churn
+					// doesn't cause the cluster itself to change.
+					typ = v3.Cluster_EDS
 					publicLbEndpoints = lbEndpointsForKubeEndpointSlices(slices, targetPort)
 
 					// If endpointslices exist but have no ready addresses, skip this ingress.
@@ -238,12 +285,18 @@ func (translator *IngressTranslator) translateIngress(ctx context.Context, ingre
 				// As Ingress with RewriteHost points to ExternalService(kourier-internal), we don't enable upstream TLS.
 				if (cfg.Network.SystemInternalTLSEnabled()) && httpPath.RewriteHost == "" {
 					var err error
-					transportSocket, err = translator.createUpstreamTransportSocket(http2, split.ServiceNamespace, trustChain)
+					transportSocket, err = translator.createUpstreamTransportSocket(http2, split.ServiceNamespace)
 					if err != nil {
 						return nil, err
 					}
 				}
-				cluster := envoy.NewCluster(splitName, connectTimeout, publicLbEndpoints, http2, transportSocket, typ)
+				var cluster *v3.Cluster
+				if typ == v3.Cluster_EDS {
+					cluster = envoy.NewEDSCluster(splitName, connectTimeout, http2, transportSocket)
+					loadAssignments = append(loadAssignments, envoy.NewClusterLoadAssignment(splitName, publicLbEndpoints))
+				} else {
+					cluster = envoy.NewCluster(splitName, connectTimeout, publicLbEndpoints, http2, transportSocket, typ)
+				}
 				logger.Debugf("adding cluster: %v", cluster)
 				clusters = append(clusters, cluster)
 
@@ -255,19 +308,19 @@ func (translator *IngressTranslator) translateIngress(ctx context.Context, ingre
 				// disable ext_authz filter for HTTP01 challenge when the feature is enabled
 				if cfg.Kourier.ExternalAuthz.Enabled && strings.HasPrefix(path, "/.well-known/acme-challenge/") {
 					routes = append(routes, envoy.NewRouteExtAuthzDisabled(
-						pathName, matchHeadersFromHTTPPath(httpPath), path, wrs, 0, httpPath.AppendHeaders, httpPath.RewriteHost))
+						pathName, matchHeadersFromHTTPPath(httpPath), path, wrs, timeouts.Request, httpPath.AppendHeaders, httpPath.RewriteHost, opts...))
 				} else if _, ok := os.LookupEnv("KOURIER_HTTPOPTION_DISABLED"); !ok && ingress.Spec.HTTPOption == v1alpha1.HTTPOptionRedirected && rule.Visibility == v1alpha1.IngressVisibilityExternalIP {
 					// Do not create redirect route when KOURIER_HTTPOPTION_DISABLED is set. This option is useful when front end proxy handles the redirection.
 					// e.g. Kourier on OpenShift handles HTTPOption by OpenShift Route so KOURIER_HTTPOPTION_DISABLED should be set.
 					routes = append(routes, envoy.NewRedirectRoute(
-						pathName, matchHeadersFromHTTPPath(httpPath), path))
+						pathName, matchHeadersFromHTTPPath(httpPath), path, opts...))
 				} else {
 					routes = append(routes, envoy.NewRoute(
-						pathName, matchHeadersFromHTTPPath(httpPath), path, wrs, 0, httpPath.AppendHeaders, httpPath.RewriteHost))
+						pathName, matchHeadersFromHTTPPath(httpPath), path, wrs, timeouts.Request, httpPath.AppendHeaders, httpPath.RewriteHost, opts...))
 				}
 				if len(ingress.Spec.TLS) != 0 || cfg.Kourier.UseHTTPSListenerWithOneCert() {
 					tlsRoutes = append(tlsRoutes, envoy.NewRoute(
-						pathName, matchHeadersFromHTTPPath(httpPath), path, wrs, 0, httpPath.AppendHeaders, httpPath.RewriteHost))
+						pathName, matchHeadersFromHTTPPath(httpPath), path, wrs, timeouts.Request, httpPath.AppendHeaders, httpPath.RewriteHost, opts...))
 				}
 			}
 		}
@@ -312,16 +365,44 @@ func (translator *IngressTranslator) translateIngress(ctx context.Context, ingre
 			Namespace: ingress.Namespace,
 			Name:      ingress.Name,
 		},
+		fleet:                   fleet,
 		localSNIMatches:         localSNIMatches,
 		externalSNIMatches:      externalSNIMatches,
 		clusters:                clusters,
+		loadAssignments:         loadAssignments,
 		externalVirtualHosts:    virtualHostMapToSlice(externalHosts),
 		externalTLSVirtualHosts: virtualHostMapToSlice(externalTLSHosts),
 		localVirtualHosts:       virtualHostMapToSlice(localHosts),
 		localTLSVirtualHosts:    virtualHostMapToSlice(localTLSHosts),
+		upstreamTrustBundle:     upstreamTrustBundle,
 	}, nil
 }
 
+// gatewayFleet returns the node ID of the gateways serving the given ingress, as
+// selected by its annotation or by the label of its namespace.
+func (translator *IngressTranslator) gatewayFleet(ingress *v1alpha1.Ingress, kourierConfig *config.Kourier) (string, error) {
+	fleet := ingress.Annotations[config.GatewayFleetKey]
+	if fleet == "" {
+		if err := trackNamespace(translator.tracker, ingress.Namespace, ingress); err != nil {
+			return "", err
+		}
+		namespace, err := translator.namespaceGetter(ingress.Namespace)
+		if err == nil {
+			fleet = namespace.GetLabels()[config.GatewayFleetKey]
+		} else if !apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to get namespace %s: %w", ingress.Namespace, err)
+		}
+	}
+
+	if fleet == "" || fleet == config.DefaultGatewayFleet {
+		return config.DefaultGatewayFleet, nil
+	}
+	if _, ok := kourierConfig.GatewayFleets[fleet]; !ok {
+		return "", fmt.Errorf("unknown gateway fleet %q", fleet)
+	}
+	return fleet, nil
+}
+
 // virtualHostMapToSlice converts a map of VirtualHosts to a sorted slice for deterministic output.
 func virtualHostMapToSlice(m map[string]*route.VirtualHost) []*route.VirtualHost {
 	// Sort by hostname for deterministic Envoy configuration
@@ -344,24 +425,50 @@ func virtualHostMapToSlice(m map[string]*route.VirtualHost) []*route.VirtualHost
 // Envoy uses first-match-wins, so longer prefix paths must precede shorter ones.
 // Without this sorting, a catch-all "/" route could match before "/.well-known/acme-challenge/..."
 // causing ACME HTTP-01 challenges to be misrouted to the application instead of the solver.
+// Exact paths come first, then regular expressions, then prefixes. Path separated
+// prefixes precede plain prefixes of the same length. Routes matching query
+// parameters precede the routes matching the same path only, which would shadow
+// them otherwise.
 func sortRoutesByPathSpecificity(routes []*route.Route) {
 	slices.SortStableFunc(routes, func(a, b *route.Route) int {
-		pathA := getRoutePrefix(a)
-		pathB := getRoutePrefix(b)
-		// Longer paths first (descending order)
-		return len(pathB) - len(pathA)
+		typeA, pathA := routePath(a)
+		typeB, pathB := routePath(b)
+		return cmp.Or(
+			cmp.Compare(pathMatchPrecedence[typeA]/4, pathMatchPrecedence[typeB]/4),
+			// Longer paths first (descending order)
+			cmp.Compare(len(pathB), len(pathA)),
+			cmp.Compare(pathMatchPrecedence[typeA], pathMatchPrecedence[typeB]),
+			// More query parameters first (descending order)
+			cmp.Compare(len(b.GetMatch().GetQueryParameters()), len(a.GetMatch().GetQueryParameters())),
+		)
 	})
 }
 
-// getRoutePrefix extracts the prefix path from a route.
-// Currently, all routes created by pkg/envoy/api use prefix matching (see TestRoutesUsePrefixMatching).
-func getRoutePrefix(r *route.Route) string {
-	if match := r.GetMatch(); match != nil {
-		if prefix, ok := match.GetPathSpecifier().(*route.RouteMatch_Prefix); ok {
-			return prefix.Prefix
-		}
+// pathMatchPrecedence orders the routes by how they match paths. Regexes and both
+// kinds of prefixes share the same precedence divided by four, so that they're
+// ordered by length across kinds and a catch-all regex like "/.*" doesn't shadow
+// longer prefixes like "/.well-known/acme-challenge/".
+var pathMatchPrecedence = map[envoy.PathMatchType]int{
+	envoy.PathMatchExact:               0,
+	envoy.PathMatchRegex:               4,
+	envoy.PathMatchPathSeparatedPrefix: 5,
+	envoy.PathMatchPrefix:              6,
+}
+
+// routePath extracts the path from a route, along with how it's matched. See
+// TestRoutesUsePrefixMatching for the paths routes created by pkg/envoy/api match.
+func routePath(r *route.Route) (envoy.PathMatchType, string) {
+	switch specifier := r.GetMatch().GetPathSpecifier().(type) {
+	case *route.RouteMatch_Path:
+		return envoy.PathMatchExact, specifier.Path
+	case *route.RouteMatch_SafeRegex:
+		return envoy.PathMatchRegex, specifier.SafeRegex.GetRegex()
+	case *route.RouteMatch_PathSeparatedPrefix:
+		return envoy.PathMatchPathSeparatedPrefix, specifier.PathSeparatedPrefix
+	case *route.RouteMatch_Prefix:
+		return envoy.PathMatchPrefix, specifier.Prefix
 	}
-	return ""
+	return envoy.PathMatchPrefix, ""
 }
 
 func (translator *IngressTranslator) translateIngressTLS(ingressTLS v1alpha1.IngressTLS, ingress *v1alpha1.Ingress) (*envoy.SNIMatch, error) {
@@ -397,12 +504,12 @@ func (translator *IngressTranslator) translateIngressTLS(ingressTLS v1alpha1.Ing
 	return sniMatch, nil
 }
 
-func (translator *IngressTranslator) createUpstreamTransportSocket(http2 bool, namespace string, trustChain []byte) (*envoycorev3.TransportSocket, error) {
+func (translator *IngressTranslator) createUpstreamTransportSocket(http2 bool, namespace string) (*envoycorev3.TransportSocket, error) {
 	var alpnProtocols string
 	if http2 {
 		alpnProtocols = "h2"
 	}
-	tlsAny, err := anypb.New(createUpstreamTLSContext(trustChain, namespace, alpnProtocols))
+	tlsAny, err := anypb.New(createUpstreamTLSContext(namespace, alpnProtocols))
 	if err != nil {
 		return nil, err
 	}
@@ -414,7 +521,9 @@ func (translator *IngressTranslator) createUpstreamTransportSocket(http2 bool, n
 	}, nil
 }
 
-func createUpstreamTLSContext(trustChain []byte, namespace string, alpnProtocols ...string) *tlsv3.UpstreamTlsContext {
+// createUpstreamTLSContext creates the TLS context to reach the upstreams in the given
+// namespace with. The CA bundle is fetched via SDS, see upstreamTrustBundleSecretName.
+func createUpstreamTLSContext(namespace string, alpnProtocols ...string) *tlsv3.UpstreamTlsContext {
 	return &tlsv3.UpstreamTlsContext{
 		CommonTlsContext: &tlsv3.CommonTlsContext{
 			AlpnProtocols: alpnProtocols,
@@ -422,30 +531,28 @@ func createUpstreamTLSContext(trustChain []byte, namespace string, alpnProtocols
 				TlsMinimumProtocolVersion: tlsv3.TlsParameters_TLSv1_3,
 				TlsMaximumProtocolVersion: tlsv3.TlsParameters_TLSv1_3,
 			},
-			ValidationContextType: &tlsv3.CommonTlsContext_ValidationContext{
-				ValidationContext: &tlsv3.CertificateValidationContext{
-					TrustedCa: &envoycorev3.DataSource{
-						Specifier: &envoycorev3.DataSource_InlineBytes{
-							InlineBytes: trustChain,
-						},
-					},
-					MatchTypedSubjectAltNames: []*tlsv3.SubjectAltNameMatcher{{
-						SanType: tlsv3.SubjectAltNameMatcher_DNS,
-						Matcher: &envoymatcherv3.StringMatcher{
-							MatchPattern: &envoymatcherv3.StringMatcher_Exact{
-								// SAN used by Activator
-								Exact: certificates.DataPlaneRoutingSAN,
+			ValidationContextType: &tlsv3.CommonTlsContext_CombinedValidationContext{
+				CombinedValidationContext: &tlsv3.CommonTlsContext_CombinedCertificateValidationContext{
+					DefaultValidationContext: &tlsv3.CertificateValidationContext{
+						MatchTypedSubjectAltNames: []*tlsv3.SubjectAltNameMatcher{{
+							SanType: tlsv3.SubjectAltNameMatcher_DNS,
+							Matcher: &envoymatcherv3.StringMatcher{
+								MatchPattern: &envoymatcherv3.StringMatcher_Exact{
+									// SAN used by Activator
+									Exact: certificates.DataPlaneRoutingSAN,
+								},
 							},
-						},
-					}, {
-						SanType: tlsv3.SubjectAltNameMatcher_DNS,
-						Matcher: &envoymatcherv3.StringMatcher{
-							MatchPattern: &envoymatcherv3.StringMatcher_Exact{
-								// SAN used by Queue-Proxy in target namespace
-								Exact: certificates.DataPlaneUserSAN(namespace),
+						}, {
+							SanType: tlsv3.SubjectAltNameMatcher_DNS,
+							Matcher: &envoymatcherv3.StringMatcher{
+								MatchPattern: &envoymatcherv3.StringMatcher_Exact{
+									// SAN used by Queue-Proxy in target namespace
+									Exact: certificates.DataPlaneUserSAN(namespace),
+								},
 							},
-						},
-					}},
+						}},
+					},
+					ValidationContextSdsSecretConfig: envoy.NewSdsSecretConfig(upstreamTrustBundleSecretName),
 				},
 			},
 		},
@@ -479,8 +586,12 @@ func (translator *IngressTranslator) buildTrustChain(logger *zap.SugaredLogger)
 	}
 
 	newline := []byte("\n")
+	// Concatenate the bundles in a stable order so identical config maps always
+	// generate identical trust chains.
+	slices.SortFunc(cms, func(a, b *corev1.ConfigMap) int { return cmp.Compare(a.Name, b.Name) })
 	for _, cm := range cms {
-		for _, bundle := range cm.Data {
+		for _, key := range slices.Sorted(maps.Keys(cm.Data)) {
+			bundle := cm.Data[key]
 			if err = checkCertBundle([]byte(bundle)); err != nil {
 				logger.Warnf("CA bundle from Configmap %s/%s is invalid and will be ignored: %v",
 					system.Namespace(), cm.Name, err)
@@ -507,6 +618,17 @@ func trackSecret(t tracker.Interface, ns, name string, ingress *v1alpha1.Ingress
 	}, ingress)
 }
 
+func trackNamespace(t tracker.Interface, name string, ingress *v1alpha1.Ingress) error {
+	if err := t.TrackReference(tracker.Reference{
+		Kind:       "Namespace",
+		APIVersion: "v1",
+		Name:       name,
+	}, ingress); err != nil {
+		return fmt.Errorf("could not track namespace reference: %w", err)
+	}
+	return nil
+}
+
 func trackService(t tracker.Interface, svcNs, svcName string, ingress *v1alpha1.Ingress) error {
 	if err := t.TrackReference(tracker.Reference{
 		Kind:       "Service",
@@ -532,21 +654,36 @@ func trackService(t tracker.Interface, svcNs, svcName string, ingress *v1alpha1.
 	return nil
 }
 
+// validateHeaderMatches returns an error if a header match in the spec of the given
+// path has no value. Envoy would match the mere presence of the header instead,
+// which the header match annotation expresses explicitly.
+func validateHeaderMatches(httpPath v1alpha1.HTTPIngressPath) error {
+	for _, name := range slices.Sorted(maps.Keys(httpPath.Headers)) {
+		if httpPath.Headers[name].Exact == "" {
+			return fmt.Errorf("header %q must have an exact value, use the annotation %s to match its presence",
+				name, config.HeaderMatchKey)
+		}
+	}
+	return nil
+}
+
+// matchHeadersFromHTTPPath returns the matchers of the headers in the spec of the
+// given path, which must have been validated with validateHeaderMatches.
 func matchHeadersFromHTTPPath(httpPath v1alpha1.HTTPIngressPath) []*route.HeaderMatcher {
 	matchHeaders := make([]*route.HeaderMatcher, 0, len(httpPath.Headers))
 
-	for header, matchType := range httpPath.Headers {
+	// Iterate in a stable order so identical paths always generate identical routes.
+	for _, name := range slices.Sorted(maps.Keys(httpPath.Headers)) {
+		matchType := httpPath.Headers[name]
 		matchHeader := &route.HeaderMatcher{
-			Name: header,
-		}
-		if matchType.Exact != "" {
-			matchHeader.HeaderMatchSpecifier = &route.HeaderMatcher_StringMatch{
+			Name: name,
+			HeaderMatchSpecifier: &route.HeaderMatcher_StringMatch{
 				StringMatch: &envoymatcherv3.StringMatcher{
 					MatchPattern: &envoymatcherv3.StringMatcher_Exact{
 						Exact: matchType.Exact,
 					},
 				},
-			}
+			},
 		}
 		matchHeaders = append(matchHeaders, matchHeader)
 	}
@@ -103,19 +103,21 @@ func TestIngressTranslator(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -177,19 +179,21 @@ func TestIngressTranslator(t *testing.T) {
 				}},
 				localSNIMatches: []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: vHosts,
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -263,19 +267,21 @@ func TestIngressTranslator(t *testing.T) {
 				}},
 				externalSNIMatches: []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+				},
 				externalVirtualHosts:    []*route.VirtualHost{},
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    vHosts,
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -359,19 +365,21 @@ func TestIngressTranslator(t *testing.T) {
 				}},
 				localSNIMatches: []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+				},
 				externalVirtualHosts:    vHostsRedirect,
 				externalTLSVirtualHosts: vHosts,
 				localVirtualHosts:       vHostsRedirect,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -436,19 +444,21 @@ func TestIngressTranslator(t *testing.T) {
 					PrivateKey:       privateKey,
 				}},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+				},
 				externalVirtualHosts:    []*route.VirtualHost{},
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    vHosts,
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -552,21 +562,17 @@ func TestIngressTranslator(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens2/servicename2",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 					envoy.NewCluster(
 						"servicens3/servicename3",
@@ -577,10 +583,15 @@ func TestIngressTranslator(t *testing.T) {
 						v3.Cluster_LOGICAL_DNS,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+					envoy.NewClusterLoadAssignment("servicens2/servicename2", lbEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -629,19 +640,21 @@ func TestIngressTranslator(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -703,6 +716,7 @@ func TestIngressTranslator(t *testing.T) {
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -765,6 +779,7 @@ func TestIngressTranslator(t *testing.T) {
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -885,19 +900,21 @@ func TestIngressTranslatorWithHTTPOptionDisabled(t *testing.T) {
 				}},
 				localSNIMatches: []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: vHosts,
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -962,19 +979,21 @@ func TestIngressTranslatorWithHTTPOptionDisabled(t *testing.T) {
 					PrivateKey:       privateKey,
 				}},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbEndpoints,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbEndpoints),
+				},
 				externalVirtualHosts:    []*route.VirtualHost{},
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    vHosts,
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}}
@@ -1053,22 +1072,25 @@ func TestIngressTranslatorWithUpstreamTLS(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbHTTPSEndpoints,
 						false,
 						&envoycorev3.TransportSocket{
 							Name:       wellknown.TransportSocketTls,
-							ConfigType: typedConfig(false, secretCert),
+							ConfigType: typedConfig(false),
 						},
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbHTTPSEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				upstreamTrustBundle:     envoy.NewValidationContextSecret(upstreamTrustBundleSecretName, secretCert),
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -1128,22 +1150,25 @@ func TestIngressTranslatorWithUpstreamTLS(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbHTTPSEndpoints,
 						true, /* http2 */
 						&envoycorev3.TransportSocket{
 							Name:       wellknown.TransportSocketTls,
-							ConfigType: typedConfig(true, secretCert),
+							ConfigType: typedConfig(true),
 						},
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbHTTPSEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				upstreamTrustBundle:     envoy.NewValidationContextSecret(upstreamTrustBundleSecretName, secretCert),
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -1204,22 +1229,25 @@ func TestIngressTranslatorWithUpstreamTLS(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbHTTPSEndpoints,
 						false, /* http2 */
 						&envoycorev3.TransportSocket{
 							Name:       wellknown.TransportSocketTls,
-							ConfigType: typedConfig(false, secretCert),
+							ConfigType: typedConfig(false),
 						},
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbHTTPSEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				upstreamTrustBundle:     envoy.NewValidationContextSecret(upstreamTrustBundleSecretName, secretCert),
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -1280,22 +1308,25 @@ func TestIngressTranslatorWithUpstreamTLS(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbHTTPSEndpoints,
 						true, /* http2 */
 						&envoycorev3.TransportSocket{
 							Name:       wellknown.TransportSocketTls,
-							ConfigType: typedConfig(true, secretCert),
+							ConfigType: typedConfig(true),
 						},
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbHTTPSEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				upstreamTrustBundle:     envoy.NewValidationContextSecret(upstreamTrustBundleSecretName, secretCert),
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -1347,22 +1378,25 @@ func TestIngressTranslatorWithUpstreamTLS(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbHTTPSEndpoints,
 						false,
 						&envoycorev3.TransportSocket{
 							Name:       wellknown.TransportSocketTls,
-							ConfigType: typedConfig(false, combineCerts(secretCert, configmapCert)),
+							ConfigType: typedConfig(false),
 						},
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbHTTPSEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				upstreamTrustBundle:     envoy.NewValidationContextSecret(upstreamTrustBundleSecretName, combineCerts(secretCert, configmapCert)),
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -1418,22 +1452,25 @@ func TestIngressTranslatorWithUpstreamTLS(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"servicens/servicename",
 						5*time.Second,
-						lbHTTPSEndpoints,
 						false,
 						&envoycorev3.TransportSocket{
 							Name:       wellknown.TransportSocketTls,
-							ConfigType: typedConfig(false, configmapCert),
+							ConfigType: typedConfig(false),
 						},
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("servicens/servicename", lbHTTPSEndpoints),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				upstreamTrustBundle:     envoy.NewValidationContextSecret(upstreamTrustBundleSecretName, configmapCert),
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}, {
@@ -1547,19 +1584,21 @@ func TestIngressTranslatorHTTP01Challenge(t *testing.T) {
 				externalSNIMatches: []*envoy.SNIMatch{},
 				localSNIMatches:    []*envoy.SNIMatch{},
 				clusters: []*v3.Cluster{
-					envoy.NewCluster(
+					envoy.NewEDSCluster(
 						"simplens/cm-acme-http-solver",
 						5*time.Second,
-						lbEndpointHTTP01Challenge,
 						false,
 						nil,
-						v3.Cluster_STATIC,
 					),
 				},
+				loadAssignments: []*endpoint.ClusterLoadAssignment{
+					envoy.NewClusterLoadAssignment("simplens/cm-acme-http-solver", lbEndpointHTTP01Challenge),
+				},
 				externalVirtualHosts:    vHosts,
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}
@@ -1662,6 +1701,8 @@ func TestIngressTranslatorDomainMappingDisableHTTP2(t *testing.T) {
 				externalTLSVirtualHosts: []*route.VirtualHost{},
 				localVirtualHosts:       vHosts,
 				localTLSVirtualHosts:    []*route.VirtualHost{},
+				upstreamTrustBundle:     envoy.NewValidationContextSecret(upstreamTrustBundleSecretName, secretCert),
+				fleet:                   config.DefaultGatewayFleet,
 			}
 		}(),
 	}
@@ -1683,6 +1724,75 @@ func TestIngressTranslatorDomainMappingDisableHTTP2(t *testing.T) {
 	})
 }
 
+func TestIngressTranslatorGatewayFleet(t *testing.T) {
+	namespace := func(fleet string) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "testspace",
+				Labels: map[string]string{config.GatewayFleetKey: fleet},
+			},
+		}
+	}
+	annotated := func(fleet string) func(*v1alpha1.Ingress) {
+		return func(ing *v1alpha1.Ingress) {
+			ing.Annotations = map[string]string{config.GatewayFleetKey: fleet}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		in      *v1alpha1.Ingress
+		state   []runtime.Object
+		want    string
+		wantErr bool
+	}{{
+		name: "default",
+		in:   ing("testspace", "testname"),
+		want: config.DefaultGatewayFleet,
+	}, {
+		name: "annotation",
+		in:   ing("testspace", "testname", annotated("tenant-gateway")),
+		want: "tenant-gateway",
+	}, {
+		name: "annotation selecting the default fleet",
+		in:   ing("testspace", "testname", annotated(config.DefaultGatewayFleet)),
+		want: config.DefaultGatewayFleet,
+	}, {
+		name:  "namespace label",
+		in:    ing("testspace", "testname"),
+		state: []runtime.Object{namespace("tenant-gateway")},
+		want:  "tenant-gateway",
+	}, {
+		name:  "annotation takes precedence over namespace label",
+		in:    ing("testspace", "testname", annotated(config.DefaultGatewayFleet)),
+		state: []runtime.Object{namespace("tenant-gateway")},
+		want:  config.DefaultGatewayFleet,
+	}, {
+		name:    "unknown fleet",
+		in:      ing("testspace", "testname", annotated("unknown-gateway")),
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := defaultConfig.DeepCopy()
+			cfg.Kourier.GatewayFleets = map[string]config.GatewayFleet{
+				"tenant-gateway": {ExternalService: "tenant", InternalService: "tenant-internal"},
+			}
+			ctx := (&testConfigStore{config: cfg}).ToContext(context.Background())
+
+			state := append(test.state, svc("servicens", "servicename"), eps("servicens", "servicename"))
+			translator := newTestIngressTranslator(ctx, fake.NewSimpleClientset(state...))
+
+			got, err := translator.translateIngress(ctx, test.in)
+			assert.Equal(t, err != nil, test.wantErr, "error: %v", err)
+			if !test.wantErr {
+				assert.Equal(t, got.fleet, test.want)
+			}
+		})
+	}
+}
+
 func ing(ns, name string, opts ...func(*v1alpha1.Ingress)) *v1alpha1.Ingress {
 	ingress := &v1alpha1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1889,6 +1999,9 @@ func newTestIngressTranslator(ctx context.Context, kubeclient *fake.Clientset) I
 		func(ns, name string) (*corev1.Service, error) {
 			return kubeclient.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
 		},
+		func(name string) (*corev1.Namespace, error) {
+			return kubeclient.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		},
 		&pkgtest.FakeTracker{},
 	)
 }
@@ -1983,7 +2096,7 @@ var (
 	}
 )
 
-func typedConfig(http2 bool, expectedCert []byte) *envoycorev3.TransportSocket_TypedConfig {
+func typedConfig(http2 bool) *envoycorev3.TransportSocket_TypedConfig {
 	alpn := []string{""}
 	if http2 {
 		alpn = []string{"h2"}
@@ -1995,30 +2108,28 @@ func typedConfig(http2 bool, expectedCert []byte) *envoycorev3.TransportSocket_T
 				TlsMinimumProtocolVersion: auth.TlsParameters_TLSv1_3,
 				TlsMaximumProtocolVersion: auth.TlsParameters_TLSv1_3,
 			},
-			ValidationContextType: &auth.CommonTlsContext_ValidationContext{
-				ValidationContext: &auth.CertificateValidationContext{
-					TrustedCa: &envoycorev3.DataSource{
-						Specifier: &envoycorev3.DataSource_InlineBytes{
-							InlineBytes: expectedCert,
-						},
-					},
-					MatchTypedSubjectAltNames: []*auth.SubjectAltNameMatcher{{
-						SanType: auth.SubjectAltNameMatcher_DNS,
-						Matcher: &envoymatcherv3.StringMatcher{
-							MatchPattern: &envoymatcherv3.StringMatcher_Exact{
-								// SAN of Activator
-								Exact: certificates.DataPlaneRoutingSAN,
+			ValidationContextType: &auth.CommonTlsContext_CombinedValidationContext{
+				CombinedValidationContext: &auth.CommonTlsContext_CombinedCertificateValidationContext{
+					DefaultValidationContext: &auth.CertificateValidationContext{
+						MatchTypedSubjectAltNames: []*auth.SubjectAltNameMatcher{{
+							SanType: auth.SubjectAltNameMatcher_DNS,
+							Matcher: &envoymatcherv3.StringMatcher{
+								MatchPattern: &envoymatcherv3.StringMatcher_Exact{
+									// SAN of Activator
+									Exact: certificates.DataPlaneRoutingSAN,
+								},
 							},
-						},
-					}, {
-						SanType: auth.SubjectAltNameMatcher_DNS,
-						Matcher: &envoymatcherv3.StringMatcher{
-							MatchPattern: &envoymatcherv3.StringMatcher_Exact{
-								// SAN of Queue-Proxy in target namespace
-								Exact: certificates.DataPlaneUserSAN("servicens"),
+						}, {
+							SanType: auth.SubjectAltNameMatcher_DNS,
+							Matcher: &envoymatcherv3.StringMatcher{
+								MatchPattern: &envoymatcherv3.StringMatcher_Exact{
+									// SAN of Queue-Proxy in target namespace
+									Exact: certificates.DataPlaneUserSAN("servicens"),
+								},
 							},
-						},
-					}},
+						}},
+					},
+					ValidationContextSdsSecretConfig: envoy.NewSdsSecretConfig(upstreamTrustBundleSecretName),
 				},
 			},
 		},
@@ -2143,6 +2254,9 @@ func TestTranslateIngress_EndpointsNotReady(t *testing.T) {
 		func(ns, name string) (*corev1.Service, error) {
 			return service, nil
 		},
+		func(name string) (*corev1.Namespace, error) {
+			return &corev1.Namespace{}, nil
+		},
 		&pkgtest.FakeTracker{})
 
 	// Translate the ingress
@@ -2231,6 +2345,9 @@ func TestTranslateIngressWithDuplicateDomains(t *testing.T) {
 		func(ns, name string) (*corev1.Service, error) {
 			return kubeclient.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
 		},
+		func(name string) (*corev1.Namespace, error) {
+			return &corev1.Namespace{}, nil
+		},
 		&pkgtest.FakeTracker{})
 
 	result, err := translator.translateIngress(ctx, ingress)
@@ -2323,6 +2440,63 @@ func TestRouteOrderingPreservesEqualLengthOrder(t *testing.T) {
 	}
 }
 
+// TestRouteOrderingByPathMatchType verifies that exact paths come first, then
+// regular expressions, then prefixes by length, path separated ones first.
+func TestRouteOrderingByPathMatchType(t *testing.T) {
+	routes := []*route.Route{
+		envoy.NewRoute("root-prefix", nil, "/", nil, 0, nil, ""),
+		envoy.NewRoute("api-prefix", nil, "/api", nil, 0, nil, ""),
+		envoy.NewRoute("api-separated", nil, "/api", nil, 0, nil, "", envoy.WithPathMatch(envoy.PathMatchPathSeparatedPrefix)),
+		envoy.NewRoute("regex", nil, "/v[0-9]+/.*", nil, 0, nil, "", envoy.WithPathMatch(envoy.PathMatchRegex)),
+		envoy.NewRoute("api-v1-prefix", nil, "/api/v1", nil, 0, nil, ""),
+		envoy.NewRoute("healthz-exact", nil, "/healthz", nil, 0, nil, "", envoy.WithPathMatch(envoy.PathMatchExact)),
+		envoy.NewRoute("root-exact", nil, "/", nil, 0, nil, "", envoy.WithPathMatch(envoy.PathMatchExact)),
+		envoy.NewRoute("catch-all-regex", nil, "/.*", nil, 0, nil, "", envoy.WithPathMatch(envoy.PathMatchRegex)),
+		envoy.NewRoute("api-regex", nil, "/a.*", nil, 0, nil, "", envoy.WithPathMatch(envoy.PathMatchRegex)),
+	}
+
+	sortRoutesByPathSpecificity(routes)
+
+	// Exact matches come first, regexes and prefixes are ordered by length, with
+	// regexes first on ties.
+	want := []string{"healthz-exact", "root-exact", "regex", "api-v1-prefix", "api-regex", "api-separated", "api-prefix", "catch-all-regex", "root-prefix"}
+	got := make([]string, 0, len(routes))
+	for _, r := range routes {
+		got = append(got, r.Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRouteOrderingByQueryParameters(t *testing.T) {
+	present := func(name string) *route.QueryParameterMatcher {
+		return &route.QueryParameterMatcher{
+			Name:                         name,
+			QueryParameterMatchSpecifier: &route.QueryParameterMatcher_PresentMatch{PresentMatch: true},
+		}
+	}
+	routes := []*route.Route{
+		envoy.NewRoute("root", nil, "/", nil, 0, nil, ""),
+		envoy.NewRoute("root-debug", nil, "/", nil, 0, nil, "", envoy.WithQueryParameters(present("debug"))),
+		envoy.NewRoute("api", nil, "/api", nil, 0, nil, ""),
+		envoy.NewRoute("root-debug-version", nil, "/", nil, 0, nil, "", envoy.WithQueryParameters(present("debug"), present("version"))),
+		envoy.NewRoute("api-debug", nil, "/api", nil, 0, nil, "", envoy.WithQueryParameters(present("debug"))),
+	}
+
+	sortRoutesByPathSpecificity(routes)
+
+	// Query parameters don't make shorter paths precede longer ones.
+	want := []string{"api-debug", "api", "root-debug-version", "root-debug", "root"}
+	got := make([]string, 0, len(routes))
+	for _, r := range routes {
+		got = append(got, r.Name)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 // TestTranslateIngressWithMultipleDomainsAndPaths verifies that when an Ingress has
 // multiple rules with different domains, each having multiple paths, domains are
 // correctly separated and routes are properly grouped.
@@ -2416,6 +2590,9 @@ func TestTranslateIngressWithMultipleDomainsAndPaths(t *testing.T) {
 		func(ns, name string) (*corev1.Service, error) {
 			return kubeclient.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
 		},
+		func(name string) (*corev1.Namespace, error) {
+			return &corev1.Namespace{}, nil
+		},
 		&pkgtest.FakeTracker{})
 
 	result, err := translator.translateIngress(ctx, ingress)
@@ -2532,6 +2709,9 @@ func TestTranslateIngressWithMixedVisibility(t *testing.T) {
 		func(ns, name string) (*corev1.Service, error) {
 			return kubeclient.CoreV1().Services(ns).Get(ctx, name, metav1.GetOptions{})
 		},
+		func(name string) (*corev1.Namespace, error) {
+			return &corev1.Namespace{}, nil
+		},
 		&pkgtest.FakeTracker{})
 
 	result, err := translator.translateIngress(ctx, ingress)
@@ -0,0 +1,307 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoymatcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"golang.org/x/net/http/httpguts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// routeOptions returns the options of the routes generated for the paths of the
+// ingress, keyed by path, as selected by the annotations of the ingress. Invalid
+// annotations are reported as ErrInvalidConfig, so that they show up in the
+// status of the ingress.
+func routeOptions(ingress *v1alpha1.Ingress) (map[string][]envoy.RouteOption, error) {
+	options := make(map[string][]envoy.RouteOption)
+
+	pathMatches, err := pathAnnotation[envoy.PathMatchType](ingress, config.PathMatchKey)
+	if err != nil {
+		return nil, err
+	}
+	for path, typ := range pathMatches {
+		if err := validatePathMatch(path, typ); err != nil {
+			return nil, fmt.Errorf("%w: annotation %s: %w", ErrInvalidConfig, config.PathMatchKey, err)
+		}
+		options[path] = append(options[path], envoy.WithPathMatch(typ))
+	}
+
+	headerMatches, err := pathAnnotation[map[string]headerMatch](ingress, config.HeaderMatchKey)
+	if err != nil {
+		return nil, err
+	}
+	for path, byHeader := range headerMatches {
+		matchers := make([]*route.HeaderMatcher, 0, len(byHeader))
+		for _, name := range slices.Sorted(maps.Keys(byHeader)) {
+			matcher, err := byHeader[name].headerMatcher(name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: annotation %s: path %q: %w", ErrInvalidConfig, config.HeaderMatchKey, path, err)
+			}
+			matchers = append(matchers, matcher)
+		}
+		options[path] = append(options[path], envoy.WithHeaderMatchers(matchers...))
+	}
+
+	queryMatches, err := pathAnnotation[map[string]queryMatch](ingress, config.QueryMatchKey)
+	if err != nil {
+		return nil, err
+	}
+	for path, byParameter := range queryMatches {
+		matchers := make([]*route.QueryParameterMatcher, 0, len(byParameter))
+		for _, name := range slices.Sorted(maps.Keys(byParameter)) {
+			matcher, err := byParameter[name].queryParameterMatcher(name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: annotation %s: path %q: %w", ErrInvalidConfig, config.QueryMatchKey, path, err)
+			}
+			matchers = append(matchers, matcher)
+		}
+		options[path] = append(options[path], envoy.WithQueryParameters(matchers...))
+	}
+
+	timeoutsByPath, err := pathAnnotation[pathTimeouts](ingress, config.PathTimeoutsKey)
+	if err != nil {
+		return nil, err
+	}
+	for path, t := range timeoutsByPath {
+		if (t.Request != nil && t.Request.Duration < 0) || (t.Idle != nil && t.Idle.Duration < 0) {
+			return nil, fmt.Errorf("%w: annotation %s: path %q: timeouts must not be negative", ErrInvalidConfig, config.PathTimeoutsKey, path)
+		}
+		if t.Request != nil {
+			options[path] = append(options[path], envoy.WithTimeout(t.Request.Duration))
+		}
+		if t.Idle != nil {
+			options[path] = append(options[path], envoy.WithIdleTimeout(t.Idle.Duration))
+		}
+	}
+
+	return options, nil
+}
+
+// validatePathMatch returns an error if the path can't be matched the given way.
+func validatePathMatch(path string, typ envoy.PathMatchType) error {
+	switch typ {
+	case envoy.PathMatchPrefix, envoy.PathMatchExact:
+	case envoy.PathMatchPathSeparatedPrefix:
+		if strings.ContainsAny(path, "?#") {
+			return fmt.Errorf("path %q of type %s must not contain '?' or '#'", path, typ)
+		}
+	case envoy.PathMatchRegex:
+		// Envoy and Go both implement RE2.
+		if _, err := regexp.Compile(path); err != nil {
+			return fmt.Errorf("path %q is not a valid regular expression: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unknown match type %q of path %q", typ, path)
+	}
+	return nil
+}
+
+// headerMatch is how a header is matched, as given in the header match annotation.
+// Exactly one of the fields but Invert and IgnoreCase must be set.
+type headerMatch struct {
+	Exact    *string `json:"exact,omitempty"`
+	Prefix   *string `json:"prefix,omitempty"`
+	Suffix   *string `json:"suffix,omitempty"`
+	Contains *string `json:"contains,omitempty"`
+	// Regex is an RE2 regular expression the whole value must match.
+	Regex *string `json:"regex,omitempty"`
+	// Present matches requests with the header if true, and without it if false.
+	Present *bool `json:"present,omitempty"`
+
+	// Invert matches the requests that would not match otherwise.
+	Invert bool `json:"invert,omitempty"`
+	// IgnoreCase matches exact values, prefixes, suffixes and contained values
+	// regardless of their case.
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+}
+
+// headerMatcher returns the Envoy matcher of the given header, or an error if the
+// match is invalid.
+func (m headerMatch) headerMatcher(name string) (*route.HeaderMatcher, error) {
+	if !validHeaderName(name) {
+		return nil, fmt.Errorf("invalid header name %q", name)
+	}
+	set := 0
+	for _, isSet := range []bool{m.Exact != nil, m.Prefix != nil, m.Suffix != nil, m.Contains != nil, m.Regex != nil, m.Present != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("header %q must have exactly one of exact, prefix, suffix, contains, regex or present", name)
+	}
+	if m.IgnoreCase && (m.Regex != nil || m.Present != nil) {
+		return nil, fmt.Errorf("ignoreCase of header %q only applies to exact, prefix, suffix and contains", name)
+	}
+	// Envoy rejects empty prefixes, suffixes and contained values, which would match
+	// any value anyway.
+	for _, value := range []*string{m.Prefix, m.Suffix, m.Contains, m.Regex} {
+		if value != nil && *value == "" {
+			return nil, fmt.Errorf("empty match of header %q", name)
+		}
+	}
+
+	matcher := &route.HeaderMatcher{Name: name, InvertMatch: m.Invert}
+	stringMatch := &envoymatcherv3.StringMatcher{IgnoreCase: m.IgnoreCase}
+	switch {
+	case m.Exact != nil:
+		stringMatch.MatchPattern = &envoymatcherv3.StringMatcher_Exact{Exact: *m.Exact}
+	case m.Prefix != nil:
+		stringMatch.MatchPattern = &envoymatcherv3.StringMatcher_Prefix{Prefix: *m.Prefix}
+	case m.Suffix != nil:
+		stringMatch.MatchPattern = &envoymatcherv3.StringMatcher_Suffix{Suffix: *m.Suffix}
+	case m.Contains != nil:
+		stringMatch.MatchPattern = &envoymatcherv3.StringMatcher_Contains{Contains: *m.Contains}
+	case m.Regex != nil:
+		// Envoy and Go both implement RE2.
+		if _, err := regexp.Compile(*m.Regex); err != nil {
+			return nil, fmt.Errorf("invalid regular expression of header %q: %w", name, err)
+		}
+		stringMatch.MatchPattern = &envoymatcherv3.StringMatcher_SafeRegex{SafeRegex: &envoymatcherv3.RegexMatcher{Regex: *m.Regex}}
+	case m.Present != nil:
+		matcher.HeaderMatchSpecifier = &route.HeaderMatcher_PresentMatch{PresentMatch: *m.Present}
+		return matcher, nil
+	}
+	matcher.HeaderMatchSpecifier = &route.HeaderMatcher_StringMatch{StringMatch: stringMatch}
+	return matcher, nil
+}
+
+// validHeaderName returns whether the given name is a valid header name, or a
+// pseudo-header like ":authority".
+func validHeaderName(name string) bool {
+	return httpguts.ValidHeaderFieldName(strings.TrimPrefix(name, ":"))
+}
+
+// queryMatch is how a query parameter is matched, as given in the query match
+// annotation. Exactly one of the fields but IgnoreCase must be set.
+type queryMatch struct {
+	Exact  *string `json:"exact,omitempty"`
+	Prefix *string `json:"prefix,omitempty"`
+	// Regex is an RE2 regular expression the whole value must match.
+	Regex *string `json:"regex,omitempty"`
+	// Present matches requests with the query parameter if true. Envoy can't match
+	// requests without it.
+	Present *bool `json:"present,omitempty"`
+
+	// IgnoreCase matches exact values and prefixes regardless of their case.
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+}
+
+// queryParameterMatcher returns the Envoy matcher of the given query parameter, or
+// an error if the match is invalid.
+func (m queryMatch) queryParameterMatcher(name string) (*route.QueryParameterMatcher, error) {
+	if name == "" {
+		return nil, errors.New("empty query parameter name")
+	}
+	set := 0
+	for _, isSet := range []bool{m.Exact != nil, m.Prefix != nil, m.Regex != nil, m.Present != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("query parameter %q must have exactly one of exact, prefix, regex or present", name)
+	}
+	if m.IgnoreCase && (m.Regex != nil || m.Present != nil) {
+		return nil, fmt.Errorf("ignoreCase of query parameter %q only applies to exact and prefix", name)
+	}
+	if (m.Prefix != nil && *m.Prefix == "") || (m.Regex != nil && *m.Regex == "") {
+		return nil, fmt.Errorf("empty match of query parameter %q", name)
+	}
+	if m.Present != nil && !*m.Present {
+		return nil, fmt.Errorf("query parameter %q can only be matched when present", name)
+	}
+
+	matcher := &route.QueryParameterMatcher{Name: name}
+	stringMatch := &envoymatcherv3.StringMatcher{IgnoreCase: m.IgnoreCase}
+	switch {
+	case m.Exact != nil:
+		stringMatch.MatchPattern = &envoymatcherv3.StringMatcher_Exact{Exact: *m.Exact}
+	case m.Prefix != nil:
+		stringMatch.MatchPattern = &envoymatcherv3.StringMatcher_Prefix{Prefix: *m.Prefix}
+	case m.Regex != nil:
+		// Envoy and Go both implement RE2.
+		if _, err := regexp.Compile(*m.Regex); err != nil {
+			return nil, fmt.Errorf("invalid regular expression of query parameter %q: %w", name, err)
+		}
+		stringMatch.MatchPattern = &envoymatcherv3.StringMatcher_SafeRegex{SafeRegex: &envoymatcherv3.RegexMatcher{Regex: *m.Regex}}
+	case m.Present != nil:
+		matcher.QueryParameterMatchSpecifier = &route.QueryParameterMatcher_PresentMatch{PresentMatch: true}
+		return matcher, nil
+	}
+	matcher.QueryParameterMatchSpecifier = &route.QueryParameterMatcher_StringMatch{StringMatch: stringMatch}
+	return matcher, nil
+}
+
+// pathTimeouts are the timeouts of a path, as given in the path timeouts
+// annotation. They override the timeouts of the ingress for the path.
+type pathTimeouts struct {
+	Request *metav1.Duration `json:"request,omitempty"`
+	Idle    *metav1.Duration `json:"idle,omitempty"`
+}
+
+// pathAnnotation parses the annotation of the ingress with the given key, a JSON
+// object keyed by the paths of the ingress. The path of paths without one is "/".
+func pathAnnotation[T any](ingress *v1alpha1.Ingress, key string) (map[string]T, error) {
+	value, ok := ingress.Annotations[key]
+	if !ok {
+		return nil, nil
+	}
+	var byPath map[string]T
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&byPath); err != nil {
+		return nil, fmt.Errorf("%w: invalid annotation %s: %w", ErrInvalidConfig, key, err)
+	}
+
+	paths := sets.New[string]()
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, httpPath := range rule.HTTP.Paths {
+			paths.Insert(ingressPath(httpPath))
+		}
+	}
+	for path := range byPath {
+		if !paths.Has(path) {
+			return nil, fmt.Errorf("%w: annotation %s: %q is not a path of the ingress", ErrInvalidConfig, key, path)
+		}
+	}
+	return byPath, nil
+}
+
+// ingressPath returns the path of the given ingress path, defaulted to "/".
+func ingressPath(httpPath v1alpha1.HTTPIngressPath) string {
+	if httpPath.Path == "" {
+		return "/"
+	}
+	return httpPath.Path
+}
@@ -0,0 +1,426 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoymatcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+	"k8s.io/client-go/kubernetes/fake"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/networking/pkg/http/header"
+	"knative.dev/networking/pkg/ingress"
+)
+
+// translateWithAnnotations translates an ingress with the paths "/test", "/api"
+// and "/" and the given annotations, and returns the routes of its external
+// virtual host keyed by path.
+func translateWithAnnotations(t *testing.T, annotations map[string]string) (map[string]*route.Route, error) {
+	t.Helper()
+
+	ctx := (&testConfigStore{config: defaultConfig}).ToContext(context.Background())
+	kubeclient := fake.NewSimpleClientset(svc("servicens", "servicename"), eps("servicens", "servicename"))
+	translator := newTestIngressTranslator(ctx, kubeclient)
+
+	ingress := ing("ns", "name", func(ing *v1alpha1.Ingress) {
+		ing.Annotations = annotations
+		paths := ing.Spec.Rules[0].HTTP.Paths
+		api, root := paths[0], paths[0]
+		api.Path, root.Path = "/api", ""
+		ing.Spec.Rules[0].HTTP.Paths = append(paths, api, root)
+	})
+	translated, err := translator.translateIngress(ctx, ingress)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make(map[string]*route.Route)
+	for _, r := range translated.externalVirtualHosts[0].GetRoutes() {
+		_, path := routePath(r)
+		routes[path] = r
+	}
+	return routes, nil
+}
+
+func TestRouteOptionsPathMatch(t *testing.T) {
+	routes, err := translateWithAnnotations(t, map[string]string{
+		config.PathMatchKey: `{"/test": "Exact", "/api": "PathSeparatedPrefix"}`,
+	})
+	assert.NilError(t, err)
+
+	typ, _ := routePath(routes["/test"])
+	assert.Equal(t, typ, envoy.PathMatchExact)
+	typ, _ = routePath(routes["/api"])
+	assert.Equal(t, typ, envoy.PathMatchPathSeparatedPrefix)
+	typ, _ = routePath(routes["/"])
+	assert.Equal(t, typ, envoy.PathMatchPrefix)
+}
+
+func TestRouteOptionsPathMatchRegex(t *testing.T) {
+	ctx := (&testConfigStore{config: defaultConfig}).ToContext(context.Background())
+	kubeclient := fake.NewSimpleClientset(svc("servicens", "servicename"), eps("servicens", "servicename"))
+	translator := newTestIngressTranslator(ctx, kubeclient)
+
+	ingress := ing("ns", "name", func(ing *v1alpha1.Ingress) {
+		ing.Annotations = map[string]string{config.PathMatchKey: `{"/v[0-9]+/.*": "Regex"}`}
+		ing.Spec.Rules[0].HTTP.Paths[0].Path = "/v[0-9]+/.*"
+	})
+	translated, err := translator.translateIngress(ctx, ingress)
+	assert.NilError(t, err)
+
+	typ, path := routePath(translated.externalVirtualHosts[0].GetRoutes()[0])
+	assert.Equal(t, typ, envoy.PathMatchRegex)
+	assert.Equal(t, path, "/v[0-9]+/.*")
+
+	ingress = ing("ns", "name", func(ing *v1alpha1.Ingress) {
+		ing.Annotations = map[string]string{config.PathMatchKey: `{"/v(": "Regex"}`}
+		ing.Spec.Rules[0].HTTP.Paths[0].Path = "/v("
+	})
+	_, err = translator.translateIngress(ctx, ingress)
+	assert.Assert(t, errors.Is(err, ErrInvalidConfig), "err = %v", err)
+}
+
+func TestRouteOptionsPathMatchRegexWithACMEChallenge(t *testing.T) {
+	ctx := (&testConfigStore{config: defaultConfig}).ToContext(context.Background())
+	kubeclient := fake.NewSimpleClientset(svc("servicens", "servicename"), eps("servicens", "servicename"))
+	translator := newTestIngressTranslator(ctx, kubeclient)
+
+	const challenge = "/.well-known/acme-challenge/token"
+	ingress := ing("ns", "name", func(ing *v1alpha1.Ingress) {
+		ing.Annotations = map[string]string{config.PathMatchKey: `{"/.*": "Regex"}`}
+		paths := ing.Spec.Rules[0].HTTP.Paths
+		paths[0].Path = "/.*"
+		acme := paths[0]
+		acme.Path = challenge
+		ing.Spec.Rules[0].HTTP.Paths = append(paths, acme)
+	})
+	translated, err := translator.translateIngress(ctx, ingress)
+	assert.NilError(t, err)
+
+	// The challenge is served although the regex matches it too.
+	var paths []string
+	for _, r := range translated.externalVirtualHosts[0].GetRoutes() {
+		_, path := routePath(r)
+		paths = append(paths, path)
+	}
+	assert.DeepEqual(t, paths, []string{challenge, "/.*"})
+}
+
+func TestSpecHeaderMatchWithoutValue(t *testing.T) {
+	ctx := (&testConfigStore{config: defaultConfig}).ToContext(context.Background())
+	kubeclient := fake.NewSimpleClientset(svc("servicens", "servicename"), eps("servicens", "servicename"))
+	translator := newTestIngressTranslator(ctx, kubeclient)
+
+	// Envoy would match the mere presence of the header.
+	ingress := ing("ns", "name", func(ing *v1alpha1.Ingress) {
+		ing.Spec.Rules[0].HTTP.Paths[0].Headers["x-user"] = v1alpha1.HeaderMatch{}
+	})
+	_, err := translator.translateIngress(ctx, ingress)
+	assert.Assert(t, errors.Is(err, ErrInvalidConfig), "err = %v", err)
+	assert.ErrorContains(t, err, `header "x-user" must have an exact value`)
+
+	// Every header of the spec is matched by its exact value.
+	ingress = ing("ns", "name", func(ing *v1alpha1.Ingress) {
+		ing.Spec.Rules[0].HTTP.Paths[0].Headers["x-user"] = v1alpha1.HeaderMatch{Exact: "alice"}
+	})
+	translated, err := translator.translateIngress(ctx, ingress)
+	assert.NilError(t, err)
+	for _, matcher := range translated.externalVirtualHosts[0].GetRoutes()[0].GetMatch().GetHeaders() {
+		assert.Assert(t, matcher.GetStringMatch().GetExact() != "", "header %q", matcher.GetName())
+	}
+}
+
+func TestRouteOptionsHeaderMatch(t *testing.T) {
+	routes, err := translateWithAnnotations(t, map[string]string{
+		config.HeaderMatchKey: `{
+			"/test": {"testheader": {"prefix": "f", "ignoreCase": true}, "x-debug": {"present": false}},
+			"/": {"user-agent": {"regex": ".*Mobile.*", "invert": true}}
+		}`,
+	})
+	assert.NilError(t, err)
+
+	// The match of the spec is replaced.
+	assert.DeepEqual(t, routes["/test"].GetMatch().GetHeaders(), []*route.HeaderMatcher{{
+		Name: "testheader",
+		HeaderMatchSpecifier: &route.HeaderMatcher_StringMatch{StringMatch: &envoymatcherv3.StringMatcher{
+			MatchPattern: &envoymatcherv3.StringMatcher_Prefix{Prefix: "f"},
+			IgnoreCase:   true,
+		}},
+	}, {
+		Name:                 "x-debug",
+		HeaderMatchSpecifier: &route.HeaderMatcher_PresentMatch{PresentMatch: false},
+	}}, protocmp.Transform())
+
+	// The match of the spec is kept.
+	assert.DeepEqual(t, routes["/"].GetMatch().GetHeaders(), []*route.HeaderMatcher{{
+		Name: "testheader",
+		HeaderMatchSpecifier: &route.HeaderMatcher_StringMatch{StringMatch: &envoymatcherv3.StringMatcher{
+			MatchPattern: &envoymatcherv3.StringMatcher_Exact{Exact: "foo"},
+		}},
+	}, {
+		Name:        "user-agent",
+		InvertMatch: true,
+		HeaderMatchSpecifier: &route.HeaderMatcher_StringMatch{StringMatch: &envoymatcherv3.StringMatcher{
+			MatchPattern: &envoymatcherv3.StringMatcher_SafeRegex{SafeRegex: &envoymatcherv3.RegexMatcher{Regex: ".*Mobile.*"}},
+		}},
+	}}, protocmp.Transform())
+
+	assert.Equal(t, len(routes["/api"].GetMatch().GetHeaders()), 1)
+}
+
+func TestRouteOptionsQueryMatch(t *testing.T) {
+	routes, err := translateWithAnnotations(t, map[string]string{
+		config.QueryMatchKey: `{
+			"/test": {"version": {"exact": "beta", "ignoreCase": true}, "debug": {"present": true}},
+			"/": {"session": {"regex": "[0-9a-f]+"}, "user": {"prefix": "qa-"}}
+		}`,
+	})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, routes["/test"].GetMatch().GetQueryParameters(), []*route.QueryParameterMatcher{{
+		Name:                         "debug",
+		QueryParameterMatchSpecifier: &route.QueryParameterMatcher_PresentMatch{PresentMatch: true},
+	}, {
+		Name: "version",
+		QueryParameterMatchSpecifier: &route.QueryParameterMatcher_StringMatch{StringMatch: &envoymatcherv3.StringMatcher{
+			MatchPattern: &envoymatcherv3.StringMatcher_Exact{Exact: "beta"},
+			IgnoreCase:   true,
+		}},
+	}}, protocmp.Transform())
+
+	assert.DeepEqual(t, routes["/"].GetMatch().GetQueryParameters(), []*route.QueryParameterMatcher{{
+		Name: "session",
+		QueryParameterMatchSpecifier: &route.QueryParameterMatcher_StringMatch{StringMatch: &envoymatcherv3.StringMatcher{
+			MatchPattern: &envoymatcherv3.StringMatcher_SafeRegex{SafeRegex: &envoymatcherv3.RegexMatcher{Regex: "[0-9a-f]+"}},
+		}},
+	}, {
+		Name: "user",
+		QueryParameterMatchSpecifier: &route.QueryParameterMatcher_StringMatch{StringMatch: &envoymatcherv3.StringMatcher{
+			MatchPattern: &envoymatcherv3.StringMatcher_Prefix{Prefix: "qa-"},
+		}},
+	}}, protocmp.Transform())
+
+	assert.Equal(t, len(routes["/api"].GetMatch().GetQueryParameters()), 0)
+}
+
+func TestRouteOptionsProbes(t *testing.T) {
+	ctx := (&testConfigStore{config: defaultConfig}).ToContext(context.Background())
+	kubeclient := fake.NewSimpleClientset(svc("servicens", "servicename"), eps("servicens", "servicename"))
+	translator := newTestIngressTranslator(ctx, kubeclient)
+
+	ing := ing("ns", "name", func(ing *v1alpha1.Ingress) {
+		ing.Annotations = map[string]string{
+			config.PathMatchKey:   `{"/test": "Exact"}`,
+			config.HeaderMatchKey: `{"/test": {"x-user": {"present": true}}}`,
+			config.QueryMatchKey:  `{"/test": {"debug": {"present": true}}}`,
+		}
+	})
+	_, err := ingress.InsertProbe(ing)
+	assert.NilError(t, err)
+	translated, err := translator.translateIngress(ctx, ing)
+	assert.NilError(t, err)
+
+	routes := translated.externalVirtualHosts[0].GetRoutes()
+	assert.Equal(t, len(routes), 2)
+	for _, r := range routes {
+		typ, _ := routePath(r)
+		probe := slices.ContainsFunc(r.GetMatch().GetHeaders(), func(m *route.HeaderMatcher) bool {
+			return m.GetName() == header.HashKey
+		})
+		if probe {
+			assert.Equal(t, typ, envoy.PathMatchPrefix)
+			assert.Equal(t, len(r.GetMatch().GetHeaders()), 2)
+			assert.Equal(t, len(r.GetMatch().GetQueryParameters()), 0)
+		} else {
+			assert.Equal(t, typ, envoy.PathMatchExact)
+			assert.Equal(t, len(r.GetMatch().GetHeaders()), 2)
+			assert.Equal(t, len(r.GetMatch().GetQueryParameters()), 1)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	cfg := defaultConfig.DeepCopy()
+	cfg.Kourier.RetryPolicy = config.RetryPolicy{RetryOn: []string{"5xx"}, NumRetries: 2}
+	ctx := (&testConfigStore{config: cfg}).ToContext(context.Background())
+	kubeclient := fake.NewSimpleClientset(svc("servicens", "servicename"), eps("servicens", "servicename"))
+	translator := newTestIngressTranslator(ctx, kubeclient)
+
+	translated, err := translator.translateIngress(ctx, ing("ns", "name"))
+	assert.NilError(t, err)
+	retryPolicy := translated.externalVirtualHosts[0].GetRoutes()[0].GetRoute().GetRetryPolicy()
+	assert.Equal(t, retryPolicy.GetRetryOn(), "5xx")
+	assert.Equal(t, retryPolicy.GetNumRetries().GetValue(), uint32(2))
+
+	// The annotations override the fields of the default they set.
+	translated, err = translator.translateIngress(ctx, ing("ns", "name", withAnnotations(map[string]string{
+		config.RetryOnKey:              "connect-failure,retriable-status-codes",
+		config.RetriableStatusCodesKey: "503",
+	})))
+	assert.NilError(t, err)
+	retryPolicy = translated.externalVirtualHosts[0].GetRoutes()[0].GetRoute().GetRetryPolicy()
+	assert.Equal(t, retryPolicy.GetRetryOn(), "connect-failure,retriable-status-codes")
+	assert.Equal(t, retryPolicy.GetNumRetries().GetValue(), uint32(2))
+	assert.DeepEqual(t, retryPolicy.GetRetriableStatusCodes(), []uint32{503})
+
+	// An empty condition list disables retries.
+	translated, err = translator.translateIngress(ctx, ing("ns", "name", withAnnotations(map[string]string{
+		config.RetryOnKey: "",
+	})))
+	assert.NilError(t, err)
+	assert.Assert(t, translated.externalVirtualHosts[0].GetRoutes()[0].GetRoute().GetRetryPolicy() == nil)
+
+	_, err = translator.translateIngress(ctx, ing("ns", "name", withAnnotations(map[string]string{
+		config.NumRetriesKey: "many",
+	})))
+	assert.Assert(t, errors.Is(err, ErrInvalidConfig), "err = %v", err)
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	cfg := defaultConfig.DeepCopy()
+	cfg.Kourier.RouteTimeouts = config.RouteTimeouts{Request: time.Minute}
+	ctx := (&testConfigStore{config: cfg}).ToContext(context.Background())
+	kubeclient := fake.NewSimpleClientset(svc("servicens", "servicename"), eps("servicens", "servicename"))
+	translator := newTestIngressTranslator(ctx, kubeclient)
+
+	translated, err := translator.translateIngress(ctx, ing("ns", "name"))
+	assert.NilError(t, err)
+	action := translated.externalVirtualHosts[0].GetRoutes()[0].GetRoute()
+	assert.Equal(t, action.GetTimeout().AsDuration(), time.Minute)
+	assert.Assert(t, action.GetIdleTimeout() == nil)
+
+	// The annotations of the ingress override the defaults, and the ones of the
+	// paths override the ones of the ingress.
+	ingress := ing("ns", "name", func(ing *v1alpha1.Ingress) {
+		ing.Annotations = map[string]string{
+			config.RequestTimeoutKey:     "30s",
+			config.RequestIdleTimeoutKey: "5m",
+			config.PathTimeoutsKey:       `{"/api": {"request": "0s", "idle": "1h"}}`,
+		}
+		api := ing.Spec.Rules[0].HTTP.Paths[0]
+		api.Path = "/api"
+		ing.Spec.Rules[0].HTTP.Paths = append(ing.Spec.Rules[0].HTTP.Paths, api)
+	})
+	translated, err = translator.translateIngress(ctx, ingress)
+	assert.NilError(t, err)
+	for _, r := range translated.externalVirtualHosts[0].GetRoutes() {
+		_, path := routePath(r)
+		switch path {
+		case "/api":
+			assert.Equal(t, r.GetRoute().GetTimeout().AsDuration(), time.Duration(0))
+			assert.Equal(t, r.GetRoute().GetIdleTimeout().AsDuration(), time.Hour)
+		default:
+			assert.Equal(t, r.GetRoute().GetTimeout().AsDuration(), 30*time.Second)
+			assert.Equal(t, r.GetRoute().GetIdleTimeout().AsDuration(), 5*time.Minute)
+		}
+	}
+
+	for _, annotations := range []map[string]string{
+		{config.RequestTimeoutKey: "soon"},
+		{config.PathTimeoutsKey: `{"/test": {"request": "-1s"}}`},
+		{config.PathTimeoutsKey: `{"/test": {"idle": "later"}}`},
+	} {
+		_, err = translator.translateIngress(ctx, ing("ns", "name", withAnnotations(annotations)))
+		assert.Assert(t, errors.Is(err, ErrInvalidConfig), "annotations = %v, err = %v", annotations, err)
+	}
+}
+
+func withAnnotations(annotations map[string]string) func(*v1alpha1.Ingress) {
+	return func(ing *v1alpha1.Ingress) {
+		ing.Annotations = annotations
+	}
+}
+
+func TestRouteOptionsInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		annotation string
+	}{{
+		name:       "not JSON",
+		key:        config.PathMatchKey,
+		annotation: "Exact",
+	}, {
+		name:       "unknown path",
+		key:        config.PathMatchKey,
+		annotation: `{"/other": "Exact"}`,
+	}, {
+		name:       "unknown path match type",
+		key:        config.PathMatchKey,
+		annotation: `{"/test": "Glob"}`,
+	}, {
+		name:       "unknown header match field",
+		key:        config.HeaderMatchKey,
+		annotation: `{"/test": {"x-user": {"prefx": "beta-"}}}`,
+	}, {
+		name:       "several header matches",
+		key:        config.HeaderMatchKey,
+		annotation: `{"/test": {"x-user": {"prefix": "beta-", "suffix": "-test"}}}`,
+	}, {
+		name:       "no header match",
+		key:        config.HeaderMatchKey,
+		annotation: `{"/test": {"x-user": {"invert": true}}}`,
+	}, {
+		name:       "empty header prefix",
+		key:        config.HeaderMatchKey,
+		annotation: `{"/test": {"x-user": {"prefix": ""}}}`,
+	}, {
+		name:       "invalid header regex",
+		key:        config.HeaderMatchKey,
+		annotation: `{"/test": {"x-user": {"regex": "beta-("}}}`,
+	}, {
+		name:       "ignore case of presence",
+		key:        config.HeaderMatchKey,
+		annotation: `{"/test": {"x-user": {"present": true, "ignoreCase": true}}}`,
+	}, {
+		name:       "invalid header name",
+		key:        config.HeaderMatchKey,
+		annotation: `{"/test": {"x user": {"present": true}}}`,
+	}, {
+		name:       "several query parameter matches",
+		key:        config.QueryMatchKey,
+		annotation: `{"/test": {"version": {"exact": "beta", "prefix": "b"}}}`,
+	}, {
+		name:       "query parameter absence",
+		key:        config.QueryMatchKey,
+		annotation: `{"/test": {"debug": {"present": false}}}`,
+	}, {
+		name:       "invalid query parameter regex",
+		key:        config.QueryMatchKey,
+		annotation: `{"/test": {"version": {"regex": "beta-("}}}`,
+	}, {
+		name:       "empty query parameter name",
+		key:        config.QueryMatchKey,
+		annotation: `{"/test": {"": {"present": true}}}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := translateWithAnnotations(t, map[string]string{test.key: test.annotation})
+			assert.Assert(t, errors.Is(err, ErrInvalidConfig), "err = %v", err)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	cache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"k8s.io/apimachinery/pkg/types"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+)
+
+// routeTable keeps the virtual hosts of one visibility (e.g. external TLS) per
+// ingress. Changing an ingress only patches its own entry and its span of the
+// concatenated virtual hosts, and the route configurations assembled from the
+// table are reused until an entry changes.
+type routeTable struct {
+	// keys holds the ingresses with virtual hosts in the table, sorted, and entries
+	// holds their virtual hosts in the same order.
+	keys    []types.NamespacedName
+	entries []routeTableEntry
+
+	// virtualHosts holds the virtual hosts of all entries, in the order of keys.
+	virtualHosts []*route.VirtualHost
+
+	// assembled holds the route configurations built from the current entries,
+	// keyed by their name.
+	assembled map[string]assembledRouteConfig
+}
+
+type routeTableEntry struct {
+	virtualHosts []*route.VirtualHost
+	// hash is the hash of the virtual hosts, computed once when they're set.
+	hash []byte
+}
+
+type assembledRouteConfig struct {
+	routeConfig *route.RouteConfiguration
+	version     string
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{
+		assembled: make(map[string]assembledRouteConfig),
+	}
+}
+
+// len returns the number of ingresses with virtual hosts in the table.
+func (t *routeTable) len() int {
+	return len(t.keys)
+}
+
+// set replaces the virtual hosts of the given ingress.
+func (t *routeTable) set(key types.NamespacedName, virtualHosts []*route.VirtualHost) error {
+	if len(virtualHosts) == 0 {
+		t.delete(key)
+		return nil
+	}
+
+	hash, err := hashVirtualHosts(virtualHosts)
+	if err != nil {
+		return err
+	}
+	entry := routeTableEntry{virtualHosts: virtualHosts, hash: hash}
+
+	i, found := slices.BinarySearchFunc(t.keys, key, compareNamespacedNames)
+	offset := t.offset(i)
+	if !found {
+		t.keys = slices.Insert(t.keys, i, key)
+		t.entries = slices.Insert(t.entries, i, entry)
+		t.virtualHosts = slices.Insert(t.virtualHosts, offset, virtualHosts...)
+		clear(t.assembled)
+		return nil
+	}
+
+	previous := t.entries[i]
+	if bytes.Equal(previous.hash, hash) {
+		// Identical virtual hosts, the assembled route configurations stay valid.
+		return nil
+	}
+	t.entries[i] = entry
+	t.virtualHosts = slices.Replace(t.virtualHosts, offset, offset+len(previous.virtualHosts), virtualHosts...)
+	clear(t.assembled)
+	return nil
+}
+
+// delete removes the virtual hosts of the given ingress.
+func (t *routeTable) delete(key types.NamespacedName) {
+	i, found := slices.BinarySearchFunc(t.keys, key, compareNamespacedNames)
+	if !found {
+		return
+	}
+	offset := t.offset(i)
+	t.virtualHosts = slices.Delete(t.virtualHosts, offset, offset+len(t.entries[i].virtualHosts))
+	t.keys = slices.Delete(t.keys, i, i+1)
+	t.entries = slices.Delete(t.entries, i, i+1)
+	clear(t.assembled)
+}
+
+// offset returns the position of the virtual hosts of the i-th entry among the
+// virtual hosts of all entries.
+func (t *routeTable) offset(i int) int {
+	offset := 0
+	for _, entry := range t.entries[:i] {
+		offset += len(entry.virtualHosts)
+	}
+	return offset
+}
+
+// routeConfig returns the route configuration with the given name, containing the
+// virtual hosts of all ingresses followed by the given extra virtual hosts, along
+// with its version. The extra virtual hosts must be the same on every call for the
+// same name, as the result is reused until the table changes.
+func (t *routeTable) routeConfig(name string, extraVirtualHosts ...*route.VirtualHost) (*route.RouteConfiguration, string, error) {
+	if assembled, ok := t.assembled[name]; ok {
+		return assembled.routeConfig, assembled.version, nil
+	}
+
+	hasher := sha256.New()
+	hasher.Write([]byte(name))
+	for _, entry := range t.entries {
+		hasher.Write(entry.hash)
+	}
+	extraHash, err := hashVirtualHosts(extraVirtualHosts)
+	if err != nil {
+		return nil, "", err
+	}
+	hasher.Write(extraHash)
+
+	// Route configurations of earlier snapshots must not change, so the virtual
+	// hosts are copied rather than shared with the table.
+	virtualHosts := make([]*route.VirtualHost, 0, len(t.virtualHosts)+len(extraVirtualHosts))
+	virtualHosts = append(virtualHosts, t.virtualHosts...)
+	virtualHosts = append(virtualHosts, extraVirtualHosts...)
+
+	assembled := assembledRouteConfig{
+		routeConfig: envoy.NewRouteConfig(name, virtualHosts),
+		version:     hex.EncodeToString(hasher.Sum(nil)),
+	}
+	t.assembled[name] = assembled
+	return assembled.routeConfig, assembled.version, nil
+}
+
+func hashVirtualHosts(virtualHosts []*route.VirtualHost) ([]byte, error) {
+	hasher := sha256.New()
+	for _, virtualHost := range virtualHosts {
+		marshaled, err := cache.MarshalResource(virtualHost)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(marshaled)
+		hasher.Write(sum[:])
+	}
+	return hasher.Sum(nil), nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"testing"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRouteTable(t *testing.T) {
+	table := newRouteTable()
+	foo := types.NamespacedName{Namespace: "ns", Name: "foo"}
+	bar := types.NamespacedName{Namespace: "ns", Name: "bar"}
+	status := &route.VirtualHost{Name: "status"}
+
+	assert.NilError(t, table.set(foo, []*route.VirtualHost{{Name: "foo"}}))
+	assert.NilError(t, table.set(bar, []*route.VirtualHost{{Name: "bar"}}))
+
+	first, firstVersion, err := table.routeConfig("config", status)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, virtualHostNames(first), []string{"bar", "foo", "status"})
+
+	// Nothing changed, so the assembled route configuration is reused.
+	again, againVersion, err := table.routeConfig("config", status)
+	assert.NilError(t, err)
+	assert.Assert(t, again == first)
+	assert.Equal(t, againVersion, firstVersion)
+
+	// Setting identical virtual hosts again keeps the assembled route configuration.
+	assert.NilError(t, table.set(foo, []*route.VirtualHost{{Name: "foo"}}))
+	unchanged, unchangedVersion, err := table.routeConfig("config", status)
+	assert.NilError(t, err)
+	assert.Assert(t, unchanged == first)
+	assert.Equal(t, unchangedVersion, firstVersion)
+
+	assert.NilError(t, table.set(foo, []*route.VirtualHost{{Name: "foo", Domains: []string{"foo.example.com"}}}))
+	_, changedVersion, err := table.routeConfig("config", status)
+	assert.NilError(t, err)
+	assert.Assert(t, changedVersion != firstVersion)
+
+	// Setting no virtual hosts removes the entry.
+	assert.NilError(t, table.set(bar, nil))
+	table.delete(foo)
+	empty, _, err := table.routeConfig("config", status)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, virtualHostNames(empty), []string{"status"})
+}
+
+func TestRouteTablePatchesEntries(t *testing.T) {
+	table := newRouteTable()
+	key := func(name string) types.NamespacedName {
+		return types.NamespacedName{Namespace: "ns", Name: name}
+	}
+	vhosts := func(names ...string) []*route.VirtualHost {
+		virtualHosts := make([]*route.VirtualHost, 0, len(names))
+		for _, name := range names {
+			virtualHosts = append(virtualHosts, &route.VirtualHost{Name: name})
+		}
+		return virtualHosts
+	}
+	names := func() []string {
+		routeConfig, _, err := table.routeConfig("config")
+		assert.NilError(t, err)
+		return virtualHostNames(routeConfig)
+	}
+
+	assert.NilError(t, table.set(key("c"), vhosts("c1")))
+	assert.NilError(t, table.set(key("a"), vhosts("a1", "a2")))
+	assert.NilError(t, table.set(key("b"), vhosts("b1")))
+	assert.DeepEqual(t, names(), []string{"a1", "a2", "b1", "c1"})
+
+	// Entries grow and shrink in place.
+	assert.NilError(t, table.set(key("b"), vhosts("b1", "b2", "b3")))
+	assert.DeepEqual(t, names(), []string{"a1", "a2", "b1", "b2", "b3", "c1"})
+	assert.NilError(t, table.set(key("a"), vhosts("a3")))
+	assert.DeepEqual(t, names(), []string{"a3", "b1", "b2", "b3", "c1"})
+
+	table.delete(key("b"))
+	assert.DeepEqual(t, names(), []string{"a3", "c1"})
+	assert.Equal(t, table.len(), 2)
+
+	// The same state always results in the same version, however it was reached.
+	_, patchedVersion, err := table.routeConfig("config")
+	assert.NilError(t, err)
+	fresh := newRouteTable()
+	assert.NilError(t, fresh.set(key("a"), vhosts("a3")))
+	assert.NilError(t, fresh.set(key("c"), vhosts("c1")))
+	_, freshVersion, err := fresh.routeConfig("config")
+	assert.NilError(t, err)
+	assert.Equal(t, patchedVersion, freshVersion)
+}
+
+func virtualHostNames(routeConfig *route.RouteConfiguration) []string {
+	names := make([]string, 0, len(routeConfig.VirtualHosts))
+	for _, virtualHost := range routeConfig.VirtualHosts {
+		names = append(names, virtualHost.Name)
+	}
+	return names
+}
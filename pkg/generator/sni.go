@@ -17,6 +17,10 @@ limitations under the License.
 package generator
 
 import (
+	"maps"
+	"slices"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	envoy "knative.dev/net-kourier/pkg/envoy/api"
@@ -39,8 +43,11 @@ type sniMatches map[types.NamespacedName]*dedupedSNIMatch
 func (s sniMatches) consume(match *envoy.SNIMatch) {
 	state := s[match.CertSource]
 	if state == nil {
+		// Copy the match, as hosts of other matches are appended to it below.
+		copied := *match
+		copied.Hosts = slices.Clone(match.Hosts)
 		state = &dedupedSNIMatch{
-			sniMatch: match,
+			sniMatch: &copied,
 			hosts:    sets.New[string](match.Hosts...),
 		}
 		s[match.CertSource] = state
@@ -61,9 +68,17 @@ func (s sniMatches) list() []*envoy.SNIMatch {
 		return nil
 	}
 
+	// Sort by certificate source for deterministic Envoy configuration
 	matches := make([]*envoy.SNIMatch, 0, len(s))
-	for _, match := range s {
-		matches = append(matches, match.sniMatch)
+	for _, source := range slices.SortedFunc(maps.Keys(s), compareNamespacedNames) {
+		matches = append(matches, s[source].sniMatch)
 	}
 	return matches
 }
+
+func compareNamespacedNames(a, b types.NamespacedName) int {
+	if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Name, b.Name)
+}
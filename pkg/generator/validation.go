@@ -0,0 +1,265 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/types"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+)
+
+// ErrInvalidConfig is an error produced when the config generated for an ingress
+// would be rejected by the gateways.
+var ErrInvalidConfig = errors.New("ingress generates invalid gateway config")
+
+// bootstrapClusters are the clusters defined statically in the bootstrap config of
+// the gateways, which routes may reference without them being in a snapshot.
+var bootstrapClusters = []string{ServiceStatsClusterName}
+
+// snapshotProblem is an inconsistency found in a snapshot before publishing it.
+type snapshotProblem struct {
+	// ingress is the ingress the problem is attributed to. It is empty if the
+	// problem can't be attributed to a single ingress.
+	ingress types.NamespacedName
+	err     error
+}
+
+// resourceOwners maps the resources of a snapshot back to the ingresses they were
+// generated for. The maps are only built once a problem is attributed, as the
+// snapshots are valid most of the time.
+type resourceOwners struct {
+	ingresses []*translatedIngress
+
+	virtualHosts map[string]types.NamespacedName
+	// serverNames holds the ingresses serving a TLS server name, in the order
+	// their filter chains were generated in.
+	serverNames map[string][]types.NamespacedName
+}
+
+// newResourceOwners returns the owners of the resources generated for the given
+// ingresses, in the order they were generated in.
+func newResourceOwners(ingresses []*translatedIngress) *resourceOwners {
+	return &resourceOwners{ingresses: ingresses}
+}
+
+// virtualHost returns the ingress the given virtual host was generated for.
+func (o *resourceOwners) virtualHost(name string) types.NamespacedName {
+	o.build()
+	return o.virtualHosts[name]
+}
+
+// serverName returns the ingresses serving the given TLS server name.
+func (o *resourceOwners) serverName(name string) []types.NamespacedName {
+	o.build()
+	return o.serverNames[name]
+}
+
+func (o *resourceOwners) build() {
+	if o.virtualHosts != nil {
+		return
+	}
+	o.virtualHosts = make(map[string]types.NamespacedName)
+	o.serverNames = make(map[string][]types.NamespacedName)
+	for _, translated := range o.ingresses {
+		for _, virtualHosts := range [][]*route.VirtualHost{
+			translated.externalVirtualHosts, translated.externalTLSVirtualHosts,
+			translated.localVirtualHosts, translated.localTLSVirtualHosts,
+		} {
+			for _, virtualHost := range virtualHosts {
+				o.virtualHosts[virtualHost.GetName()] = translated.name
+			}
+		}
+		for _, match := range slices.Concat(translated.externalSNIMatches, translated.localSNIMatches) {
+			for _, host := range match.Hosts {
+				if !slices.Contains(o.serverNames[host], translated.name) {
+					o.serverNames[host] = append(o.serverNames[host], translated.name)
+				}
+			}
+		}
+	}
+}
+
+// validateSnapshot checks the given snapshot for what would make the gateways
+// reject it: references to missing resources, domains served by more than one
+// virtual host of a route config, filter chains of a listener with identical
+// matches and listeners without any filter chain.
+func validateSnapshot(snapshot *cache.Snapshot, owners *resourceOwners) []snapshotProblem {
+	var problems []snapshotProblem
+	if err := snapshot.Consistent(); err != nil {
+		problems = append(problems, snapshotProblem{err: fmt.Errorf("%w: %w", ErrInvalidConfig, err)})
+	}
+
+	clusters := snapshot.GetResources(resource.ClusterType)
+	for _, name := range sortedNames(snapshot.GetResources(resource.RouteType)) {
+		routeConfig := snapshot.GetResources(resource.RouteType)[name].(*route.RouteConfiguration)
+		problems = append(problems, validateRouteConfig(routeConfig, clusters, owners)...)
+	}
+	for _, name := range sortedNames(snapshot.GetResources(resource.ListenerType)) {
+		l := snapshot.GetResources(resource.ListenerType)[name].(*listener.Listener)
+		problems = append(problems, validateListener(l, owners)...)
+	}
+	return problems
+}
+
+func validateRouteConfig(routeConfig *route.RouteConfiguration, clusters map[string]cachetypes.Resource, owners *resourceOwners) []snapshotProblem {
+	var problems []snapshotProblem
+	domains := make(map[string]string)
+	for _, virtualHost := range routeConfig.GetVirtualHosts() {
+		for _, domain := range virtualHost.GetDomains() {
+			if other, ok := domains[domain]; ok && other != virtualHost.GetName() {
+				problems = append(problems, snapshotProblem{
+					ingress: owners.virtualHost(virtualHost.GetName()),
+					err: fmt.Errorf("%w: domain %q of route config %q is already served by virtual host %q",
+						ErrDomainConflict, domain, routeConfig.GetName(), other),
+				})
+				continue
+			}
+			domains[domain] = virtualHost.GetName()
+		}
+
+		for _, r := range virtualHost.GetRoutes() {
+			for _, cluster := range routeClusters(r) {
+				if _, ok := clusters[cluster]; !ok && !slices.Contains(bootstrapClusters, cluster) {
+					problems = append(problems, snapshotProblem{
+						ingress: owners.virtualHost(virtualHost.GetName()),
+						err:     fmt.Errorf("%w: route %q references unknown cluster %q", ErrInvalidConfig, r.GetName(), cluster),
+					})
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// routeClusters returns the names of the clusters the given route forwards to.
+func routeClusters(r *route.Route) []string {
+	action := r.GetRoute()
+	if action == nil {
+		return nil
+	}
+	if cluster := action.GetCluster(); cluster != "" {
+		return []string{cluster}
+	}
+	clusters := make([]string, 0, len(action.GetWeightedClusters().GetClusters()))
+	for _, weighted := range action.GetWeightedClusters().GetClusters() {
+		clusters = append(clusters, weighted.GetName())
+	}
+	return clusters
+}
+
+func validateListener(l *listener.Listener, owners *resourceOwners) []snapshotProblem {
+	if len(l.GetFilterChains()) == 0 && l.GetDefaultFilterChain() == nil {
+		return []snapshotProblem{{err: fmt.Errorf("%w: listener %q has no filter chains", ErrInvalidConfig, l.GetName())}}
+	}
+
+	var problems []snapshotProblem
+	// Server names are matched individually, so a filter chain overlaps with another
+	// one if they share a server name and match identically otherwise.
+	seen := make(map[string]struct{})
+	for _, chain := range l.GetFilterChains() {
+		match := proto.Clone(chain.GetFilterChainMatch()).(*listener.FilterChainMatch)
+		serverNames := match.GetServerNames()
+		if match != nil {
+			match.ServerNames = nil
+		}
+		marshaled, err := proto.MarshalOptions{Deterministic: true}.Marshal(match)
+		if err != nil {
+			problems = append(problems, snapshotProblem{err: err})
+			continue
+		}
+
+		if len(serverNames) == 0 {
+			serverNames = []string{""}
+		}
+		for _, serverName := range serverNames {
+			key := string(marshaled) + "\x00" + serverName
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				continue
+			}
+			err := fmt.Errorf("%w: server name %q is matched by more than one filter chain of listener %q",
+				ErrDomainConflict, serverName, l.GetName())
+			// The first ingress serving the name keeps it.
+			ingresses := owners.serverName(serverName)
+			if len(ingresses) < 2 {
+				problems = append(problems, snapshotProblem{err: err})
+				continue
+			}
+			for _, ingress := range ingresses[1:] {
+				problems = append(problems, snapshotProblem{ingress: ingress, err: err})
+			}
+		}
+	}
+	return problems
+}
+
+// dropEmptyListeners removes the listeners without any filter chain, which would
+// be rejected by the gateways.
+func dropEmptyListeners(listeners []cachetypes.Resource) []cachetypes.Resource {
+	return slices.DeleteFunc(listeners, func(r cachetypes.Resource) bool {
+		l := r.(*listener.Listener)
+		return len(l.GetFilterChains()) == 0 && l.GetDefaultFilterChain() == nil
+	})
+}
+
+func sortedNames(resources map[string]cachetypes.Resource) []string {
+	return slices.Sorted(maps.Keys(resources))
+}
+
+// rejection records the translation of an ingress evicted from the caches and the
+// reason it was evicted for.
+type rejection struct {
+	translated *translatedIngress
+	err        error
+}
+
+// equal returns whether both translations generate the same config.
+func (t *translatedIngress) equal(other *translatedIngress) bool {
+	return t.name == other.name &&
+		t.fleet == other.fleet &&
+		slices.EqualFunc(t.localSNIMatches, other.localSNIMatches, sniMatchEqual) &&
+		slices.EqualFunc(t.externalSNIMatches, other.externalSNIMatches, sniMatchEqual) &&
+		slices.EqualFunc(t.clusters, other.clusters, protoEqual) &&
+		slices.EqualFunc(t.loadAssignments, other.loadAssignments, protoEqual) &&
+		slices.EqualFunc(t.externalVirtualHosts, other.externalVirtualHosts, protoEqual) &&
+		slices.EqualFunc(t.externalTLSVirtualHosts, other.externalTLSVirtualHosts, protoEqual) &&
+		slices.EqualFunc(t.localVirtualHosts, other.localVirtualHosts, protoEqual) &&
+		slices.EqualFunc(t.localTLSVirtualHosts, other.localTLSVirtualHosts, protoEqual) &&
+		proto.Equal(t.upstreamTrustBundle, other.upstreamTrustBundle)
+}
+
+func sniMatchEqual(a, b *envoy.SNIMatch) bool {
+	return slices.Equal(a.Hosts, b.Hosts) &&
+		a.CertSource == b.CertSource &&
+		bytes.Equal(a.CertificateChain, b.CertificateChain) &&
+		bytes.Equal(a.PrivateKey, b.PrivateKey)
+}
+
+func protoEqual[T proto.Message](a, b T) bool {
+	return proto.Equal(a, b)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/protobuf/testing/protocmp"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+)
+
+func TestRejectDanglingClusterReference(t *testing.T) {
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+	caches, err := NewCaches(ctx, fake.NewSimpleClientset())
+	assert.NilError(t, err)
+
+	rejected := make(map[types.NamespacedName]error)
+	caches.SetOnRejected(func(key types.NamespacedName, err error) {
+		rejected[key] = err
+	})
+
+	valid := benchmarkTranslatedIngress(1, "rev-1")
+	assert.NilError(t, caches.UpdateIngress(ctx, valid))
+
+	// The routes reference a cluster that's not part of the translation.
+	dangling := benchmarkTranslatedIngress(2, "rev-1")
+	dangling.clusters = nil
+	dangling.loadAssignments = nil
+	assert.NilError(t, caches.UpdateIngress(ctx, dangling))
+
+	snapshots, _, err := caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(rejected), 1)
+	assert.Assert(t, errors.Is(rejected[dangling.name], ErrInvalidConfig), rejected[dangling.name])
+
+	// The rejected ingress is left out, the other one is still served.
+	vhosts := getVHostsNames(snapshotRouteConfigs(snapshots[config.DefaultGatewayFleet]))
+	assert.Assert(t, slices.Contains(vhosts, valid.externalVirtualHosts[0].GetName()))
+	assert.Assert(t, !slices.Contains(vhosts, dangling.externalVirtualHosts[0].GetName()))
+	_, ok, err := caches.DumpIngress(dangling.name)
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+
+	// The same translation is rejected right away.
+	dangling = benchmarkTranslatedIngress(2, "rev-1")
+	dangling.clusters = nil
+	dangling.loadAssignments = nil
+	assert.Assert(t, errors.Is(caches.UpdateIngress(ctx, dangling), ErrInvalidConfig))
+
+	// A fixed translation is admitted again.
+	assert.NilError(t, caches.UpdateIngress(ctx, benchmarkTranslatedIngress(2, "rev-2")))
+	clear(rejected)
+	_, _, err = caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(rejected), 0)
+}
+
+func TestRejectionRevision(t *testing.T) {
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+	caches, err := NewCaches(ctx, fake.NewSimpleClientset())
+	assert.NilError(t, err)
+
+	var rejectedAt uint64
+	caches.SetOnRejected(func(types.NamespacedName, error) {
+		// Reconciling the rejected ingress again waits for the current revision.
+		rejectedAt = caches.Revision()
+	})
+
+	valid := benchmarkTranslatedIngress(1, "rev-1")
+	assert.NilError(t, caches.UpdateIngress(ctx, valid))
+	dangling := benchmarkTranslatedIngress(2, "rev-1")
+	dangling.clusters = nil
+	dangling.loadAssignments = nil
+	assert.NilError(t, caches.UpdateIngress(ctx, dangling))
+	// Both ingresses wait for the gateways to acknowledge this revision.
+	waiting := caches.Revision()
+
+	// The snapshots are generated from the revision evicting the dangling ingress,
+	// so acknowledging it makes the valid ingress ready and resolves the rejection.
+	snapshots, revision, err := caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+	vhosts := getVHostsNames(snapshotRouteConfigs(snapshots[config.DefaultGatewayFleet]))
+	assert.Assert(t, slices.Contains(vhosts, valid.externalVirtualHosts[0].GetName()))
+	assert.Assert(t, !slices.Contains(vhosts, dangling.externalVirtualHosts[0].GetName()))
+	assert.Assert(t, revision > waiting, "revision %d, want > %d", revision, waiting)
+	assert.Equal(t, rejectedAt, revision)
+	assert.Equal(t, caches.Revision(), revision)
+
+	// Nothing is evicted the next time, so the revision is unchanged.
+	_, again, err := caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, again, revision)
+}
+
+func TestRejectDuplicateDomain(t *testing.T) {
+	ctx := config.ToContext(context.Background(), config.FromContextOrDefaults(context.Background()))
+	caches, err := NewCaches(ctx, fake.NewSimpleClientset())
+	assert.NilError(t, err)
+
+	rejected := make(map[types.NamespacedName]error)
+	caches.SetOnRejected(func(key types.NamespacedName, err error) {
+		rejected[key] = err
+	})
+
+	first := benchmarkTranslatedIngress(1, "rev-1")
+	second := benchmarkTranslatedIngress(2, "rev-1")
+	// Both ingresses claim the same external domain.
+	second.externalVirtualHosts[0].Domains = first.externalVirtualHosts[0].GetDomains()
+	assert.NilError(t, caches.UpdateIngress(ctx, first))
+	assert.NilError(t, caches.UpdateIngress(ctx, second))
+
+	_, _, err = caches.ToEnvoySnapshots(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, len(rejected), 1)
+	for _, err := range rejected {
+		assert.Assert(t, errors.Is(err, ErrDomainConflict), err)
+	}
+
+	// Removing the ingress served in its place gives the rejected one another chance.
+	kept, evicted := first, second
+	if _, ok := rejected[first.name]; ok {
+		kept, evicted = second, first
+	}
+	assert.Assert(t, caches.UpdateIngress(ctx, evicted) != nil)
+	assert.NilError(t, caches.DeleteIngressInfo(ctx, kept.name.Name, kept.name.Namespace))
+	assert.NilError(t, caches.UpdateIngress(ctx, evicted))
+}
+
+func TestValidateListener(t *testing.T) {
+	owner1 := types.NamespacedName{Namespace: "ns", Name: "first"}
+	owner2 := types.NamespacedName{Namespace: "ns", Name: "second"}
+	owners := newResourceOwners([]*translatedIngress{
+		{name: owner1, externalSNIMatches: []*envoy.SNIMatch{{Hosts: []string{"foo.example.com"}}}},
+		{name: owner2, externalSNIMatches: []*envoy.SNIMatch{{Hosts: []string{"foo.example.com"}}}},
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		problems := validateListener(&listener.Listener{Name: "empty"}, owners)
+		assert.Equal(t, len(problems), 1)
+		assert.Equal(t, problems[0].ingress, types.NamespacedName{})
+		assert.Assert(t, errors.Is(problems[0].err, ErrInvalidConfig))
+	})
+
+	t.Run("duplicate server name", func(t *testing.T) {
+		problems := validateListener(&listener.Listener{
+			Name: "tls",
+			FilterChains: []*listener.FilterChain{
+				{FilterChainMatch: &listener.FilterChainMatch{ServerNames: []string{"foo.example.com"}}},
+				{FilterChainMatch: &listener.FilterChainMatch{ServerNames: []string{"bar.example.com", "foo.example.com"}}},
+			},
+		}, owners)
+		assert.Equal(t, len(problems), 1)
+		assert.Equal(t, problems[0].ingress, owner2)
+		assert.Assert(t, errors.Is(problems[0].err, ErrDomainConflict))
+	})
+
+	t.Run("distinct matches", func(t *testing.T) {
+		problems := validateListener(&listener.Listener{
+			Name: "tls",
+			FilterChains: []*listener.FilterChain{
+				{FilterChainMatch: &listener.FilterChainMatch{ServerNames: []string{"foo.example.com"}}},
+				{FilterChainMatch: &listener.FilterChainMatch{ServerNames: []string{"bar.example.com"}}},
+				{},
+			},
+		}, owners)
+		assert.Equal(t, len(problems), 0)
+	})
+}
+
+func TestDropEmptyListeners(t *testing.T) {
+	l := &listener.Listener{Name: "http", FilterChains: []*listener.FilterChain{{}}}
+	listeners := dropEmptyListeners([]cachetypes.Resource{&listener.Listener{Name: "empty"}, l})
+	assert.DeepEqual(t, listeners, []cachetypes.Resource{l}, protocmp.Transform())
+}
+
+func snapshotRouteConfigs(snapshot *cache.Snapshot) []*route.RouteConfiguration {
+	resources := snapshot.GetResources(resource.RouteType)
+	routeConfigs := make([]*route.RouteConfiguration, 0, len(resources))
+	for _, r := range resources {
+		routeConfigs = append(routeConfigs, r.(*route.RouteConfiguration))
+	}
+	return routeConfigs
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package offline translates ingresses read from manifests into Envoy config,
+// without a Kubernetes cluster.
+package offline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/utils/ptr"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// manifestExtensions are the extensions of the files read from directories.
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// Objects holds the objects ingresses are translated from.
+type Objects struct {
+	Ingresses      []*v1alpha1.Ingress
+	Services       []*corev1.Service
+	EndpointSlices []*discoveryv1.EndpointSlice
+	Secrets        []*corev1.Secret
+	ConfigMaps     []*corev1.ConfigMap
+	Namespaces     []*corev1.Namespace
+}
+
+// Load reads the objects from the given files and directories. Directories are
+// walked recursively for YAML and JSON files. Files can hold several documents,
+// and objects of any other kind are ignored.
+func Load(paths ...string) (*Objects, error) {
+	objects := &Objects{}
+	err := walkManifests(paths, func(file string) error {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := objects.Decode(f); err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// Fingerprint returns a hash of the names and contents of the files Load reads
+// from the given paths, to tell whether they changed.
+func Fingerprint(paths ...string) (string, error) {
+	hasher := sha256.New()
+	err := walkManifests(paths, func(file string) error {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hasher, "%s\x00%d\x00", file, len(data))
+		hasher.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// walkManifests calls f for the given files, and for the YAML and JSON files in
+// the given directories, in lexical order.
+func walkManifests(paths []string, f func(file string) error) error {
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (file != path && !slices.Contains(manifestExtensions, filepath.Ext(file))) {
+				return nil
+			}
+			return f(file)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode adds the objects in the given YAML or JSON stream.
+func (o *Objects) Decode(r io.Reader) error {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			// Empty documents.
+			continue
+		}
+		if err := o.add(raw); err != nil {
+			return err
+		}
+	}
+}
+
+// add adds the given object, if it's of a supported kind.
+func (o *Objects) add(raw json.RawMessage) error {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return err
+	}
+
+	var err error
+	switch typeMeta.GroupVersionKind() {
+	case v1alpha1.SchemeGroupVersion.WithKind("Ingress"):
+		o.Ingresses, err = appendDecoded(o.Ingresses, raw)
+	case corev1.SchemeGroupVersion.WithKind("Service"):
+		o.Services, err = appendDecoded(o.Services, raw)
+	case discoveryv1.SchemeGroupVersion.WithKind("EndpointSlice"):
+		o.EndpointSlices, err = appendDecoded(o.EndpointSlices, raw)
+	case corev1.SchemeGroupVersion.WithKind("Endpoints"):
+		var endpoints corev1.Endpoints
+		if err := json.Unmarshal(raw, &endpoints); err != nil {
+			return err
+		}
+		o.EndpointSlices = append(o.EndpointSlices, endpointSlices(&endpoints)...)
+	case corev1.SchemeGroupVersion.WithKind("Secret"):
+		o.Secrets, err = appendDecoded(o.Secrets, raw)
+	case corev1.SchemeGroupVersion.WithKind("ConfigMap"):
+		o.ConfigMaps, err = appendDecoded(o.ConfigMaps, raw)
+	case corev1.SchemeGroupVersion.WithKind("Namespace"):
+		o.Namespaces, err = appendDecoded(o.Namespaces, raw)
+	case corev1.SchemeGroupVersion.WithKind("List"):
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return err
+		}
+		for _, item := range list.Items {
+			if err := o.add(item); err != nil {
+				return err
+			}
+		}
+	case schema.GroupVersionKind{}:
+		return errors.New("object without apiVersion and kind")
+	}
+	return err
+}
+
+func appendDecoded[T any](objects []*T, raw json.RawMessage) ([]*T, error) {
+	object := new(T)
+	if err := json.Unmarshal(raw, object); err != nil {
+		return nil, err
+	}
+	return append(objects, object), nil
+}
+
+// endpointSlices converts the given endpoints to endpoint slices, like the
+// endpoint slice mirroring controller does, so that hand written endpoints can be
+// used. Every subset becomes a slice.
+func endpointSlices(endpoints *corev1.Endpoints) []*discoveryv1.EndpointSlice {
+	result := make([]*discoveryv1.EndpointSlice, 0, len(endpoints.Subsets))
+	for i, subset := range endpoints.Subsets {
+		slice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%d", endpoints.Name, i),
+				Namespace: endpoints.Namespace,
+				Labels:    map[string]string{discoveryv1.LabelServiceName: endpoints.Name},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+		}
+		for _, port := range subset.Ports {
+			slice.Ports = append(slice.Ports, discoveryv1.EndpointPort{
+				Name:     ptr.To(port.Name),
+				Port:     ptr.To(port.Port),
+				Protocol: ptr.To(port.Protocol),
+			})
+		}
+		addEndpoints := func(addresses []corev1.EndpointAddress, ready bool) {
+			for _, address := range addresses {
+				if ip := net.ParseIP(address.IP); ip != nil && ip.To4() == nil {
+					slice.AddressType = discoveryv1.AddressTypeIPv6
+				}
+				slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+					Addresses:  []string{address.IP},
+					Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(ready)},
+				})
+			}
+		}
+		addEndpoints(subset.Addresses, true)
+		addEndpoints(subset.NotReadyAddresses, false)
+		result = append(result, slice)
+	}
+	return result
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package offline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"gotest.tools/v3/assert"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/pkg/system"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ingress.yaml": `
+apiVersion: networking.internal.knative.dev/v1alpha1
+kind: Ingress
+metadata:
+  name: a
+  namespace: ns
+---
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ignored
+`,
+		"nested/list.json": `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"apiVersion": "v1", "kind": "Service", "metadata": {"name": "svc", "namespace": "ns"}},
+    {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "secret", "namespace": "ns"}}
+  ]
+}`,
+		"notes.txt": "not a manifest",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	objects, err := Load(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(objects.Ingresses), 1)
+	assert.Equal(t, objects.Ingresses[0].Name, "a")
+	assert.Equal(t, len(objects.Services), 1)
+	assert.Equal(t, objects.Services[0].Name, "svc")
+	assert.Equal(t, len(objects.Secrets), 1)
+
+	// Files given explicitly are read whatever their extension.
+	_, err = Load(filepath.Join(dir, "notes.txt"))
+	assert.ErrorContains(t, err, "notes.txt")
+}
+
+func TestLoadEndpoints(t *testing.T) {
+	objects := &Objects{}
+	assert.NilError(t, objects.Decode(strings.NewReader(`
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: svc
+  namespace: ns
+subsets:
+- addresses:
+  - ip: 10.0.0.1
+  notReadyAddresses:
+  - ip: 10.0.0.2
+  ports:
+  - name: http
+    port: 8080
+    protocol: TCP
+- addresses:
+  - ip: fd00::1
+  ports:
+  - name: http
+    port: 8080
+    protocol: TCP
+`)))
+
+	assert.Equal(t, len(objects.EndpointSlices), 2)
+	ipv4, ipv6 := objects.EndpointSlices[0], objects.EndpointSlices[1]
+	assert.Equal(t, ipv4.Name, "svc-0")
+	assert.Equal(t, ipv4.Namespace, "ns")
+	assert.Equal(t, ipv4.Labels[discoveryv1.LabelServiceName], "svc")
+	assert.Equal(t, ipv4.AddressType, discoveryv1.AddressTypeIPv4)
+	assert.Equal(t, *ipv4.Ports[0].Port, int32(8080))
+	assert.Equal(t, len(ipv4.Endpoints), 2)
+	assert.Equal(t, *ipv4.Endpoints[0].Conditions.Ready, true)
+	assert.Equal(t, *ipv4.Endpoints[1].Conditions.Ready, false)
+	assert.Equal(t, ipv6.AddressType, discoveryv1.AddressTypeIPv6)
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "ingress.yaml")
+	assert.NilError(t, os.WriteFile(manifest, []byte("kind: Ingress\n"), 0o600))
+
+	before, err := Fingerprint(dir)
+	assert.NilError(t, err)
+
+	// Files that aren't read don't count.
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o600))
+	after, err := Fingerprint(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, after, before)
+
+	assert.NilError(t, os.WriteFile(manifest, []byte("kind: Service\n"), 0o600))
+	after, err = Fingerprint(dir)
+	assert.NilError(t, err)
+	assert.Assert(t, after != before)
+}
+
+func TestTranslate(t *testing.T) {
+	t.Setenv(system.NamespaceEnvKey, "knative-serving")
+	t.Setenv(config.GatewayNamespaceEnv, "kourier-system")
+
+	objects, err := Load(filepath.Join("testdata", "helloworld.yaml"))
+	assert.NilError(t, err)
+	snapshots, err := Translate(context.Background(), objects)
+	assert.NilError(t, err)
+	got, err := Marshal(snapshots, "json")
+	assert.NilError(t, err)
+
+	// Regenerate with:
+	//   go run ./cmd/kourier-translate pkg/offline/testdata/helloworld.yaml > pkg/offline/testdata/helloworld.json
+	want, err := os.ReadFile(filepath.Join("testdata", "helloworld.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), string(want))
+}
+
+func TestTranslateOtherIngressClass(t *testing.T) {
+	t.Setenv(system.NamespaceEnvKey, "knative-serving")
+
+	// Neither ingresses of another class nor ingresses without a class are
+	// translated, like in the controller.
+	objects := &Objects{}
+	assert.NilError(t, objects.Decode(strings.NewReader(`
+apiVersion: networking.internal.knative.dev/v1alpha1
+kind: Ingress
+metadata:
+  name: other
+  namespace: ns
+  annotations:
+    networking.knative.dev/ingress.class: istio.ingress.networking.knative.dev
+spec:
+  rules:
+  - hosts: [other.example.com]
+    visibility: ExternalIP
+    http:
+      paths:
+      - splits:
+        - serviceName: svc
+          serviceNamespace: ns
+          servicePort: 80
+          percent: 100
+---
+apiVersion: networking.internal.knative.dev/v1alpha1
+kind: Ingress
+metadata:
+  name: unset
+  namespace: ns
+spec:
+  rules:
+  - hosts: [unset.example.com]
+    visibility: ExternalIP
+    http:
+      paths:
+      - splits:
+        - serviceName: svc
+          serviceNamespace: ns
+          servicePort: 80
+          percent: 100
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: ns
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: svc-abcde
+  namespace: ns
+  labels:
+    kubernetes.io/service-name: svc
+addressType: IPv4
+ports:
+- port: 80
+endpoints:
+- addresses: [10.0.0.1]
+`)))
+
+	snapshots, err := Translate(context.Background(), objects)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snapshots[config.DefaultGatewayFleet].GetResources(resource.ClusterType)), 0)
+}
+
+func TestTranslateRejectedIngress(t *testing.T) {
+	t.Setenv(system.NamespaceEnvKey, "knative-serving")
+
+	objects := &Objects{}
+	assert.NilError(t, objects.Decode(strings.NewReader(`
+apiVersion: networking.internal.knative.dev/v1alpha1
+kind: Ingress
+metadata:
+  name: invalid
+  namespace: ns
+  annotations:
+    networking.knative.dev/ingress.class: kourier.ingress.networking.knative.dev
+spec:
+  rules:
+  - hosts: [invalid.example.com]
+    visibility: ExternalIP
+    http:
+      paths:
+      - headers:
+          x-user: {}
+        splits:
+        - serviceName: other
+          serviceNamespace: ns
+          servicePort: 80
+          percent: 100
+---
+apiVersion: networking.internal.knative.dev/v1alpha1
+kind: Ingress
+metadata:
+  name: valid
+  namespace: ns
+  annotations:
+    networking.knative.dev/ingress.class: kourier.ingress.networking.knative.dev
+spec:
+  rules:
+  - hosts: [valid.example.com]
+    visibility: ExternalIP
+    http:
+      paths:
+      - splits:
+        - serviceName: svc
+          serviceNamespace: ns
+          servicePort: 80
+          percent: 100
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: ns
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: svc-abcde
+  namespace: ns
+  labels:
+    kubernetes.io/service-name: svc
+addressType: IPv4
+ports:
+- port: 80
+endpoints:
+- addresses: [10.0.0.1]
+`)))
+
+	// The rejected ingress is reported, the other one is still translated.
+	snapshots, err := Translate(context.Background(), objects)
+	assert.ErrorContains(t, err, "ingress ns/invalid rejected")
+	clusters := snapshots[config.DefaultGatewayFleet].GetResources(resource.ClusterType)
+	assert.Equal(t, len(clusters), 1)
+	_, ok := clusters["ns/svc"]
+	assert.Assert(t, ok, "clusters: %v", clusters)
+}
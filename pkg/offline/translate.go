@@ -0,0 +1,189 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package offline
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	discoveryv1listers "k8s.io/client-go/listers/discovery/v1"
+	k8scache "k8s.io/client-go/tools/cache"
+	"knative.dev/net-kourier/pkg/generator"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/networking/pkg/apis/networking/v1alpha1"
+	netconfig "knative.dev/networking/pkg/config"
+	"knative.dev/pkg/reconciler"
+	"knative.dev/pkg/system"
+	"knative.dev/pkg/tracker"
+	"sigs.k8s.io/yaml"
+)
+
+// isKourierIngress selects the ingresses of the Kourier ingress class, like the
+// controller does. Ingresses without an ingress class are skipped.
+var isKourierIngress = reconciler.AnnotationFilterFunc(
+	networking.IngressClassAnnotationKey, config.KourierIngressClassName, false,
+)
+
+// Translate translates the ingresses of the given objects the way the controller
+// does, with config-kourier and config-network taken from the objects if present.
+// It returns the snapshots of all gateway fleets, keyed by node ID. Ingresses of
+// another ingress class or without one are skipped. If ingresses are rejected, the snapshots
+// generated without them are returned along with an error listing them.
+func Translate(ctx context.Context, objects *Objects) (map[string]*cache.Snapshot, error) {
+	cfg, err := objects.config()
+	if err != nil {
+		return nil, err
+	}
+	ctx = config.ToContext(ctx, cfg)
+
+	secrets := newIndexer(objects.Secrets)
+	configMaps := newIndexer(objects.ConfigMaps)
+	endpointSlices := newIndexer(objects.EndpointSlices)
+	services := newIndexer(objects.Services)
+	namespaces := newIndexer(objects.Namespaces)
+
+	translator := generator.NewIngressTranslator(
+		func(ns, name string) (*corev1.Secret, error) {
+			return corev1listers.NewSecretLister(secrets).Secrets(ns).Get(name)
+		},
+		func(label string) ([]*corev1.ConfigMap, error) {
+			req, err := labels.NewRequirement(label, selection.Exists, nil)
+			if err != nil {
+				return nil, err
+			}
+			return corev1listers.NewConfigMapLister(configMaps).ConfigMaps(system.Namespace()).List(labels.NewSelector().Add(*req))
+		},
+		func(ns, name string) ([]*discoveryv1.EndpointSlice, error) {
+			selector := labels.SelectorFromSet(labels.Set{
+				discoveryv1.LabelServiceName: name,
+			})
+			return discoveryv1listers.NewEndpointSliceLister(endpointSlices).EndpointSlices(ns).List(selector)
+		},
+		func(ns, name string) (*corev1.Service, error) {
+			return corev1listers.NewServiceLister(services).Services(ns).Get(name)
+		},
+		func(name string) (*corev1.Namespace, error) {
+			return corev1listers.NewNamespaceLister(namespaces).Get(name)
+		},
+		tracker.New(func(types.NamespacedName) {}, 0))
+
+	// The caches only read the secret of the certificate shared by all ingresses
+	// through the client.
+	kubeObjects := make([]runtime.Object, 0, len(objects.Secrets))
+	for _, secret := range objects.Secrets {
+		kubeObjects = append(kubeObjects, secret)
+	}
+	caches, err := generator.NewCaches(ctx, fake.NewClientset(kubeObjects...))
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	caches.SetOnRejected(func(key types.NamespacedName, err error) {
+		errs = append(errs, fmt.Errorf("ingress %s rejected: %w", key, err))
+	})
+
+	ingresses := slices.SortedFunc(slices.Values(objects.Ingresses), func(a, b *v1alpha1.Ingress) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	for _, ing := range ingresses {
+		if !isKourierIngress(ing) {
+			continue
+		}
+		if err := generator.UpdateInfoForIngress(ctx, caches, ing.DeepCopy(), &translator); err != nil {
+			// The controller reports the error on the ingress and serves the others.
+			errs = append(errs, fmt.Errorf("ingress %s rejected: %w", types.NamespacedName{Namespace: ing.Namespace, Name: ing.Name}, err))
+		}
+	}
+
+	snapshots, _, err := caches.ToEnvoySnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, errors.Join(errs...)
+}
+
+// Marshal returns the resources of the given snapshots, in the format served by
+// the debug endpoint of the controller. The format is either "json" or "yaml".
+func Marshal(snapshots map[string]*cache.Snapshot, format string) ([]byte, error) {
+	dumps := make(map[string]*generator.SnapshotDump, len(snapshots))
+	for nodeID, snapshot := range snapshots {
+		dump, err := generator.DumpSnapshot(snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump snapshot of %s: %w", nodeID, err)
+		}
+		dumps[nodeID] = dump
+	}
+
+	data, err := json.MarshalIndent(dumps, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "json":
+		return append(data, '\n'), nil
+	case "yaml":
+		return yaml.JSONToYAML(data)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// config returns the configuration of the controller, read from the config maps
+// of the objects or defaulted.
+func (o *Objects) config() (*config.Config, error) {
+	cfg := config.FromContextOrDefaults(context.Background())
+	for _, cm := range o.ConfigMaps {
+		var err error
+		switch cm.Name {
+		case config.ConfigName:
+			cfg.Kourier, err = config.NewKourierConfigFromConfigMap(cm)
+		case netconfig.ConfigMapName:
+			cfg.Network, err = netconfig.NewConfigFromConfigMap(cm)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid config map %s: %w", cm.Name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// newIndexer returns an indexer holding the given objects, to be read through
+// listers like the informers of the controller.
+func newIndexer[T any](objects []*T) k8scache.Indexer {
+	indexer := k8scache.NewIndexer(k8scache.MetaNamespaceKeyFunc, k8scache.Indexers{
+		k8scache.NamespaceIndex: k8scache.MetaNamespaceIndexFunc,
+	})
+	for _, object := range objects {
+		// Objects of the same name replace each other, like later revisions would.
+		_ = indexer.Add(object)
+	}
+	return indexer
+}
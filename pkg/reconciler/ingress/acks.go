@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	envoy "knative.dev/net-kourier/pkg/envoy/server"
+)
+
+// ackStatusManager determines the readiness of ingresses by the snapshots the
+// gateways acknowledged, as an alternative to probing them. An ingress is ready
+// once all connected gateways of its fleet acknowledged a snapshot generated from a
+// revision of the caches that contains it. Gateways of other fleets don't hold it
+// back.
+type ackStatusManager struct {
+	tracker *envoy.AckTracker
+	// readyCallback is called for every ingress that became ready after IsReady
+	// returned false for it.
+	readyCallback func(types.NamespacedName)
+	// ackedCallback, if set, is called with the generation all gateways acknowledged
+	// whenever it might have changed.
+	ackedCallback func(uint64)
+
+	mu sync.Mutex
+	// waiting holds the fleet and revision every ingress that is not ready yet
+	// waits for.
+	waiting map[types.NamespacedName]ackWaiter
+}
+
+// ackWaiter is a revision of the caches an ingress waits for the gateways of its
+// fleet to acknowledge.
+type ackWaiter struct {
+	fleet    string
+	revision uint64
+}
+
+func newAckStatusManager(readyCallback func(types.NamespacedName)) *ackStatusManager {
+	m := &ackStatusManager{
+		readyCallback: readyCallback,
+		waiting:       make(map[types.NamespacedName]ackWaiter),
+	}
+	m.tracker = envoy.NewAckTracker(m.recheck)
+	return m
+}
+
+// IsReady returns whether all gateways of the given fleet acknowledged the given
+// revision. If not, the ingress is remembered and readyCallback is called once
+// they did.
+func (m *ackStatusManager) IsReady(key types.NamespacedName, fleet string, revision uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if acked, ok := m.tracker.FleetAckedGeneration(fleet); ok && acked >= revision {
+		delete(m.waiting, key)
+		return true
+	}
+	m.waiting[key] = ackWaiter{fleet: fleet, revision: revision}
+	return false
+}
+
+// CancelIngress stops waiting for the given ingress to become ready.
+func (m *ackStatusManager) CancelIngress(key types.NamespacedName) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.waiting, key)
+}
+
+// recheck calls readyCallback for all waiting ingresses that became ready.
+func (m *ackStatusManager) recheck() {
+	if acked, ok := m.tracker.AckedGeneration(); ok && m.ackedCallback != nil {
+		m.ackedCallback(acked)
+	}
+
+	var ready []types.NamespacedName
+	m.mu.Lock()
+	for key, waiter := range m.waiting {
+		if acked, ok := m.tracker.FleetAckedGeneration(waiter.fleet); ok && acked >= waiter.revision {
+			ready = append(ready, key)
+			delete(m.waiting, key)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, key := range ready {
+		m.readyCallback(key)
+	}
+}
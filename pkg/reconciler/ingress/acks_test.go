@@ -0,0 +1,161 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestAckStatusManager(t *testing.T) {
+	var ready []types.NamespacedName
+	m := newAckStatusManager(func(key types.NamespacedName) {
+		ready = append(ready, key)
+	})
+	foo := types.NamespacedName{Namespace: "ns", Name: "foo"}
+	bar := types.NamespacedName{Namespace: "ns", Name: "bar"}
+	baz := types.NamespacedName{Namespace: "ns", Name: "baz"}
+
+	// Nothing is ready without any gateway connected.
+	m.tracker.Published(1, testSnapshots(t, "v1")...)
+	assert.Assert(t, !m.IsReady(foo, "gateway", 1))
+
+	// A gateway connects and receives the first snapshot.
+	ctx := context.Background()
+	assert.NilError(t, m.tracker.OnStreamOpen(ctx, 1, resource.AnyType))
+	assert.NilError(t, m.tracker.OnStreamRequest(1, &discovery.DiscoveryRequest{
+		Node:    &core.Node{Id: "gateway"},
+		TypeUrl: resource.ClusterType,
+	}))
+	m.tracker.OnStreamResponse(ctx, 1, nil, &discovery.DiscoveryResponse{
+		TypeUrl:     resource.ClusterType,
+		VersionInfo: "v1",
+		Nonce:       "a",
+	})
+	assert.Assert(t, !m.IsReady(bar, "gateway", 2))
+	assert.Assert(t, len(ready) == 0)
+
+	ack := func(version, nonce string) {
+		assert.NilError(t, m.tracker.OnStreamRequest(1, &discovery.DiscoveryRequest{
+			TypeUrl:       resource.ClusterType,
+			VersionInfo:   version,
+			ResponseNonce: nonce,
+		}))
+	}
+	ack("v1", "a")
+	assert.DeepEqual(t, ready, []types.NamespacedName{foo})
+	assert.Assert(t, m.IsReady(foo, "gateway", 1))
+
+	// bar is only contained in the next snapshot.
+	m.tracker.Published(2, testSnapshots(t, "v2")...)
+	m.tracker.OnStreamResponse(ctx, 1, nil, &discovery.DiscoveryResponse{
+		TypeUrl:     resource.ClusterType,
+		VersionInfo: "v2",
+		Nonce:       "b",
+	})
+	ack("v2", "b")
+	assert.DeepEqual(t, ready, []types.NamespacedName{foo, bar})
+
+	// A cancelled ingress is not reported.
+	assert.Assert(t, !m.IsReady(baz, "gateway", 3))
+	m.CancelIngress(baz)
+	m.tracker.Published(3, testSnapshots(t, "v3")...)
+	m.tracker.OnStreamResponse(ctx, 1, nil, &discovery.DiscoveryResponse{
+		TypeUrl:     resource.ClusterType,
+		VersionInfo: "v3",
+		Nonce:       "c",
+	})
+	ack("v3", "c")
+	assert.DeepEqual(t, ready, []types.NamespacedName{foo, bar})
+}
+
+func TestAckStatusManagerFleets(t *testing.T) {
+	var ready []types.NamespacedName
+	m := newAckStatusManager(func(key types.NamespacedName) {
+		ready = append(ready, key)
+	})
+	foo := types.NamespacedName{Namespace: "ns", Name: "foo"}
+	bar := types.NamespacedName{Namespace: "ns", Name: "bar"}
+
+	// A gateway of each fleet connects and receives the first snapshot of its fleet.
+	ctx := context.Background()
+	m.tracker.Published(1, testSnapshots(t, "a1", "b1")...)
+	for id, fleet := range map[int64]string{1: "fleet-a", 2: "fleet-b"} {
+		assert.NilError(t, m.tracker.OnStreamOpen(ctx, id, resource.AnyType))
+		assert.NilError(t, m.tracker.OnStreamRequest(id, &discovery.DiscoveryRequest{
+			Node:    &core.Node{Id: fleet},
+			TypeUrl: resource.ClusterType,
+		}))
+	}
+	send := func(id int64, version, nonce string) {
+		m.tracker.OnStreamResponse(ctx, id, nil, &discovery.DiscoveryResponse{
+			TypeUrl:     resource.ClusterType,
+			VersionInfo: version,
+			Nonce:       nonce,
+		})
+	}
+	ack := func(id int64, version, nonce string) {
+		assert.NilError(t, m.tracker.OnStreamRequest(id, &discovery.DiscoveryRequest{
+			TypeUrl:       resource.ClusterType,
+			VersionInfo:   version,
+			ResponseNonce: nonce,
+		}))
+	}
+	send(1, "a1", "a")
+	send(2, "b1", "b")
+	ack(1, "a1", "a")
+	assert.Assert(t, m.IsReady(foo, "fleet-a", 1))
+	assert.Assert(t, !m.IsReady(bar, "fleet-b", 1))
+
+	// The gateway of fleet-b never acknowledges anything, which doesn't hold back
+	// the ingresses of fleet-a.
+	m.tracker.Published(2, testSnapshots(t, "a2", "b2")...)
+	assert.Assert(t, !m.IsReady(foo, "fleet-a", 2))
+	send(1, "a2", "c")
+	send(2, "b2", "d")
+	ack(1, "a2", "c")
+	assert.DeepEqual(t, ready, []types.NamespacedName{foo})
+	assert.Assert(t, !m.IsReady(bar, "fleet-b", 1))
+}
+
+// testSnapshots returns a snapshot for each of the given versions, each with a
+// different cluster.
+func testSnapshots(t *testing.T, versions ...string) []*cache.Snapshot {
+	t.Helper()
+
+	snapshots := make([]*cache.Snapshot, 0, len(versions))
+	for _, version := range versions {
+		snapshot, err := cache.NewSnapshot(version, map[resource.Type][]cachetypes.Resource{
+			resource.ClusterType:  {&clusterv3.Cluster{Name: version, ConnectTimeout: durationpb.New(time.Second)}},
+			resource.ListenerType: nil,
+		})
+		assert.NilError(t, err)
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
@@ -58,6 +58,50 @@ const (
 	// GatewayNamespaceEnv is an env variable specifying where the gateway is deployed.
 	GatewayNamespaceEnv = "KOURIER_GATEWAY_NAMESPACE"
 
+	// DefaultGatewayFleet is the node ID of the gateways serving all ingresses that
+	// are not selected for another fleet.
+	DefaultGatewayFleet = "3scale-kourier-gateway"
+
+	// GatewayFleetKey is the annotation of an ingress, or the label of its namespace,
+	// selecting the fleet of gateways serving it. The annotation takes precedence.
+	GatewayFleetKey = "kourier.knative.dev/gateway-fleet"
+
+	// PathMatchKey is the annotation of an ingress selecting how its paths are
+	// matched. It holds a JSON object mapping paths of the ingress to "Prefix",
+	// "Exact", "PathSeparatedPrefix" or "Regex". Paths not listed are matched as
+	// prefixes.
+	PathMatchKey = "kourier.knative.dev/path-match"
+
+	// HeaderMatchKey is the annotation of an ingress adding header matches to its
+	// paths. It holds a JSON object mapping paths of the ingress to objects mapping
+	// header names to matches, which replace the matches of the same headers in the
+	// spec of the ingress.
+	HeaderMatchKey = "kourier.knative.dev/header-match"
+
+	// QueryMatchKey is the annotation of an ingress adding query parameter matches
+	// to its paths. It holds a JSON object mapping paths of the ingress to objects
+	// mapping query parameter names to matches.
+	QueryMatchKey = "kourier.knative.dev/query-match"
+
+	// RetryOnKey, NumRetriesKey, PerTryTimeoutKey, RetriableStatusCodesKey and
+	// RetryPreviousHostsKey are the annotations of an ingress overriding the retry
+	// policy of its routes set in config-kourier. See RetryPolicy.
+	RetryOnKey              = "kourier.knative.dev/retry-on"
+	NumRetriesKey           = "kourier.knative.dev/num-retries"
+	PerTryTimeoutKey        = "kourier.knative.dev/per-try-timeout"
+	RetriableStatusCodesKey = "kourier.knative.dev/retriable-status-codes"
+	RetryPreviousHostsKey   = "kourier.knative.dev/retry-previous-hosts"
+
+	// RequestTimeoutKey and RequestIdleTimeoutKey are the annotations of an ingress
+	// overriding the timeouts of its routes set in config-kourier. See RouteTimeouts.
+	RequestTimeoutKey     = "kourier.knative.dev/request-timeout"
+	RequestIdleTimeoutKey = "kourier.knative.dev/request-idle-timeout"
+
+	// PathTimeoutsKey is the annotation of an ingress overriding the timeouts of
+	// its paths. It holds a JSON object mapping paths of the ingress to objects
+	// with a "request" and an "idle" timeout, like {"/": {"request": "30s"}}.
+	PathTimeoutsKey = "kourier.knative.dev/path-timeouts"
+
 	// KourierIngressClassName is the class name to reconcile.
 	KourierIngressClassName = "kourier.ingress.networking.knative.dev"
 
@@ -18,8 +18,10 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"net"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -29,7 +31,9 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	cm "knative.dev/pkg/configmap"
+	"knative.dev/pkg/network"
 	"knative.dev/pkg/observability/metrics"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -53,6 +57,36 @@ const (
 	// for incoming requests. This value is set to "stream_idle_timeout" in Envoy.
 	IdleTimeoutKey = "stream-idle-timeout"
 
+	// snapshotMinIntervalKey is the config map key for the amount of time that
+	// Kourier waits for further changes before publishing a new snapshot.
+	snapshotMinIntervalKey = "snapshot-min-interval"
+
+	// snapshotMaxDelayKey is the config map key for the maximum amount of time a
+	// change waits before it is published, regardless of further changes.
+	snapshotMaxDelayKey = "snapshot-max-delay"
+
+	// enableReadinessProbingKey is the config map key for determining ingress
+	// readiness by probing the gateways rather than by their xDS acknowledgements.
+	enableReadinessProbingKey = "enable-readiness-probing"
+
+	// gatewayFleetsKey is the config map key for the additional fleets of gateways,
+	// keyed by their node ID.
+	gatewayFleetsKey = "gateway-fleets"
+
+	// retryOnKey, numRetriesKey, perTryTimeoutKey, retriableStatusCodesKey and
+	// retryPreviousHostsKey are the config map keys for the default retry policy of
+	// the routes. See RetryPolicy.
+	retryOnKey              = "retry-on"
+	numRetriesKey           = "num-retries"
+	perTryTimeoutKey        = "per-try-timeout"
+	retriableStatusCodesKey = "retriable-status-codes"
+	retryPreviousHostsKey   = "retry-previous-hosts"
+
+	// requestTimeoutKey and requestIdleTimeoutKey are the config map keys for the
+	// default timeouts of the routes. See RouteTimeouts.
+	requestTimeoutKey     = "request-timeout"
+	requestIdleTimeoutKey = "request-idle-timeout"
+
 	// enableCryptoMB is the config map for enabling CryptoMB private key provider.
 	enableCryptoMB = "enable-cryptomb"
 
@@ -114,6 +148,12 @@ func NewKourierConfigFromMap(configMap map[string]string) (*Kourier, error) {
 		cm.AsBool(enableProxyProtocol, &nc.EnableProxyProtocol),
 		cm.AsString(clusterCert, &nc.ClusterCertSecret),
 		cm.AsDuration(IdleTimeoutKey, &nc.IdleTimeout),
+		cm.AsDuration(snapshotMinIntervalKey, &nc.SnapshotMinInterval),
+		cm.AsDuration(snapshotMaxDelayKey, &nc.SnapshotMaxDelay),
+		cm.AsBool(enableReadinessProbingKey, &nc.EnableReadinessProbing),
+		asGatewayFleets(gatewayFleetsKey, &nc.GatewayFleets),
+		asRetryPolicy(configMapRetryKeys, &nc.RetryPolicy),
+		asRouteTimeouts(requestTimeoutKey, requestIdleTimeoutKey, &nc.RouteTimeouts),
 		cm.AsUint32(trustedHopsCount, &nc.TrustedHopsCount),
 		cm.AsBool(useRemoteAddress, &nc.UseRemoteAddress),
 		cm.AsStringSet(cipherSuites, &nc.CipherSuites),
@@ -128,6 +168,16 @@ func NewKourierConfigFromMap(configMap map[string]string) (*Kourier, error) {
 		return nil, err
 	}
 
+	if nc.SnapshotMinInterval < 0 || nc.SnapshotMaxDelay < 0 {
+		return nil, fmt.Errorf("%s and %s must not be negative", snapshotMinIntervalKey, snapshotMaxDelayKey)
+	}
+	if nc.SnapshotMaxDelay != 0 && nc.SnapshotMaxDelay < nc.SnapshotMinInterval {
+		return nil, fmt.Errorf("%s must not be smaller than %s", snapshotMaxDelayKey, snapshotMinIntervalKey)
+	}
+	if len(nc.GatewayFleets) > 0 && nc.EnableReadinessProbing {
+		return nil, fmt.Errorf("%s is not supported together with %s", enableReadinessProbingKey, gatewayFleetsKey)
+	}
+
 	return nc, nil
 }
 
@@ -283,6 +333,31 @@ type Kourier struct {
 	// this option, for example, the "timeoutSeconds" specified in Knative service is still
 	// valid.
 	IdleTimeout time.Duration
+	// SnapshotMinInterval specifies the amount of time without further changes
+	// that Kourier waits for before publishing a new snapshot, so that bursts of
+	// changes are coalesced into a single snapshot. The default, 0s, publishes
+	// changes as soon as possible.
+	SnapshotMinInterval time.Duration
+	// SnapshotMaxDelay bounds how long a change waits for the burst it is part of
+	// to settle before it is published anyway. The default, 0s, waits no longer
+	// than SnapshotMinInterval.
+	SnapshotMaxDelay time.Duration
+	// EnableReadinessProbing specifies whether an ingress is marked ready once HTTP
+	// probes sent to every gateway pod succeed. By default, an ingress is marked
+	// ready once all connected gateways of its fleet acknowledged a snapshot
+	// containing it.
+	EnableReadinessProbing bool
+	// GatewayFleets holds the fleets of gateways besides the default one, keyed by
+	// the node ID of their gateways. Every fleet is served the ingresses selected
+	// for it by the GatewayFleetKey annotation or namespace label.
+	GatewayFleets map[string]GatewayFleet
+	// RetryPolicy is the default retry policy of the routes, which the retry
+	// annotations of an ingress override. By default, requests aren't retried.
+	RetryPolicy RetryPolicy
+	// RouteTimeouts are the default timeouts of the routes, which the timeout
+	// annotations of an ingress override. By default, requests are only bounded by
+	// IdleTimeout.
+	RouteTimeouts RouteTimeouts
 	// TrustedHopsCount configures the number of additional ingress proxy hops from the
 	// right side of the x-forwarded-for HTTP header to trust.
 	TrustedHopsCount uint32
@@ -308,12 +383,64 @@ type Kourier struct {
 	CertsSecretNamespace string
 }
 
+// GatewayFleet configures an additional fleet of gateways.
+type GatewayFleet struct {
+	// ExternalService and InternalService are the names of the services in front of
+	// the gateways of the fleet, in the gateway namespace.
+	ExternalService string `json:"external-service"`
+	InternalService string `json:"internal-service"`
+}
+
+// GatewayFleetNames returns the node IDs of all fleets, including the default one,
+// in a stable order.
+func (k *Kourier) GatewayFleetNames() []string {
+	names := append(make([]string, 0, len(k.GatewayFleets)+1), DefaultGatewayFleet)
+	return append(names, slices.Sorted(maps.Keys(k.GatewayFleets))...)
+}
+
+// ServiceHostnames returns the hostnames of the external and internal services in
+// front of the gateways of the given fleet.
+func (k *Kourier) ServiceHostnames(fleet string) (string, string) {
+	gatewayFleet, ok := k.GatewayFleets[fleet]
+	if !ok {
+		return ServiceHostnames()
+	}
+	return network.GetServiceHostname(gatewayFleet.ExternalService, GatewayNamespace()),
+		network.GetServiceHostname(gatewayFleet.InternalService, GatewayNamespace())
+}
+
 // UseHTTPSListenerWithOneCert returns true if we need to modify the HTTPS listener with just one cert
 // instead of one per ingress.
 func (k *Kourier) UseHTTPSListenerWithOneCert() bool {
 	return k.CertsSecretName != "" && k.CertsSecretNamespace != ""
 }
 
+// asGatewayFleets parses and validates the YAML map of gateway fleets, keyed by
+// the node ID of their gateways.
+func asGatewayFleets(key string, target *map[string]GatewayFleet) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok || strings.TrimSpace(raw) == "" {
+			return nil
+		}
+
+		var fleets map[string]GatewayFleet
+		if err := yaml.UnmarshalStrict([]byte(raw), &fleets); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		for name, fleet := range fleets {
+			if name == DefaultGatewayFleet {
+				return fmt.Errorf("%s must not contain the default fleet %q", key, DefaultGatewayFleet)
+			}
+			if fleet.ExternalService == "" || fleet.InternalService == "" {
+				return fmt.Errorf("gateway fleet %q in %s must name its external and internal services", name, key)
+			}
+		}
+		*target = fleets
+		return nil
+	}
+}
+
 // asListenIPAddresses parses and validates a comma-separated list of IP addresses.
 func asListenIPAddresses(target *[]string) cm.ParseFunc {
 	return func(data map[string]string) error {
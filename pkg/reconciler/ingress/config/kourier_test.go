@@ -115,6 +115,126 @@ func TestKourierConfig(t *testing.T) {
 			clusterCert:                   "",
 			IdleTimeoutKey:                "200s",
 		},
+	}, {
+		name: "set snapshot intervals",
+		want: &Kourier{
+			ListenIPAddresses:          []string{"0.0.0.0"},
+			EnableServiceAccessLogging: true,
+			SnapshotMinInterval:        100 * time.Millisecond,
+			SnapshotMaxDelay:           time.Second,
+		},
+		data: map[string]string{
+			snapshotMinIntervalKey: "100ms",
+			snapshotMaxDelayKey:    "1s",
+		},
+	}, {
+		name: "enable readiness probing",
+		want: &Kourier{
+			ListenIPAddresses:          []string{"0.0.0.0"},
+			EnableServiceAccessLogging: true,
+			EnableReadinessProbing:     true,
+		},
+		data: map[string]string{
+			enableReadinessProbingKey: "true",
+		},
+	}, {
+		name: "gateway fleets",
+		want: &Kourier{
+			ListenIPAddresses:          []string{"0.0.0.0"},
+			EnableServiceAccessLogging: true,
+			GatewayFleets: map[string]GatewayFleet{
+				"edge": {ExternalService: "kourier-edge", InternalService: "kourier-edge-internal"},
+			},
+		},
+		data: map[string]string{
+			gatewayFleetsKey: `
+edge:
+  external-service: kourier-edge
+  internal-service: kourier-edge-internal
+`,
+		},
+	}, {
+		name: "retry policy",
+		want: &Kourier{
+			ListenIPAddresses:          []string{"0.0.0.0"},
+			EnableServiceAccessLogging: true,
+			RetryPolicy: RetryPolicy{
+				RetryOn:              []string{"connect-failure", "retriable-status-codes"},
+				NumRetries:           3,
+				PerTryTimeout:        2 * time.Second,
+				RetriableStatusCodes: []uint32{503, 504},
+				RetryPreviousHosts:   true,
+			},
+		},
+		data: map[string]string{
+			retryOnKey:              "connect-failure, retriable-status-codes",
+			numRetriesKey:           "3",
+			perTryTimeoutKey:        "2s",
+			retriableStatusCodesKey: "503,504",
+			retryPreviousHostsKey:   "true",
+		},
+	}, {
+		name: "route timeouts",
+		want: &Kourier{
+			ListenIPAddresses:          []string{"0.0.0.0"},
+			EnableServiceAccessLogging: true,
+			RouteTimeouts:              RouteTimeouts{Request: time.Minute, Idle: 10 * time.Minute},
+		},
+		data: map[string]string{
+			requestTimeoutKey:     "1m",
+			requestIdleTimeoutKey: "10m",
+		},
+	}, {
+		name:    "negative route timeout",
+		wantErr: true,
+		data: map[string]string{
+			requestTimeoutKey: "-1m",
+		},
+	}, {
+		name:    "unknown retry condition",
+		wantErr: true,
+		data: map[string]string{
+			retryOnKey: "5xx,flaky",
+		},
+	}, {
+		name:    "retriable status codes without their condition",
+		wantErr: true,
+		data: map[string]string{
+			retryOnKey:              "5xx",
+			retriableStatusCodesKey: "409",
+		},
+	}, {
+		name:    "gateway fleet without services",
+		wantErr: true,
+		data: map[string]string{
+			gatewayFleetsKey: `edge: {}`,
+		},
+	}, {
+		name:    "gateway fleets containing the default fleet",
+		wantErr: true,
+		data: map[string]string{
+			gatewayFleetsKey: DefaultGatewayFleet + `: {external-service: a, internal-service: b}`,
+		},
+	}, {
+		name:    "gateway fleets with readiness probing",
+		wantErr: true,
+		data: map[string]string{
+			enableReadinessProbingKey: "true",
+			gatewayFleetsKey:          `edge: {external-service: a, internal-service: b}`,
+		},
+	}, {
+		name:    "snapshot max delay smaller than min interval",
+		wantErr: true,
+		data: map[string]string{
+			snapshotMinIntervalKey: "1s",
+			snapshotMaxDelayKey:    "100ms",
+		},
+	}, {
+		name:    "negative snapshot min interval",
+		wantErr: true,
+		data: map[string]string{
+			snapshotMinIntervalKey: "-1s",
+		},
 	}, {
 		name: "add 3 trusted hops",
 		want: &Kourier{
@@ -0,0 +1,174 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	cm "knative.dev/pkg/configmap"
+)
+
+// RetriableStatusCodesCondition is the retry condition enabling the retries on
+// the RetriableStatusCodes of a RetryPolicy.
+const RetriableStatusCodesCondition = "retriable-status-codes"
+
+// retryConditions are the conditions of the x-envoy-retry-on and
+// x-envoy-retry-grpc-on headers of Envoy, which can be combined in a RetryPolicy.
+var retryConditions = sets.New(
+	"5xx", "gateway-error", "reset", "reset-before-request", "connect-failure",
+	"envoy-ratelimited", "retriable-4xx", "refused-stream", RetriableStatusCodesCondition,
+	"retriable-headers", "http3-post-connect-failure",
+	"cancelled", "deadline-exceeded", "internal", "resource-exhausted", "unavailable",
+)
+
+// RetryPolicy configures how the gateways retry the failed requests of a route.
+// +k8s:deepcopy-gen=true
+type RetryPolicy struct {
+	// RetryOn holds the conditions requests are retried on, like "5xx" or
+	// "connect-failure". Requests aren't retried if it's empty, the default.
+	// See https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/router_filter#x-envoy-retry-on.
+	RetryOn []string
+	// NumRetries is the maximum number of retries of a request. The default, 0,
+	// retries once, like Envoy does.
+	NumRetries uint32
+	// PerTryTimeout bounds every try of a request. The default, 0s, only bounds
+	// the request as a whole, by the timeout of its route.
+	PerTryTimeout time.Duration
+	// RetriableStatusCodes holds the status codes requests are retried on if
+	// RetryOn contains RetriableStatusCodesCondition.
+	RetriableStatusCodes []uint32
+	// RetryPreviousHosts specifies whether retries avoid the hosts tried already,
+	// so that a request failing on a terminating pod is retried on another one.
+	RetryPreviousHosts bool
+}
+
+// Enabled returns whether failed requests are retried.
+func (p *RetryPolicy) Enabled() bool {
+	return len(p.RetryOn) > 0
+}
+
+// WithAnnotations returns a copy of the policy overridden by the retry
+// annotations of an ingress.
+func (p *RetryPolicy) WithAnnotations(annotations map[string]string) (*RetryPolicy, error) {
+	policy := p.DeepCopy()
+	if err := cm.Parse(annotations, asRetryPolicy(annotationRetryKeys, policy)); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// retryPolicyKeys are the keys of the fields of a RetryPolicy, in a config map or
+// in annotations.
+type retryPolicyKeys struct {
+	retryOn, numRetries, perTryTimeout, retriableStatusCodes, retryPreviousHosts string
+}
+
+var (
+	configMapRetryKeys = retryPolicyKeys{
+		retryOn:              retryOnKey,
+		numRetries:           numRetriesKey,
+		perTryTimeout:        perTryTimeoutKey,
+		retriableStatusCodes: retriableStatusCodesKey,
+		retryPreviousHosts:   retryPreviousHostsKey,
+	}
+	annotationRetryKeys = retryPolicyKeys{
+		retryOn:              RetryOnKey,
+		numRetries:           NumRetriesKey,
+		perTryTimeout:        PerTryTimeoutKey,
+		retriableStatusCodes: RetriableStatusCodesKey,
+		retryPreviousHosts:   RetryPreviousHostsKey,
+	}
+)
+
+// asRetryPolicy parses the fields of a RetryPolicy set with the given keys, and
+// validates the resulting policy.
+func asRetryPolicy(keys retryPolicyKeys, target *RetryPolicy) cm.ParseFunc {
+	return func(data map[string]string) error {
+		if err := cm.Parse(data,
+			asRetryConditions(keys.retryOn, &target.RetryOn),
+			cm.AsUint32(keys.numRetries, &target.NumRetries),
+			cm.AsDuration(keys.perTryTimeout, &target.PerTryTimeout),
+			asStatusCodes(keys.retriableStatusCodes, &target.RetriableStatusCodes),
+			cm.AsBool(keys.retryPreviousHosts, &target.RetryPreviousHosts),
+		); err != nil {
+			return err
+		}
+
+		if target.PerTryTimeout < 0 {
+			return fmt.Errorf("%s must not be negative", keys.perTryTimeout)
+		}
+		if len(target.RetriableStatusCodes) > 0 && !slices.Contains(target.RetryOn, RetriableStatusCodesCondition) {
+			return fmt.Errorf("%s requires %s to contain %q", keys.retriableStatusCodes, keys.retryOn, RetriableStatusCodesCondition)
+		}
+		return nil
+	}
+}
+
+// asRetryConditions parses a comma-separated list of retry conditions. An empty
+// list disables retries.
+func asRetryConditions(key string, target *[]string) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok {
+			return nil
+		}
+
+		var conditions []string
+		for _, condition := range strings.Split(raw, ",") {
+			condition = strings.TrimSpace(condition)
+			if condition == "" {
+				continue
+			}
+			if !retryConditions.Has(condition) {
+				return fmt.Errorf("unknown retry condition %q in %s", condition, key)
+			}
+			conditions = append(conditions, condition)
+		}
+		*target = conditions
+		return nil
+	}
+}
+
+// asStatusCodes parses a comma-separated list of HTTP status codes.
+func asStatusCodes(key string, target *[]uint32) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok {
+			return nil
+		}
+
+		var codes []uint32
+		for _, code := range strings.Split(raw, ",") {
+			code = strings.TrimSpace(code)
+			if code == "" {
+				continue
+			}
+			parsed, err := strconv.ParseUint(code, 10, 32)
+			if err != nil || parsed < 100 || parsed > 599 {
+				return fmt.Errorf("invalid status code %q in %s", code, key)
+			}
+			codes = append(codes, uint32(parsed))
+		}
+		*target = codes
+		return nil
+	}
+}
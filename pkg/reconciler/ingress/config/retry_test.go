@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRetryPolicyWithAnnotations(t *testing.T) {
+	defaults := &RetryPolicy{
+		RetryOn:            []string{"5xx"},
+		NumRetries:         2,
+		RetryPreviousHosts: true,
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *RetryPolicy
+		wantErr     bool
+	}{{
+		name: "no annotations",
+		want: defaults,
+	}, {
+		name: "overridden fields",
+		annotations: map[string]string{
+			RetryOnKey:              "reset,retriable-status-codes",
+			PerTryTimeoutKey:        "500ms",
+			RetriableStatusCodesKey: "503",
+			RetryPreviousHostsKey:   "false",
+		},
+		want: &RetryPolicy{
+			RetryOn:              []string{"reset", "retriable-status-codes"},
+			NumRetries:           2,
+			PerTryTimeout:        500 * time.Millisecond,
+			RetriableStatusCodes: []uint32{503},
+		},
+	}, {
+		name:        "disabled",
+		annotations: map[string]string{RetryOnKey: ""},
+		want: &RetryPolicy{
+			NumRetries:         2,
+			RetryPreviousHosts: true,
+		},
+	}, {
+		name:        "negative per try timeout",
+		annotations: map[string]string{PerTryTimeoutKey: "-1s"},
+		wantErr:     true,
+	}, {
+		name:        "invalid status code",
+		annotations: map[string]string{RetryOnKey: "retriable-status-codes", RetriableStatusCodesKey: "700"},
+		wantErr:     true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := defaults.WithAnnotations(test.annotations)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("WithAnnotations() = %v, wantErr %v", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Error("WithAnnotations() (-want, +got):", diff)
+			}
+		})
+	}
+	if defaults.NumRetries != 2 || len(defaults.RetryOn) != 1 {
+		t.Errorf("WithAnnotations() modified the defaults: %+v", defaults)
+	}
+}
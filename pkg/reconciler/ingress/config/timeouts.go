@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	cm "knative.dev/pkg/configmap"
+)
+
+// RouteTimeouts configures how long the gateways wait for the requests of a
+// route.
+type RouteTimeouts struct {
+	// Request bounds the time from the end of a request to the end of its
+	// response, retries included. The default, 0s, doesn't bound it. Websocket
+	// connections and bidirectional streams, whose requests don't end, are only
+	// bounded by Idle.
+	Request time.Duration
+	// Idle bounds the time a request, websocket connection or stream may go
+	// without activity. The default, 0s, leaves it to IdleTimeout.
+	Idle time.Duration
+}
+
+// WithAnnotations returns a copy of the timeouts overridden by the timeout
+// annotations of an ingress.
+func (t RouteTimeouts) WithAnnotations(annotations map[string]string) (RouteTimeouts, error) {
+	if err := cm.Parse(annotations, asRouteTimeouts(RequestTimeoutKey, RequestIdleTimeoutKey, &t)); err != nil {
+		return RouteTimeouts{}, err
+	}
+	return t, nil
+}
+
+// asRouteTimeouts parses the request and idle timeouts set with the given keys.
+func asRouteTimeouts(requestKey, idleKey string, target *RouteTimeouts) cm.ParseFunc {
+	return func(data map[string]string) error {
+		if err := cm.Parse(data,
+			cm.AsDuration(requestKey, &target.Request),
+			cm.AsDuration(idleKey, &target.Idle),
+		); err != nil {
+			return err
+		}
+
+		if target.Request < 0 || target.Idle < 0 {
+			return fmt.Errorf("%s and %s must not be negative", requestKey, idleKey)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRouteTimeoutsWithAnnotations(t *testing.T) {
+	defaults := RouteTimeouts{Request: time.Minute, Idle: 5 * time.Minute}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        RouteTimeouts
+		wantErr     bool
+	}{{
+		name: "no annotations",
+		want: defaults,
+	}, {
+		name:        "request timeout",
+		annotations: map[string]string{RequestTimeoutKey: "30s"},
+		want:        RouteTimeouts{Request: 30 * time.Second, Idle: 5 * time.Minute},
+	}, {
+		name:        "disabled request timeout",
+		annotations: map[string]string{RequestTimeoutKey: "0s", RequestIdleTimeoutKey: "1h"},
+		want:        RouteTimeouts{Idle: time.Hour},
+	}, {
+		name:        "not a duration",
+		annotations: map[string]string{RequestIdleTimeoutKey: "forever"},
+		wantErr:     true,
+	}, {
+		name:        "negative",
+		annotations: map[string]string{RequestTimeoutKey: "-1s"},
+		wantErr:     true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := defaults.WithAnnotations(test.annotations)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("WithAnnotations() = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("WithAnnotations() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -69,6 +69,15 @@ func (in *Kourier) DeepCopyInto(out *Kourier) {
 	}
 	out.Tracing = in.Tracing
 	out.ExternalAuthz = in.ExternalAuthz
+	if in.GatewayFleets != nil {
+		in, out := &in.GatewayFleets, &out.GatewayFleets
+		*out = make(map[string]GatewayFleet, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.RetryPolicy.DeepCopyInto(&out.RetryPolicy)
+	out.RouteTimeouts = in.RouteTimeouts
 	return
 }
 
@@ -81,3 +90,29 @@ func (in *Kourier) DeepCopy() *Kourier {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.RetryOn != nil {
+		in, out := &in.RetryOn, &out.RetryOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetriableStatusCodes != nil {
+		in, out := &in.RetriableStatusCodes, &out.RetriableStatusCodes
+		*out = make([]uint32, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -18,14 +18,19 @@ package ingress
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +51,7 @@ import (
 	netconfig "knative.dev/networking/pkg/config"
 	"knative.dev/networking/pkg/status"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	namespaceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/namespace"
 	podinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/pod"
 	secretfilteredinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret/filtered"
 	serviceinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/service"
@@ -63,9 +69,12 @@ const (
 	gatewayLabelKey   = "app"
 	gatewayLabelValue = "3scale-kourier-gateway"
 
-	nodeID         = "3scale-kourier-gateway"
 	managementPort = 18000
 
+	// xdsTLSEnvPrefix is the prefix of the environment variables configuring TLS on
+	// the management server.
+	xdsTLSEnvPrefix = "KOURIER_XDS_TLS"
+
 	unknownWeightedClusterPrefix = "route: unknown weighted cluster '"
 )
 
@@ -73,6 +82,26 @@ var isKourierIngress = reconciler.AnnotationFilterFunc(
 	v1alpha1ingress.ClassAnnotationKey, config.KourierIngressClassName, false,
 )
 
+type serversKey struct{}
+
+// WithServerShutdown returns a context making NewController track the management
+// server it starts, and a function waiting until that server was shut down. The
+// server drains the connected gateways once the context is done, which outlasts
+// the controllers, so the process must wait for it before exiting.
+func WithServerShutdown(ctx context.Context) (context.Context, func()) {
+	servers := &sync.WaitGroup{}
+	return context.WithValue(ctx, serversKey{}, servers), servers.Wait
+}
+
+// serversFromContext returns the servers tracked through the given context, or an
+// untracked group if there are none.
+func serversFromContext(ctx context.Context) *sync.WaitGroup {
+	if servers, ok := ctx.Value(serversKey{}).(*sync.WaitGroup); ok {
+		return servers
+	}
+	return &sync.WaitGroup{}
+}
+
 func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
 	logger := logging.FromContext(ctx)
 
@@ -81,6 +110,7 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 	endpointSliceInformer := endpointsliceinformer.Get(ctx)
 	serviceInformer := serviceinformer.Get(ctx)
 	podInformer := podinformer.Get(ctx)
+	namespaceInformer := namespaceinformer.Get(ctx)
 	secretInformer := getSecretInformer(ctx)
 	nsConfigmapInformer := nsconfigmapinformer.Get(ctx) // this is filtered to SYSTEM_NAMESPACE
 
@@ -94,7 +124,8 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 		logger.Fatalw("Failed create new caches", zap.Error(err))
 	}
 
-	r := &Reconciler{caches: caches}
+	r := &Reconciler{caches: caches, metrics: newSnapshotMetrics(nil), gatewayRejections: newGatewayRejections()}
+	r.snapshotPublisher = newSnapshotPublisher(r.updateEnvoyConfig)
 
 	impl := v1alpha1ingress.NewImpl(ctx, r, config.KourierIngressClassName, func(impl *controller.Impl) controller.Options {
 		configsToResync := []interface{}{
@@ -128,47 +159,113 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 		}, ingressInformer.Informer())
 	}
 
+	r.resyncNotReady = func() {
+		impl.FilteredGlobalResync(func(obj interface{}) bool {
+			return isKourierIngress(obj) && !obj.(*v1alpha1.Ingress).IsReady()
+		}, ingressInformer.Informer())
+	}
+
+	// handleNACK reacts to a gateway rejecting a pushed snapshot. NACKs look the same
+	// on state-of-the-world and on delta streams.
+	handleNACK := func(detail *rpcstatus.Status) {
+		logger.Warnf("Error pushing snapshot to gateway: code: %v message %s", detail.GetCode(), detail.GetMessage())
+
+		// Report the rejection on the ingresses the rejected resources belong to.
+		rejected := r.caches.IngressesForNACK(detail.GetMessage())
+		revision := r.caches.Revision()
+		for _, key := range rejected {
+			logger.Infof("Gateway rejected the config of ingress %s", key)
+			r.gatewayRejections.record(key, detail.GetMessage(), revision)
+			impl.EnqueueKey(key)
+		}
+
+		// We know we can handle this error without a global resync.
+		if strings.HasPrefix(detail.GetMessage(), unknownWeightedClusterPrefix) {
+			// The error message contains the service name as referenced by the ingress.
+			svc := strings.TrimPrefix(strings.TrimSuffix(detail.GetMessage(), "'"), unknownWeightedClusterPrefix)
+			ns, name, err := cache.SplitMetaNamespaceKey(svc)
+			if err != nil {
+				logger.Errorw("Failed to parse service name from error", zap.Error(err))
+				return
+			}
+
+			logger.Infof("Triggering reconcile for all ingresses referencing %q", svc)
+			impl.Tracker.OnChanged(&corev1.Service{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Service",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: ns,
+					Name:      name,
+				},
+			})
+			return
+		}
+
+		if len(rejected) > 0 {
+			return
+		}
+
+		// Fallback to a global resync of non-ready ingresses for every other error.
+		r.resyncNotReady()
+	}
+
+	r.ackStatusManager = newAckStatusManager(func(key types.NamespacedName) {
+		logger.Debugf("Snapshot acknowledged by all gateways for ingress: %s", key)
+		impl.EnqueueKey(key)
+	})
+	acks := r.ackStatusManager.tracker
+
+	var snapshots snapshotConfig
+	if err := envconfig.Process(snapshotEnvPrefix, &snapshots); err != nil {
+		logger.Fatalw("Failed to read the snapshot persistence config", zap.Error(err))
+	}
+	if snapshots.File != "" {
+		r.persister = newSnapshotPersister(snapshots.File, logger.Named("snapshot-persister"))
+		r.ackStatusManager.ackedCallback = r.persister.acked
+	}
+
+	var xdsTLS envoy.TLSConfig
+	if err := envconfig.Process(xdsTLSEnvPrefix, &xdsTLS); err != nil {
+		logger.Fatalw("Failed to read the xDS TLS config", zap.Error(err))
+	}
+	if err := xdsTLS.Validate(); err != nil {
+		logger.Fatalw("Invalid xDS TLS config", zap.Error(err))
+	}
+
+	var debug debugConfig
+	if err := envconfig.Process(debugEnvPrefix, &debug); err != nil {
+		logger.Fatalw("Failed to read the debug endpoint config", zap.Error(err))
+	}
+	if err := debug.Validate(); err != nil {
+		logger.Fatalw("Invalid debug endpoint config", zap.Error(err))
+	}
+
 	envoyXdsServer := envoy.NewXdsServer(
+		ctx,
 		managementPort,
 		&xds.CallbackFuncs{
-			StreamRequestFunc: func(_ int64, req *v3.DiscoveryRequest) error {
-				if req.GetErrorDetail() == nil {
-					return nil
+			StreamOpenFunc:        acks.OnStreamOpen,
+			StreamClosedFunc:      acks.OnStreamClosed,
+			DeltaStreamOpenFunc:   acks.OnDeltaStreamOpen,
+			DeltaStreamClosedFunc: acks.OnDeltaStreamClosed,
+			StreamRequestFunc: func(id int64, req *v3.DiscoveryRequest) error {
+				if req.GetErrorDetail() != nil {
+					handleNACK(req.GetErrorDetail())
 				}
-				logger.Warnf("Error pushing snapshot to gateway: code: %v message %s", req.GetErrorDetail().GetCode(), req.GetErrorDetail().GetMessage())
-
-				// We know we can handle this error without a global resync.
-				if strings.HasPrefix(req.GetErrorDetail().GetMessage(), unknownWeightedClusterPrefix) {
-					// The error message contains the service name as referenced by the ingress.
-					svc := strings.TrimPrefix(strings.TrimSuffix(req.GetErrorDetail().GetMessage(), "'"), unknownWeightedClusterPrefix)
-					ns, name, err := cache.SplitMetaNamespaceKey(svc)
-					if err != nil {
-						logger.Errorw("Failed to parse service name from error", zap.Error(err))
-						return nil
-					}
-
-					logger.Infof("Triggering reconcile for all ingresses referencing %q", svc)
-					impl.Tracker.OnChanged(&corev1.Service{
-						TypeMeta: metav1.TypeMeta{
-							Kind:       "Service",
-							APIVersion: "v1",
-						},
-						ObjectMeta: metav1.ObjectMeta{
-							Namespace: ns,
-							Name:      name,
-						},
-					})
-					return nil
+				return acks.OnStreamRequest(id, req)
+			},
+			StreamResponseFunc: acks.OnStreamResponse,
+			StreamDeltaRequestFunc: func(id int64, req *v3.DeltaDiscoveryRequest) error {
+				if req.GetErrorDetail() != nil {
+					handleNACK(req.GetErrorDetail())
 				}
-
-				// Fallback to a global resync of non-ready ingresses for every other error.
-				impl.FilteredGlobalResync(func(obj interface{}) bool {
-					return isKourierIngress(obj) && !obj.(*v1alpha1.Ingress).IsReady()
-				}, ingressInformer.Informer())
-
-				return nil
+				return acks.OnStreamDeltaRequest(id, req)
 			},
+			StreamDeltaResponseFunc: acks.OnStreamDeltaResponse,
 		},
+		envoy.WithTLS(&xdsTLS),
 	)
 	r.xdsServer = envoyXdsServer
 
@@ -189,6 +286,12 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 		impl.EnqueueKey(key)
 	})
 
+	r.caches.SetOnRejected(func(key types.NamespacedName, err error) {
+		logger.Warnw("Ingress evicted from the gateway config", zap.Stringer("ingress", key), zap.Error(err))
+		// Reconciling the ingress again reports the rejection in its status.
+		impl.EnqueueKey(key)
+	})
+
 	ingressTranslator := generator.NewIngressTranslator(
 		func(ns, name string) (*corev1.Secret, error) {
 			return secretInformer.Lister().Secrets(ns).Get(name)
@@ -209,16 +312,15 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 		func(ns, name string) (*corev1.Service, error) {
 			return serviceInformer.Lister().Services(ns).Get(name)
 		},
+		func(name string) (*corev1.Namespace, error) {
+			return namespaceInformer.Lister().Get(name)
+		},
 		impl.Tracker)
 	r.ingressTranslator = &ingressTranslator
 
-	// Initialize the Envoy snapshot.
-	snapshot, err := r.caches.ToEnvoySnapshot(ctx)
-	if err != nil {
-		logger.Fatalw("Failed to create snapshot", zap.Error(err))
-	}
-	err = r.xdsServer.SetSnapshot(nodeID, snapshot)
-	if err != nil {
+	// Initialize the Envoy snapshot. It holds no ingresses yet, so the server is
+	// only marked as seeded once runXDSServer primed the config.
+	if err := r.updateEnvoyConfig(ctx); err != nil {
 		logger.Fatalw("Failed to set snapshot", zap.Error(err))
 	}
 
@@ -246,6 +348,14 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 		),
 	))
 
+	// Ingresses select their fleet of gateways through a label on their namespace.
+	namespaceInformer.Informer().AddEventHandler(controller.HandleAll(
+		controller.EnsureTypeMeta(
+			impl.Tracker.OnChanged,
+			corev1.SchemeGroupVersion.WithKind("Namespace"),
+		),
+	))
+
 	viaTracker := controller.EnsureTypeMeta(
 		impl.Tracker.OnChanged,
 		discoveryv1.SchemeGroupVersion.WithKind("EndpointSlice"))
@@ -296,7 +406,15 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 		}),
 	})
 
-	go runXDSServer(ctx, firstSyncFinished, r)
+	servers := serversFromContext(ctx)
+	servers.Add(1)
+	go func() {
+		defer servers.Done()
+		runXDSServer(ctx, firstSyncFinished, r)
+	}()
+	if debug.Port != 0 {
+		go runDebugServer(ctx, debug, r)
+	}
 
 	return impl
 }
@@ -372,19 +490,46 @@ func getInitialConfig(ctx context.Context) (*config.Config, error) {
 	}, nil
 }
 
+// runXDSServer primes the config and serves it to the gateways. It returns once the
+// management server was shut down.
 func runXDSServer(ctx context.Context, firstSyncFinished chan struct{}, r *Reconciler) {
 	logger := logging.FromContext(ctx)
 
+	managementServerDone := make(chan struct{})
+	startManagementServer := func() {
+		go func() {
+			defer close(managementServerDone)
+			runManagementServer(ctx, r)
+		}()
+	}
+
 	syncedCallbacks := []cache.InformerSynced{
 		ingressinformer.Get(ctx).Informer().HasSynced,
 		endpointsliceinformer.Get(ctx).Informer().HasSynced,
 		serviceinformer.Get(ctx).Informer().HasSynced,
 		podinformer.Get(ctx).Informer().HasSynced,
+		namespaceinformer.Get(ctx).Informer().HasSynced,
 		getSecretInformer(ctx).Informer().HasSynced,
 		// this is filtered to SYSTEM_NAMESPACE
 		nsconfigmapinformer.Get(ctx).Informer().HasSynced,
 	}
 
+	// Serve the last snapshots acknowledged by all gateways until the config is
+	// generated from a complete view of the cluster.
+	serving := false
+	if r.persister != nil {
+		if snapshots, ok := r.persister.restore(); ok {
+			logger.Infof("Serving %d persisted snapshots until the config is primed", len(snapshots))
+			if err := r.xdsServer.SetSnapshots(snapshots); err != nil {
+				logger.Errorw("Failed to set persisted snapshots", zap.Error(err))
+			} else {
+				r.xdsServer.MarkSeeded()
+				startManagementServer()
+				serving = true
+			}
+		}
+	}
+
 	// sharedmain panics when this fails so we don't need to handle it here
 	cache.WaitForCacheSync(ctx.Done(), syncedCallbacks...)
 
@@ -398,22 +543,60 @@ func runXDSServer(ctx context.Context, firstSyncFinished chan struct{}, r *Recon
 	for _, ingress := range ingressesToSync {
 		err := generator.UpdateInfoForIngress(ctx, r.caches, ingress, r.ingressTranslator)
 		if err != nil {
-			logger.Fatalw("Failed prewarm ingress", zap.Error(err))
+			// The ingress is reconciled again once the reconciler starts, which reports
+			// the error on it.
+			logger.Errorw("Failed to prewarm ingress, skipping it", zap.String("ingress", ingress.Namespace+"/"+ingress.Name), zap.Error(err))
 		}
 	}
+	if r.persister != nil {
+		r.persister.arm()
+	}
 	// Update the entire batch of ready ingresses at once.
 	if err := r.updateEnvoyConfig(ctx); err != nil {
-		logger.Fatalw("Failed to set initial envoy config", zap.Error(err))
+		if !serving {
+			logger.Fatalw("Failed to set initial envoy config", zap.Error(err))
+		}
+		// Keep serving the persisted snapshots, the next change publishes again.
+		logger.Errorw("Failed to set initial envoy config, serving persisted snapshots", zap.Error(err))
+	} else {
+		r.xdsServer.MarkSeeded()
 	}
+	go r.snapshotPublisher.Run(ctx)
 
 	// Let's start the management server **after** the configuration has been seeded.
-	go func() {
-		logger.Info("Starting Management Server on Port ", managementPort)
-		if err := r.xdsServer.RunManagementServer(); err != nil {
-			logger.Fatalw("Failed to serve XDS Server", zap.Error(err))
-		}
-	}()
+	if !serving {
+		startManagementServer()
+	}
 
 	// Closing this channel will unblock the ingress reconciler
 	close(firstSyncFinished)
+
+	<-managementServerDone
+}
+
+func runManagementServer(ctx context.Context, r *Reconciler) {
+	logger := logging.FromContext(ctx)
+	logger.Info("Starting Management Server on Port ", managementPort)
+	if err := r.xdsServer.RunManagementServer(); err != nil {
+		logger.Fatalw("Failed to serve XDS Server", zap.Error(err))
+	}
+}
+
+func runDebugServer(ctx context.Context, debug debugConfig, r *Reconciler) {
+	logger := logging.FromContext(ctx)
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", debug.Port),
+		Handler:           newDebugHandler(r, debug.TokenFile),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logger.Info("Starting debug endpoint on port ", debug.Port)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Errorw("Failed to serve debug endpoint", zap.Error(err))
+	}
 }
@@ -18,10 +18,20 @@ package ingress
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gotest.tools/v3/assert"
 
 	_ "knative.dev/networking/pkg/client/injection/client/fake"
 	_ "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/ingress/fake"
+	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/namespace/fake"
 	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/pod/fake"
 	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/secret/filtered/fake"
 	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/service/fake"
@@ -36,6 +46,7 @@ import (
 	networkcfg "knative.dev/networking/pkg/config"
 	kubeclient "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
 	rtesting "knative.dev/pkg/reconciler/testing"
 	"knative.dev/pkg/system"
 
@@ -81,6 +92,70 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestServerShutdown(t *testing.T) {
+	ctx, cancel, informers := rtesting.SetupFakeContextWithCancel(t,
+		informerfiltering.GetContextWithFilteringLabelSelector,
+	)
+	defer cancel()
+	ctx, waitForServers := WithServerShutdown(ctx)
+
+	configMaps := []*corev1.ConfigMap{{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      config.ConfigName,
+		},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      networkcfg.ConfigMapName,
+		},
+	}}
+	for _, cm := range configMaps {
+		_, err := kubeclient.Get(ctx).CoreV1().ConfigMaps(system.Namespace()).Create(ctx, cm, metav1.CreateOptions{})
+		assert.NilError(t, err)
+	}
+
+	NewController(ctx, configmap.NewStaticWatcher(configMaps...))
+	assert.NilError(t, controller.StartInformers(ctx.Done(), informers...))
+
+	// A gateway connects once the config is primed.
+	conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", managementPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NilError(t, err)
+	defer conn.Close()
+	streamCtx, closeStream := context.WithCancel(context.Background())
+	defer closeStream()
+	stream, err := discovery.NewAggregatedDiscoveryServiceClient(conn).StreamAggregatedResources(streamCtx, grpc.WaitForReady(true))
+	assert.NilError(t, err)
+	assert.NilError(t, stream.Send(&discovery.DiscoveryRequest{
+		Node:    &core.Node{Id: config.DefaultGatewayFleet},
+		TypeUrl: resource.ClusterType,
+	}))
+	_, err = stream.Recv()
+	assert.NilError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		waitForServers()
+		close(stopped)
+	}()
+
+	// The management server keeps serving the connected gateway for the grace
+	// period after the controller is stopped.
+	cancel()
+	select {
+	case <-stopped:
+		t.Fatal("Servers stopped while a gateway was still connected")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	closeStream()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Servers did not stop after the gateway disconnected")
+	}
+}
+
 func TestGetInitialConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/net-kourier/pkg/generator"
+)
+
+// debugEnvPrefix is the prefix of the environment variables configuring the debug
+// endpoint.
+const debugEnvPrefix = "KOURIER_DEBUG"
+
+// debugConfig configures the debug endpoint. It is read from the environment with
+// the KOURIER_DEBUG prefix.
+type debugConfig struct {
+	// Port is the port the debug endpoint is served on. 0 disables it.
+	Port uint `envconfig:"PORT"`
+
+	// TokenFile holds the bearer token clients must present. It's read again on
+	// every request, so that a rotated token is picked up without a restart.
+	TokenFile string `envconfig:"TOKEN_FILE"`
+}
+
+// Validate makes sure the debug endpoint is never served without authentication.
+func (c *debugConfig) Validate() error {
+	if c.Port != 0 && c.TokenFile == "" {
+		return errors.New("a token file must be configured to serve the debug endpoint")
+	}
+	return nil
+}
+
+// newDebugHandler returns the handler of the debug endpoint, which exposes the
+// snapshots served to the gateways, the translation of single ingresses and the
+// acknowledgement state of the connected gateways.
+func newDebugHandler(r *Reconciler, tokenFile string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/snapshots", func(w http.ResponseWriter, _ *http.Request) {
+		dumps := make(map[string]*generator.SnapshotDump)
+		for nodeID, snapshot := range r.xdsServer.Snapshots() {
+			dump, err := generator.DumpSnapshot(snapshot)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to dump snapshot of %s: %v", nodeID, err), http.StatusInternalServerError)
+				return
+			}
+			dumps[nodeID] = dump
+		}
+		writeJSON(w, dumps)
+	})
+	mux.HandleFunc("GET /debug/ingresses/{namespace}/{name}", func(w http.ResponseWriter, req *http.Request) {
+		key := types.NamespacedName{Namespace: req.PathValue("namespace"), Name: req.PathValue("name")}
+		dump, ok, err := r.caches.DumpIngress(key)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to dump ingress: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, fmt.Sprintf("ingress %s not found", key), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, dump)
+	})
+	mux.HandleFunc("GET /debug/gateways", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, r.ackStatusManager.tracker.Gateways())
+	})
+
+	return withBearerToken(tokenFile, mux)
+}
+
+// withBearerToken only passes on requests carrying the token in the given file.
+func withBearerToken(tokenFile string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			http.Error(w, "failed to read token", http.StatusInternalServerError)
+			return
+		}
+		want := strings.TrimSpace(string(token))
+		got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || want == "" || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	envoy "knative.dev/net-kourier/pkg/envoy/server"
+	"knative.dev/net-kourier/pkg/generator"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	ctx = config.ToContext(ctx, config.FromContextOrDefaults(ctx))
+
+	caches, err := generator.NewCaches(ctx, fake.NewSimpleClientset())
+	assert.NilError(t, err)
+	r := &Reconciler{
+		caches:           caches,
+		xdsServer:        envoy.NewXdsServer(ctx, 0, &xds.CallbackFuncs{}),
+		ackStatusManager: newAckStatusManager(func(types.NamespacedName) {}),
+		metrics:          newSnapshotMetrics(nil),
+	}
+	assert.NilError(t, r.updateEnvoyConfig(ctx))
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NilError(t, os.WriteFile(tokenFile, []byte("secret-token\n"), 0o600))
+	server := httptest.NewServer(newDebugHandler(r, tokenFile))
+	t.Cleanup(server.Close)
+
+	get := func(path, token string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		assert.NilError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NilError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		assert.Equal(t, get("/debug/snapshots", "").StatusCode, http.StatusUnauthorized)
+		assert.Equal(t, get("/debug/snapshots", "wrong-token").StatusCode, http.StatusUnauthorized)
+	})
+
+	t.Run("snapshots", func(t *testing.T) {
+		resp := get("/debug/snapshots", "secret-token")
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+		var dumps map[string]generator.SnapshotDump
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&dumps))
+		dump, ok := dumps[config.DefaultGatewayFleet]
+		assert.Assert(t, ok, "no snapshot for the default fleet: %v", dumps)
+		assert.Assert(t, dump.Version != "")
+		assert.Assert(t, len(dump.Listeners) > 0)
+		assert.Assert(t, len(dump.Routes) > 0)
+	})
+
+	t.Run("unknown ingress", func(t *testing.T) {
+		assert.Equal(t, get("/debug/ingresses/ns/missing", "secret-token").StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("gateways", func(t *testing.T) {
+		resp := get("/debug/gateways", "secret-token")
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+		var gateways []envoy.GatewayStatus
+		assert.NilError(t, json.NewDecoder(resp.Body).Decode(&gateways))
+		assert.Equal(t, len(gateways), 0)
+	})
+}
+
+func TestDebugConfigValidate(t *testing.T) {
+	assert.NilError(t, (&debugConfig{}).Validate())
+	assert.NilError(t, (&debugConfig{Port: 8081, TokenFile: "token"}).Validate())
+	assert.ErrorContains(t, (&debugConfig{Port: 8081}).Validate(), "token file must be configured")
+}
@@ -20,7 +20,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	envoy "knative.dev/net-kourier/pkg/envoy/server"
 	"knative.dev/net-kourier/pkg/generator"
@@ -34,19 +38,37 @@ import (
 )
 
 const (
-	conflictReason      = "DomainConflict"
-	notReconciledReason = "ReconcileIngressFailed"
+	conflictReason        = "DomainConflict"
+	invalidConfigReason   = "InvalidConfiguration"
+	gatewayRejectedReason = "GatewayRejected"
+	notReconciledReason   = "ReconcileIngressFailed"
+	publishFailedReason   = "PublishSnapshotFailed"
 )
 
 type Reconciler struct {
 	xdsServer         *envoy.XdsServer
 	caches            *generator.Caches
+	snapshotPublisher *snapshotPublisher
 	statusManager     *status.Prober
+	ackStatusManager  *ackStatusManager
 	ingressTranslator *generator.IngressTranslator
+	metrics           *snapshotMetrics
+
+	// gatewayRejections holds the ingresses whose config was rejected by a gateway.
+	gatewayRejections *gatewayRejections
+	// persister persists the snapshots acknowledged by all gateways. It's nil if
+	// persistence is disabled.
+	persister *snapshotPersister
+
+	// publishFailure holds the error of the last publication, until one succeeds.
+	publishFailure publishFailure
 
 	// resyncConflicts triggers a filtered global resync to reenqueue all ingresses in
 	// a "Conflict" state.
 	resyncConflicts func()
+	// resyncNotReady triggers a filtered global resync to reenqueue all ingresses
+	// that aren't ready.
+	resyncNotReady func()
 }
 
 type leaderAwareReconciler interface {
@@ -90,24 +112,50 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ing *v1alpha1.Ingress) r
 		logging.FromContext(ctx).Info(err.Error())
 		ing.Status.MarkLoadBalancerFailed(conflictReason, "Ingress rejected: "+err.Error())
 		return nil
+	} else if errors.Is(err, generator.ErrInvalidConfig) {
+		// The config generated for the ingress would be rejected by the gateways, so
+		// it's not served until the ingress changes.
+		logging.FromContext(ctx).Info(err.Error())
+		ing.Status.MarkLoadBalancerFailed(invalidConfigReason, "Ingress rejected: "+err.Error())
+		return nil
 	} else if err != nil {
 		ing.Status.MarkIngressNotReady(notReconciledReason, err.Error())
 		return fmt.Errorf("failed to update ingress: %w", err)
 	}
 
+	// Any snapshot generated from now on contains the ingress.
+	revision := r.caches.Revision()
+
 	ing.Status.MarkNetworkConfigured()
-	if !ing.IsReady() || !isExpectedLoadBalancer(ing) {
-		ready, err := r.statusManager.IsReady(ctx, before)
+	key := types.NamespacedName{Namespace: ing.Namespace, Name: ing.Name}
+	fleet := r.caches.GatewayFleet(key)
+
+	if rejection, isNew, ok := r.gatewayRejections.get(key, ing.Generation); ok {
+		// The rejection is resolved once the gateways acknowledged a later snapshot.
+		if !r.ackStatusManager.IsReady(key, fleet, rejection.revision) {
+			if isNew {
+				controller.GetEventRecorder(ctx).Event(ing, corev1.EventTypeWarning, gatewayRejectedReason, rejection.message)
+			}
+			ing.Status.MarkLoadBalancerFailed(gatewayRejectedReason, "Gateway rejected config: "+rejection.message)
+			return nil
+		}
+		r.gatewayRejections.clear(key)
+	}
+
+	if !ing.IsReady() || !isExpectedLoadBalancer(ctx, ing, fleet) {
+		ready, err := r.isLoadBalancerReady(ctx, before, fleet, revision)
 		if err != nil {
 			return fmt.Errorf("failed to probe Ingress: %w", err)
 		}
 		if ready {
-			external, internal := config.ServiceHostnames()
+			external, internal := config.FromContext(ctx).Kourier.ServiceHostnames(fleet)
 
 			ing.Status.MarkLoadBalancerReady(
 				[]v1alpha1.LoadBalancerIngressStatus{{DomainInternal: external}},
 				[]v1alpha1.LoadBalancerIngressStatus{{DomainInternal: internal}},
 			)
+		} else if err := r.publishFailure.get(revision); err != nil {
+			ing.Status.MarkIngressNotReady(publishFailedReason, "Failed to publish config: "+err.Error())
 		} else {
 			ing.Status.MarkLoadBalancerNotReady()
 		}
@@ -116,9 +164,20 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ing *v1alpha1.Ingress) r
 	return nil
 }
 
-// isExpectedLoadBalancer verifies if expected Loadbalancer is set in status field.
-func isExpectedLoadBalancer(ing *v1alpha1.Ingress) bool {
-	external, internal := config.ServiceHostnames()
+// isLoadBalancerReady returns whether the gateways serve the given ingress, either
+// by probing them or by the gateways of its fleet having acknowledged the given
+// revision of the caches.
+func (r *Reconciler) isLoadBalancerReady(ctx context.Context, ing *v1alpha1.Ingress, fleet string, revision uint64) (bool, error) {
+	if config.FromContext(ctx).Kourier.EnableReadinessProbing {
+		return r.statusManager.IsReady(ctx, ing)
+	}
+	return r.ackStatusManager.IsReady(types.NamespacedName{Namespace: ing.Namespace, Name: ing.Name}, fleet, revision), nil
+}
+
+// isExpectedLoadBalancer verifies if the Loadbalancer of the given fleet is set in
+// status field.
+func isExpectedLoadBalancer(ctx context.Context, ing *v1alpha1.Ingress, fleet string) bool {
+	external, internal := config.FromContext(ctx).Kourier.ServiceHostnames(fleet)
 	if ing.Status.PublicLoadBalancer == nil || len(ing.Status.PublicLoadBalancer.Ingress) < 1 ||
 		ing.Status.PublicLoadBalancer.Ingress[0].DomainInternal != external {
 		return false
@@ -137,6 +196,9 @@ func (r *Reconciler) ObserveKind(ctx context.Context, ing *v1alpha1.Ingress) rec
 		// If we had an error due to a duplicated domain, just abort.
 		logging.FromContext(ctx).Info(err.Error())
 		return nil
+	} else if errors.Is(err, generator.ErrInvalidConfig) {
+		logging.FromContext(ctx).Info(err.Error())
+		return nil
 	} else if err != nil {
 		return fmt.Errorf("failed to update ingress: %w", err)
 	}
@@ -149,14 +211,13 @@ func (r *Reconciler) ObserveDeletion(ctx context.Context, key types.NamespacedNa
 	logger.Infof("Ingress deleted, updating config")
 
 	r.statusManager.CancelIngressProbingByKey(key)
+	r.ackStatusManager.CancelIngress(key)
+	r.gatewayRejections.clear(key)
 
 	if err := r.caches.DeleteIngressInfo(ctx, key.Name, key.Namespace); err != nil {
 		return err
 	}
-
-	if err := r.updateEnvoyConfig(ctx); err != nil {
-		return fmt.Errorf("failed updating envoy config: %w", err)
-	}
+	r.snapshotPublisher.Enqueue(ctx)
 
 	r.resyncConflicts()
 	return nil
@@ -176,17 +237,44 @@ func (r *Reconciler) updateIngress(ctx context.Context, ingress *v1alpha1.Ingres
 		return err
 	}
 
-	return r.updateEnvoyConfig(ctx)
+	// Publishing is asynchronous to coalesce bursts of changes. The ingress becomes
+	// ready once the gateways acknowledged a snapshot containing the change.
+	r.snapshotPublisher.Enqueue(ctx)
+	return nil
 }
 
 func (r *Reconciler) updateEnvoyConfig(ctx context.Context) error {
 	logger := logging.FromContext(ctx)
 	logger.Debugf("Preparing Envoy Snapshot")
 
-	newSnapshot, err := r.caches.ToEnvoySnapshot(ctx)
+	start := time.Now()
+	snapshots, revision, err := r.caches.ToEnvoySnapshots(ctx)
 	if err != nil {
+		r.xdsServer.SnapshotFailed()
+		r.publishFailed(revision, err)
+		return err
+	}
+	r.metrics.recordBuild(ctx, time.Since(start), snapshots)
+
+	if r.persister != nil {
+		r.persister.published(revision, snapshots)
+	}
+	r.ackStatusManager.tracker.Published(revision, slices.Collect(maps.Values(snapshots))...)
+
+	previous := r.xdsServer.Snapshots()
+	if err := r.xdsServer.SetSnapshots(snapshots); err != nil {
+		r.publishFailed(revision, err)
 		return err
 	}
+	r.publishFailure.clear()
+	generator.LogSnapshotDiffs(logger, previous, snapshots)
+	return nil
+}
 
-	return r.xdsServer.SetSnapshot(nodeID, newSnapshot)
+// publishFailed reports a failure to publish the given revision of the caches on
+// the ingresses that aren't ready, the first time it happens.
+func (r *Reconciler) publishFailed(revision uint64, err error) {
+	if r.publishFailure.record(revision, err) {
+		r.resyncNotReady()
+	}
 }
@@ -18,6 +18,8 @@ package ingress
 
 import (
 	"context"
+	"errors"
+	"math"
 	"testing"
 	"time"
 
@@ -114,6 +116,71 @@ func TestReconcile(t *testing.T) {
 				i.Status.MarkLoadBalancerNotReady()
 			}),
 		}},
+	}, {
+		Name: "failed publication marks the ingress not ready",
+		Key:  "ns/name",
+		Objects: []runtime.Object{
+			ing("name", "ns", withBasicSpec, withKourier),
+		},
+		OtherTestData: map[string]interface{}{
+			publishFailureKey: errors.New("snapshot is inconsistent"),
+		},
+		WantEvents: []string{
+			rtesting.Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "name"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{{
+			Name:  "name",
+			Patch: []byte(`{"metadata":{"finalizers":["ingresses.networking.internal.knative.dev"],"resourceVersion":""}}`),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ing("name", "ns", withBasicSpec, withKourier, func(i *v1alpha1.Ingress) {
+				i.Status.InitializeConditions()
+				i.Status.MarkNetworkConfigured()
+				i.Status.MarkIngressNotReady(publishFailedReason, "Failed to publish config: snapshot is inconsistent")
+			}),
+		}},
+	}, {
+		Name: "invalid annotation marks the ingress failed",
+		Key:  "ns/name",
+		Objects: []runtime.Object{
+			ing("name", "ns", withBasicSpec, withKourier, withInvalidHeaderMatch),
+		},
+		WantEvents: []string{
+			rtesting.Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "name"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{{
+			Name:  "name",
+			Patch: []byte(`{"metadata":{"finalizers":["ingresses.networking.internal.knative.dev"],"resourceVersion":""}}`),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ing("name", "ns", withBasicSpec, withKourier, withInvalidHeaderMatch, func(i *v1alpha1.Ingress) {
+				i.Status.InitializeConditions()
+				i.Status.MarkLoadBalancerFailed(invalidConfigReason, "Ingress rejected: failed to translate ingress: "+
+					"ingress generates invalid gateway config: annotation kourier.knative.dev/header-match: "+
+					`path "/": header "x-user" must have exactly one of exact, prefix, suffix, contains, regex or present`)
+			}),
+		}},
+	}, {
+		Name: "header match without value marks the ingress failed",
+		Key:  "ns/name",
+		Objects: []runtime.Object{
+			ing("name", "ns", withBasicSpec, withKourier, withValuelessHeaderMatch),
+		},
+		WantEvents: []string{
+			rtesting.Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "name"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{{
+			Name:  "name",
+			Patch: []byte(`{"metadata":{"finalizers":["ingresses.networking.internal.knative.dev"],"resourceVersion":""}}`),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: ing("name", "ns", withBasicSpec, withKourier, withValuelessHeaderMatch, func(i *v1alpha1.Ingress) {
+				i.Status.InitializeConditions()
+				i.Status.MarkLoadBalancerFailed(invalidConfigReason, "Ingress rejected: failed to translate ingress: "+
+					`ingress generates invalid gateway config: path "/": header "x-user" must have an exact value, `+
+					"use the annotation kourier.knative.dev/header-match to match its presence")
+			}),
+		}},
 	}}
 
 	table.Test(t, func(t *testing.T, tr *rtesting.TableRow) (
@@ -141,6 +208,9 @@ func TestReconcile(t *testing.T) {
 			func(ns, name string) (*corev1.Service, error) {
 				return ls.GetK8sServiceLister().Services(ns).Get(name)
 			},
+			func(name string) (*corev1.Namespace, error) {
+				return ls.GetNamespaceLister().Get(name)
+			},
 			&fakeTracker{},
 		)
 
@@ -163,14 +233,23 @@ func TestReconcile(t *testing.T) {
 		c, _ := generator.NewCaches(ctx, kubeclient)
 
 		r := &Reconciler{
-			xdsServer:         server.NewXdsServer(18000, &xds.CallbackFuncs{}),
+			xdsServer:         server.NewXdsServer(ctx, 18000, &xds.CallbackFuncs{}),
 			caches:            c,
 			ingressTranslator: &it,
 			resyncConflicts:   func() {},
+			resyncNotReady:    func() {},
+			metrics:           newSnapshotMetrics(nil),
+			gatewayRejections: newGatewayRejections(),
 			statusManager: status.NewProber(
 				nil, NewProbeTargetLister(logging.FromContext(ctx), ls.GetEndpointSlicesLister()), nil,
 			),
 		}
+		r.snapshotPublisher = newSnapshotPublisher(r.updateEnvoyConfig)
+		if err, ok := tr.OtherTestData[publishFailureKey].(error); ok {
+			// Publishing any revision of the caches failed.
+			r.publishFailure.record(math.MaxUint64, err)
+		}
+		r.ackStatusManager = newAckStatusManager(func(types.NamespacedName) {})
 
 		rr := ingressreconciler.NewReconciler(ctx,
 			logging.FromContext(ctx), fakenetworkingclient.Get(ctx),
@@ -196,6 +275,10 @@ func TestReconcile(t *testing.T) {
 	})
 }
 
+// publishFailureKey is the key of the OtherTestData of TestReconcile holding the
+// error publishing snapshots fails with.
+const publishFailureKey = "publishFailure"
+
 type testConfigStore struct {
 	config *config.Config
 }
@@ -226,3 +309,13 @@ func (t *fakeTracker) GetObservers(_ interface{}) []types.NamespacedName {
 	return []types.NamespacedName{}
 }
 func (t *fakeTracker) OnDeletedObserver(_ interface{}) {}
+
+func withValuelessHeaderMatch(i *v1alpha1.Ingress) {
+	i.Spec.Rules[0].HTTP.Paths[0].Headers = map[string]v1alpha1.HeaderMatch{"x-user": {}}
+}
+
+func withInvalidHeaderMatch(i *v1alpha1.Ingress) {
+	withAnnotation(map[string]string{
+		config.HeaderMatchKey: `{"/": {"x-user": {"invert": true}}}`,
+	})(i)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	envoy "knative.dev/net-kourier/pkg/envoy/server"
+	"knative.dev/pkg/observability/attributekey"
+)
+
+const scopeName = "knative.dev/net-kourier/pkg/reconciler/ingress"
+
+// GatewayFleetAttr is the fleet of gateways a snapshot is served to.
+var GatewayFleetAttr = attributekey.String("kourier.gateway.fleet")
+
+// snapshotResourceTypes are the resource types whose sizes are recorded.
+var snapshotResourceTypes = []string{
+	resource.ListenerType,
+	resource.RouteType,
+	resource.ClusterType,
+	resource.EndpointType,
+	resource.SecretType,
+}
+
+type snapshotMetrics struct {
+	buildDuration metric.Float64Histogram
+	resources     metric.Int64Gauge
+}
+
+func newSnapshotMetrics(provider metric.MeterProvider) *snapshotMetrics {
+	var (
+		m   snapshotMetrics
+		err error
+	)
+
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+
+	meter := provider.Meter(scopeName)
+
+	m.buildDuration, err = meter.Float64Histogram(
+		"kourier.xds.snapshot.build.duration",
+		metric.WithDescription("The duration of building the snapshots of all gateway fleets."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	m.resources, err = meter.Int64Gauge(
+		"kourier.xds.snapshot.resources",
+		metric.WithDescription("The number of resources of a type in the latest snapshot of a gateway fleet."),
+		metric.WithUnit("{resource}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return &m
+}
+
+func (m *snapshotMetrics) recordBuild(ctx context.Context, d time.Duration, snapshots map[string]*cache.Snapshot) {
+	m.buildDuration.Record(ctx, d.Seconds())
+	for fleet, snapshot := range snapshots {
+		for _, typeURL := range snapshotResourceTypes {
+			m.resources.Record(ctx, int64(len(snapshot.GetResources(typeURL))), metric.WithAttributes(
+				GatewayFleetAttr.With(fleet),
+				envoy.TypeURLAttr.With(typeURL),
+			))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	envoy "knative.dev/net-kourier/pkg/envoy/server"
+	"knative.dev/net-kourier/pkg/generator"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestSnapshotMetrics(t *testing.T) {
+	ctx := logtesting.TestContextWithLogger(t)
+	ctx = config.ToContext(ctx, config.FromContextOrDefaults(ctx))
+
+	reader := sdkmetric.NewManualReader()
+	caches, err := generator.NewCaches(ctx, fake.NewSimpleClientset())
+	assert.NilError(t, err)
+	r := &Reconciler{
+		caches:           caches,
+		xdsServer:        envoy.NewXdsServer(ctx, 0, &xds.CallbackFuncs{}),
+		ackStatusManager: newAckStatusManager(func(types.NamespacedName) {}),
+		metrics:          newSnapshotMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	}
+	assert.NilError(t, r.updateEnvoyConfig(ctx))
+
+	var rm metricdata.ResourceMetrics
+	assert.NilError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	duration := metrics["kourier.xds.snapshot.build.duration"].(metricdata.Histogram[float64])
+	assert.Equal(t, duration.DataPoints[0].Count, uint64(1))
+
+	snapshot, err := caches.ToEnvoySnapshot(ctx)
+	assert.NilError(t, err)
+	resources := metrics["kourier.xds.snapshot.resources"].(metricdata.Gauge[int64])
+	assert.Equal(t, len(resources.DataPoints), 5)
+	for _, point := range resources.DataPoints {
+		fleet, _ := point.Attributes.Value(attribute.Key(GatewayFleetAttr))
+		assert.Equal(t, fleet.AsString(), config.DefaultGatewayFleet)
+		if typeURL, _ := point.Attributes.Value(attribute.Key(envoy.TypeURLAttr)); typeURL.AsString() == resource.ListenerType {
+			assert.Equal(t, point.Value, int64(len(snapshot.GetResources(resource.ListenerType))))
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"go.uber.org/zap"
+	envoy "knative.dev/net-kourier/pkg/envoy/server"
+)
+
+// snapshotEnvPrefix is the prefix of the environment variables configuring the
+// persistence of snapshots.
+const snapshotEnvPrefix = "KOURIER_SNAPSHOT"
+
+// snapshotConfig configures the persistence of snapshots. It is read from the
+// environment with the KOURIER_SNAPSHOT prefix.
+type snapshotConfig struct {
+	// File is where the last snapshots acknowledged by all gateways are persisted,
+	// to serve them right away when the controller restarts. It must be on a volume
+	// outliving the controller. Empty disables persistence.
+	File string `envconfig:"FILE"`
+}
+
+// snapshotPersister persists the last snapshots acknowledged by all gateways.
+type snapshotPersister struct {
+	path   string
+	logger *zap.SugaredLogger
+
+	mu sync.Mutex
+	// armed is false until the snapshots are generated from a complete view of the
+	// cluster, so that the snapshots generated at startup don't replace the
+	// persisted ones.
+	armed bool
+	// pending are the latest published snapshots, not persisted yet.
+	pending  map[string]*cache.Snapshot
+	revision uint64
+}
+
+func newSnapshotPersister(path string, logger *zap.SugaredLogger) *snapshotPersister {
+	return &snapshotPersister{path: path, logger: logger}
+}
+
+// restore returns the persisted snapshots. It returns false if there are none.
+func (p *snapshotPersister) restore() (map[string]*cache.Snapshot, bool) {
+	snapshots, err := envoy.ReadSnapshotsFile(p.path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			p.logger.Warnw("Failed to restore persisted snapshots", zap.Error(err))
+		}
+		return nil, false
+	}
+	return snapshots, true
+}
+
+// arm starts accepting published snapshots.
+func (p *snapshotPersister) arm() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.armed = true
+}
+
+// published records the snapshots published as the given revision of the caches.
+// They're persisted once all gateways acknowledged them.
+func (p *snapshotPersister) published(revision uint64, snapshots map[string]*cache.Snapshot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.armed {
+		p.pending, p.revision = snapshots, revision
+	}
+}
+
+// acked persists the pending snapshots if all gateways acknowledged them.
+func (p *snapshotPersister) acked(revision uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending == nil || revision < p.revision {
+		return
+	}
+	if err := envoy.WriteSnapshotsFile(p.path, p.pending); err != nil {
+		p.logger.Errorw("Failed to persist snapshots", zap.Error(err))
+		return
+	}
+	p.pending = nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"path/filepath"
+	"testing"
+
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"gotest.tools/v3/assert"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestSnapshotPersister(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.json")
+	persister := newSnapshotPersister(path, logtesting.TestLogger(t))
+
+	_, ok := persister.restore()
+	assert.Assert(t, !ok)
+
+	snapshot := func(version string) map[string]*cache.Snapshot {
+		s, err := cache.NewSnapshot(version, map[resource.Type][]cachetypes.Resource{resource.ClusterType: nil})
+		assert.NilError(t, err)
+		return map[string]*cache.Snapshot{"gateway": s}
+	}
+
+	// Snapshots published before arming are not persisted.
+	persister.published(1, snapshot("startup"))
+	persister.acked(1)
+	_, ok = persister.restore()
+	assert.Assert(t, !ok)
+
+	persister.arm()
+	persister.published(2, snapshot("v2"))
+	// Not acknowledged yet.
+	persister.acked(1)
+	_, ok = persister.restore()
+	assert.Assert(t, !ok)
+
+	persister.acked(2)
+	restored, ok := persister.restore()
+	assert.Assert(t, ok)
+	assert.Equal(t, restored["gateway"].GetVersion(resource.ClusterType), "v2")
+
+	// Snapshots are only replaced once acknowledged.
+	persister.published(3, snapshot("v3"))
+	persister.acked(2)
+	restored, ok = persister.restore()
+	assert.Assert(t, ok)
+	assert.Equal(t, restored["gateway"].GetVersion(resource.ClusterType), "v2")
+}
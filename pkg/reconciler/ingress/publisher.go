@@ -0,0 +1,178 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/pkg/logging"
+)
+
+// publishRetryDelay is how long to wait before retrying a failed publication, if
+// the configuration doesn't ask for waiting longer already.
+const publishRetryDelay = time.Second
+
+// snapshotPublisher coalesces bursts of cache changes into a single snapshot.
+//
+// After a change is enqueued, publication waits until no further change has been
+// enqueued for SnapshotMinInterval, but no longer than SnapshotMaxDelay. Changes
+// enqueued while a snapshot is being built are picked up by the next one, so no
+// change is ever lost: an ingress only becomes ready once the gateways
+// acknowledged a snapshot built from a revision of the caches containing it.
+// Failed publications are retried until one succeeds.
+type snapshotPublisher struct {
+	publish func(context.Context) error
+	trigger chan struct{}
+
+	mu sync.Mutex
+	// ctx is the context of the latest enqueued change. It carries the config the
+	// snapshot is to be built with.
+	ctx context.Context
+}
+
+func newSnapshotPublisher(publish func(context.Context) error) *snapshotPublisher {
+	return &snapshotPublisher{
+		publish: publish,
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// Enqueue requests a snapshot to be published with the config carried by ctx. It
+// never blocks.
+func (p *snapshotPublisher) Enqueue(ctx context.Context) {
+	p.mu.Lock()
+	p.ctx = context.WithoutCancel(ctx)
+	p.mu.Unlock()
+
+	select {
+	case p.trigger <- struct{}{}:
+	default:
+		// A publication is already pending and will include this change.
+	}
+}
+
+// Run publishes the enqueued changes until ctx is done.
+func (p *snapshotPublisher) Run(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.trigger:
+		}
+
+		minInterval, maxDelay := p.intervals()
+		if !p.coalesce(ctx, minInterval, maxDelay) {
+			return
+		}
+
+		if err := p.publish(p.latestContext()); err != nil {
+			logger.Errorw("Failed to publish snapshot, retrying", zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(max(maxDelay, publishRetryDelay)):
+			}
+			select {
+			case p.trigger <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// coalesce waits for further changes to settle as described on snapshotPublisher.
+// It returns false if ctx is done in the meantime.
+func (p *snapshotPublisher) coalesce(ctx context.Context, minInterval, maxDelay time.Duration) bool {
+	if minInterval <= 0 {
+		return ctx.Err() == nil
+	}
+
+	quiet := time.NewTimer(minInterval)
+	defer quiet.Stop()
+	deadline := time.NewTimer(max(maxDelay, minInterval))
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-quiet.C:
+			return true
+		case <-deadline.C:
+			return true
+		case <-p.trigger:
+			quiet.Reset(minInterval)
+		}
+	}
+}
+
+func (p *snapshotPublisher) latestContext() context.Context {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ctx
+}
+
+func (p *snapshotPublisher) intervals() (time.Duration, time.Duration) {
+	cfg := config.FromContextOrDefaults(p.latestContext())
+	return cfg.Kourier.SnapshotMinInterval, cfg.Kourier.SnapshotMaxDelay
+}
+
+// publishFailure remembers the last failed publication until one succeeds, so that
+// the ingresses it contained report it in their status.
+type publishFailure struct {
+	mu  sync.Mutex
+	err error
+	// revision is the revision of the caches the failed snapshot was built from.
+	revision uint64
+}
+
+// record remembers that publishing the given revision of the caches failed. It
+// returns whether the failure is new, rather than a retry failing the same way.
+func (f *publishFailure) record(revision uint64, err error) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	isNew := f.err == nil || f.err.Error() != err.Error()
+	f.err, f.revision = err, revision
+	return isNew
+}
+
+// clear forgets the failure, once a publication succeeded.
+func (f *publishFailure) clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = nil
+}
+
+// get returns the error publishing the given revision of the caches failed with,
+// if it did.
+func (f *publishFailure) get(revision uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err == nil || f.revision < revision {
+		return nil
+	}
+	return f.err
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestSnapshotPublisher(t *testing.T) {
+	tests := []struct {
+		name        string
+		minInterval time.Duration
+		maxDelay    time.Duration
+		enqueues    int
+		every       time.Duration
+		wantMax     int
+	}{{
+		name:        "burst is coalesced",
+		minInterval: 100 * time.Millisecond,
+		enqueues:    50,
+		wantMax:     1,
+	}, {
+		name:        "steady stream is published every max delay",
+		minInterval: 50 * time.Millisecond,
+		maxDelay:    200 * time.Millisecond,
+		enqueues:    50,
+		every:       10 * time.Millisecond,
+		wantMax:     4,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(logtesting.TestContextWithLogger(t))
+			defer cancel()
+
+			cfg := config.FromContextOrDefaults(ctx).DeepCopy()
+			cfg.Kourier.SnapshotMinInterval = test.minInterval
+			cfg.Kourier.SnapshotMaxDelay = test.maxDelay
+			ctx = config.ToContext(ctx, cfg)
+
+			published := make(chan struct{}, 100)
+			p := newSnapshotPublisher(func(context.Context) error {
+				published <- struct{}{}
+				return nil
+			})
+			go p.Run(ctx)
+
+			for range test.enqueues {
+				p.Enqueue(ctx)
+				time.Sleep(test.every)
+			}
+
+			// The last change must always land.
+			select {
+			case <-published:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Snapshot was never published")
+			}
+			time.Sleep(2 * max(test.minInterval, test.maxDelay))
+
+			got := 1 + len(published)
+			assert.Assert(t, got <= test.wantMax, "published %d snapshots, want at most %d", got, test.wantMax)
+		})
+	}
+}
+
+func TestSnapshotPublisherUsesLatestConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(logtesting.TestContextWithLogger(t))
+	defer cancel()
+
+	published := make(chan string, 1)
+	p := newSnapshotPublisher(func(ctx context.Context) error {
+		published <- config.FromContext(ctx).Kourier.CertsSecretName
+		return nil
+	})
+
+	for _, name := range []string{"first", "second"} {
+		cfg := config.FromContextOrDefaults(ctx).DeepCopy()
+		cfg.Kourier.CertsSecretName = name
+		p.Enqueue(config.ToContext(ctx, cfg))
+	}
+	go p.Run(ctx)
+
+	assert.Equal(t, <-published, "second")
+}
+
+func TestSnapshotPublisherRetriesOnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(logtesting.TestContextWithLogger(t))
+	defer cancel()
+	ctx = config.ToContext(ctx, config.FromContextOrDefaults(ctx))
+
+	var failed atomic.Bool
+	attempts := make(chan struct{}, 2)
+	p := newSnapshotPublisher(func(context.Context) error {
+		attempts <- struct{}{}
+		if !failed.Swap(true) {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	go p.Run(ctx)
+	p.Enqueue(ctx)
+
+	for range 2 {
+		select {
+		case <-attempts:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Snapshot publication was not retried")
+		}
+	}
+}
+
+func TestPublishFailure(t *testing.T) {
+	var f publishFailure
+	assert.NilError(t, f.get(1))
+
+	assert.Assert(t, f.record(2, errors.New("boom")))
+	// Retries failing the same way aren't new failures.
+	assert.Assert(t, !f.record(3, errors.New("boom")))
+	assert.ErrorContains(t, f.get(3), "boom")
+	// Later revisions weren't published yet.
+	assert.NilError(t, f.get(4))
+
+	f.clear()
+	assert.NilError(t, f.get(3))
+	assert.Assert(t, f.record(5, errors.New("boom")))
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// gatewayRejections remembers the ingresses whose config was rejected by a
+// gateway, until the gateways accept a later snapshot or the ingress changes.
+type gatewayRejections struct {
+	mu         sync.Mutex
+	rejections map[types.NamespacedName]*gatewayRejection
+}
+
+type gatewayRejection struct {
+	// message is the error the gateway rejected the snapshot with.
+	message string
+	// revision is the revision of the caches when the rejection was received. The
+	// rejection is resolved once the gateways acknowledged it.
+	revision uint64
+	// generation is the generation of the ingress the rejection was reported on,
+	// or 0 if it wasn't reported yet.
+	generation int64
+}
+
+func newGatewayRejections() *gatewayRejections {
+	return &gatewayRejections{
+		rejections: make(map[types.NamespacedName]*gatewayRejection),
+	}
+}
+
+// record remembers that the config of the given ingress was rejected at the given
+// revision of the caches.
+func (g *gatewayRejections) record(key types.NamespacedName, message string, revision uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if rejection, ok := g.rejections[key]; ok && rejection.message == message {
+		// Every gateway rejects the same snapshot, so only report it once.
+		rejection.revision = max(rejection.revision, revision)
+		return
+	}
+	g.rejections[key] = &gatewayRejection{message: message, revision: revision}
+}
+
+// get returns the rejection of the given ingress, if any. isNew is true the first
+// time the rejection is returned. Rejections reported on an older generation of
+// the ingress are dropped, as the change might have fixed them.
+func (g *gatewayRejections) get(key types.NamespacedName, generation int64) (rejection gatewayRejection, isNew bool, ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	r, ok := g.rejections[key]
+	if !ok {
+		return gatewayRejection{}, false, false
+	}
+	if r.generation != 0 && r.generation != generation {
+		delete(g.rejections, key)
+		return gatewayRejection{}, false, false
+	}
+	isNew = r.generation == 0
+	r.generation = generation
+	return *r, isNew, true
+}
+
+// clear forgets the rejection of the given ingress.
+func (g *gatewayRejections) clear(key types.NamespacedName) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.rejections, key)
+}
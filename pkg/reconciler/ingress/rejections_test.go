@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGatewayRejections(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: "name"}
+	rejections := newGatewayRejections()
+
+	_, _, ok := rejections.get(key, 1)
+	assert.Assert(t, !ok)
+
+	rejections.record(key, "route: unknown weighted cluster 'ns/svc'", 3)
+	rejection, isNew, ok := rejections.get(key, 1)
+	assert.Assert(t, ok)
+	assert.Assert(t, isNew)
+	assert.Equal(t, rejection.message, "route: unknown weighted cluster 'ns/svc'")
+	assert.Equal(t, rejection.revision, uint64(3))
+
+	// Every gateway reports the same rejection, which is only new once.
+	rejections.record(key, "route: unknown weighted cluster 'ns/svc'", 4)
+	rejection, isNew, ok = rejections.get(key, 1)
+	assert.Assert(t, ok)
+	assert.Assert(t, !isNew)
+	assert.Equal(t, rejection.revision, uint64(4))
+
+	// A different error is reported again.
+	rejections.record(key, "duplicate domain", 5)
+	_, isNew, ok = rejections.get(key, 1)
+	assert.Assert(t, ok)
+	assert.Assert(t, isNew)
+
+	// A change to the ingress drops the rejection.
+	_, _, ok = rejections.get(key, 2)
+	assert.Assert(t, !ok)
+	_, _, ok = rejections.get(key, 2)
+	assert.Assert(t, !ok)
+
+	rejections.record(key, "duplicate domain", 6)
+	rejections.clear(key)
+	_, _, ok = rejections.get(key, 2)
+	assert.Assert(t, !ok)
+}
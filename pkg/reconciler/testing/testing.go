@@ -101,3 +101,8 @@ func (l *Listers) GetConfigMapLister() corev1listers.ConfigMapLister {
 func (l *Listers) GetSecretLister() corev1listers.SecretLister {
 	return corev1listers.NewSecretLister(l.IndexerFor(&corev1.Secret{}))
 }
+
+// GetNamespaceLister get lister for K8s Namespace resource.
+func (l *Listers) GetNamespaceLister() corev1listers.NamespaceLister {
+	return corev1listers.NewNamespaceLister(l.IndexerFor(&corev1.Namespace{}))
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+)
+
+// findVirtualHost returns the virtual host serving the given host. Like in Envoy,
+// exact domains take precedence over the longest suffix wildcard ("*.example.com"),
+// which takes precedence over the longest prefix wildcard ("example.*"), which
+// takes precedence over "*".
+func findVirtualHost(vhosts []*route.VirtualHost, host string) *route.VirtualHost {
+	host = strings.ToLower(host)
+
+	var suffix, prefix, catchAll *route.VirtualHost
+	suffixLen, prefixLen := 0, 0
+	for _, vhost := range vhosts {
+		for _, domain := range vhost.GetDomains() {
+			domain = strings.ToLower(domain)
+			switch {
+			case domain == host:
+				return vhost
+			case domain == "*":
+				if catchAll == nil {
+					catchAll = vhost
+				}
+			case strings.HasPrefix(domain, "*"):
+				// The wildcard must match at least one character.
+				if len(host) > len(domain)-1 && strings.HasSuffix(host, domain[1:]) && len(domain) > suffixLen {
+					suffix, suffixLen = vhost, len(domain)
+				}
+			case strings.HasSuffix(domain, "*"):
+				if len(host) > len(domain)-1 && strings.HasPrefix(host, domain[:len(domain)-1]) && len(domain) > prefixLen {
+					prefix, prefixLen = vhost, len(domain)
+				}
+			}
+		}
+	}
+	switch {
+	case suffix != nil:
+		return suffix
+	case prefix != nil:
+		return prefix
+	default:
+		return catchAll
+	}
+}
+
+// stripPort removes the port from the given host, if any.
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// findRoute returns the first route of the virtual host matching the request.
+func findRoute(routes []*route.Route, req Request) *route.Route {
+	path, rawQuery, _ := strings.Cut(req.Path, "?")
+	query, _ := url.ParseQuery(rawQuery)
+	for _, r := range routes {
+		if routeMatches(r.GetMatch(), req, path, query) {
+			return r
+		}
+	}
+	return nil
+}
+
+func routeMatches(match *route.RouteMatch, req Request, path string, query url.Values) bool {
+	caseSensitive := match.GetCaseSensitive() == nil || match.GetCaseSensitive().GetValue()
+	fold := func(s string) string {
+		if caseSensitive {
+			return s
+		}
+		return strings.ToLower(s)
+	}
+
+	switch specifier := match.GetPathSpecifier().(type) {
+	case *route.RouteMatch_Prefix:
+		if !strings.HasPrefix(fold(path), fold(specifier.Prefix)) {
+			return false
+		}
+	case *route.RouteMatch_Path:
+		if fold(path) != fold(specifier.Path) {
+			return false
+		}
+	case *route.RouteMatch_SafeRegex:
+		if !regexMatches(specifier.SafeRegex, path) {
+			return false
+		}
+	case *route.RouteMatch_PathSeparatedPrefix:
+		prefix := fold(specifier.PathSeparatedPrefix)
+		if fold(path) != prefix && !strings.HasPrefix(fold(path), prefix+"/") {
+			return false
+		}
+	default:
+		// CONNECT requests and path match policies are not simulated.
+		return false
+	}
+
+	for _, header := range match.GetHeaders() {
+		if !headerMatches(header, req) {
+			return false
+		}
+	}
+	for _, param := range match.GetQueryParameters() {
+		if !queryParameterMatches(param, query) {
+			return false
+		}
+	}
+	return true
+}
+
+// headerMatches evaluates the header matcher against the request. Missing headers
+// only match matchers that are inverted or expect the header to be absent.
+func headerMatches(m *route.HeaderMatcher, req Request) bool {
+	value, present := headerValue(m.GetName(), req)
+	if !present {
+		if !m.GetTreatMissingHeaderAsEmpty() {
+			if specifier, ok := m.GetHeaderMatchSpecifier().(*route.HeaderMatcher_PresentMatch); ok {
+				return m.GetInvertMatch() != !specifier.PresentMatch
+			}
+			return m.GetInvertMatch()
+		}
+	}
+
+	var matches bool
+	switch specifier := m.GetHeaderMatchSpecifier().(type) {
+	case *route.HeaderMatcher_ExactMatch:
+		matches = value == specifier.ExactMatch
+	case *route.HeaderMatcher_SafeRegexMatch:
+		matches = regexMatches(specifier.SafeRegexMatch, value)
+	case *route.HeaderMatcher_RangeMatch:
+		n, err := strconv.ParseInt(value, 10, 64)
+		matches = err == nil && n >= specifier.RangeMatch.GetStart() && n < specifier.RangeMatch.GetEnd()
+	case *route.HeaderMatcher_PresentMatch:
+		matches = specifier.PresentMatch
+	case *route.HeaderMatcher_PrefixMatch:
+		matches = strings.HasPrefix(value, specifier.PrefixMatch)
+	case *route.HeaderMatcher_SuffixMatch:
+		matches = strings.HasSuffix(value, specifier.SuffixMatch)
+	case *route.HeaderMatcher_ContainsMatch:
+		matches = strings.Contains(value, specifier.ContainsMatch)
+	case *route.HeaderMatcher_StringMatch:
+		matches = stringMatches(specifier.StringMatch, value)
+	default:
+		// A matcher without specifier only checks that the header is present.
+		matches = true
+	}
+	return matches != m.GetInvertMatch()
+}
+
+// headerValue returns the value of the given header of the request. Headers with
+// several values are matched against the values joined with commas, like Envoy
+// does.
+func headerValue(name string, req Request) (string, bool) {
+	switch strings.ToLower(name) {
+	case ":authority", "host":
+		return req.Host, req.Host != ""
+	case ":path":
+		return req.Path, true
+	case ":scheme":
+		if req.SNI != "" {
+			return "https", true
+		}
+		return "http", true
+	}
+	values := req.Headers.Values(name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return strings.Join(values, ","), true
+}
+
+// queryParameterMatches evaluates the query parameter matcher against the first
+// value of the parameter.
+func queryParameterMatches(m *route.QueryParameterMatcher, query url.Values) bool {
+	values, present := query[m.GetName()]
+	if !present {
+		return false
+	}
+	switch specifier := m.GetQueryParameterMatchSpecifier().(type) {
+	case *route.QueryParameterMatcher_StringMatch:
+		return stringMatches(specifier.StringMatch, values[0])
+	case *route.QueryParameterMatcher_PresentMatch:
+		return specifier.PresentMatch
+	default:
+		return true
+	}
+}
+
+func stringMatches(m *matcher.StringMatcher, value string) bool {
+	if regex := m.GetSafeRegex(); regex != nil {
+		return regexMatches(regex, value)
+	}
+	if m.GetIgnoreCase() {
+		value = strings.ToLower(value)
+	}
+	pattern := func(s string) string {
+		if m.GetIgnoreCase() {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	switch specifier := m.GetMatchPattern().(type) {
+	case *matcher.StringMatcher_Exact:
+		return value == pattern(specifier.Exact)
+	case *matcher.StringMatcher_Prefix:
+		return strings.HasPrefix(value, pattern(specifier.Prefix))
+	case *matcher.StringMatcher_Suffix:
+		return strings.HasSuffix(value, pattern(specifier.Suffix))
+	case *matcher.StringMatcher_Contains:
+		return strings.Contains(value, pattern(specifier.Contains))
+	default:
+		return false
+	}
+}
+
+// regexMatches returns whether the regex matches the whole value. RE2 is used by
+// both Envoy and Go.
+func regexMatches(m *matcher.RegexMatcher, value string) bool {
+	re, err := regexp.Compile("^(?:" + m.GetRegex() + ")$")
+	return err == nil && re.MatchString(value)
+}
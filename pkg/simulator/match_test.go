@@ -0,0 +1,148 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"net/http"
+	"testing"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/v3/assert"
+)
+
+func TestFindVirtualHost(t *testing.T) {
+	vhosts := []*route.VirtualHost{
+		{Name: "any", Domains: []string{"*"}},
+		{Name: "prefix", Domains: []string{"foo.*"}},
+		{Name: "suffix", Domains: []string{"*.example.com"}},
+		{Name: "longer-suffix", Domains: []string{"*.foo.example.com"}},
+		{Name: "exact", Domains: []string{"foo.example.com"}},
+	}
+
+	tests := map[string]string{
+		"foo.example.com":     "exact",
+		"FOO.example.com":     "exact",
+		"bar.foo.example.com": "longer-suffix",
+		"bar.example.com":     "suffix",
+		"foo.example.org":     "prefix",
+		"example.org":         "any",
+		// Wildcards match at least one character.
+		".example.com": "any",
+		"foo.":         "any",
+	}
+	for host, want := range tests {
+		t.Run(host, func(t *testing.T) {
+			assert.Equal(t, findVirtualHost(vhosts, host).GetName(), want)
+		})
+	}
+}
+
+func TestRouteMatches(t *testing.T) {
+	exact := func(name, value string) *route.HeaderMatcher {
+		return &route.HeaderMatcher{
+			Name: name,
+			HeaderMatchSpecifier: &route.HeaderMatcher_StringMatch{
+				StringMatch: &matcher.StringMatcher{MatchPattern: &matcher.StringMatcher_Exact{Exact: value}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		match *route.RouteMatch
+		req   Request
+		want  bool
+	}{{
+		name:  "prefix",
+		match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/api"}},
+		req:   Request{Path: "/apis"},
+		want:  true,
+	}, {
+		name: "case insensitive prefix",
+		match: &route.RouteMatch{
+			PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/API"},
+			CaseSensitive: wrapperspb.Bool(false),
+		},
+		req:  Request{Path: "/api"},
+		want: true,
+	}, {
+		name:  "exact path ignores the query",
+		match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Path{Path: "/api"}},
+		req:   Request{Path: "/api?x=y"},
+		want:  true,
+	}, {
+		name:  "path separated prefix",
+		match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_PathSeparatedPrefix{PathSeparatedPrefix: "/api"}},
+		req:   Request{Path: "/apis"},
+		want:  false,
+	}, {
+		name:  "regex matches the whole path",
+		match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_SafeRegex{SafeRegex: &matcher.RegexMatcher{Regex: "/v[0-9]+"}}},
+		req:   Request{Path: "/v1/users"},
+		want:  false,
+	}, {
+		name: "header",
+		match: &route.RouteMatch{
+			PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+			Headers:       []*route.HeaderMatcher{exact("K-Tag", "v2")},
+		},
+		req:  Request{Path: "/", Headers: http.Header{"K-Tag": {"v2"}}},
+		want: true,
+	}, {
+		name: "missing header",
+		match: &route.RouteMatch{
+			PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+			Headers:       []*route.HeaderMatcher{exact("K-Tag", "v2")},
+		},
+		req:  Request{Path: "/"},
+		want: false,
+	}, {
+		name: "absent header",
+		match: &route.RouteMatch{
+			PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+			Headers: []*route.HeaderMatcher{{
+				Name:                 "K-Tag",
+				HeaderMatchSpecifier: &route.HeaderMatcher_PresentMatch{PresentMatch: true},
+				InvertMatch:          true,
+			}},
+		},
+		req:  Request{Path: "/"},
+		want: true,
+	}, {
+		name: "query parameter",
+		match: &route.RouteMatch{
+			PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+			QueryParameters: []*route.QueryParameterMatcher{{
+				Name: "version",
+				QueryParameterMatchSpecifier: &route.QueryParameterMatcher_StringMatch{
+					StringMatch: &matcher.StringMatcher{MatchPattern: &matcher.StringMatcher_Exact{Exact: "2"}},
+				},
+			}},
+		},
+		req:  Request{Path: "/?version=2"},
+		want: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &route.Route{Match: test.match}
+			assert.Equal(t, findRoute([]*route.Route{r}, test.req) != nil, test.want)
+		})
+	}
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulator evaluates how the gateways route a request with a given
+// snapshot, following the matching rules of Envoy for the subset of the config
+// Kourier generates.
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	extauthz "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ErrNoMatch is returned when the request isn't matched by the snapshot.
+var ErrNoMatch = errors.New("request not matched")
+
+// Request describes the request to simulate.
+type Request struct {
+	// Port is the port of the listener the request is sent to.
+	Port uint32
+	// SNI is the server name sent in the TLS handshake. It's empty for plain text
+	// requests.
+	SNI string
+	// Host is the value of the Host header.
+	Host string
+	// Path is the path of the request, including the query string.
+	Path string
+	// Headers are the other headers of the request.
+	Headers http.Header
+}
+
+// Result describes how a request is routed. The fields are only set as far as the
+// request was matched.
+type Result struct {
+	Listener string `json:"listener,omitempty"`
+	// ServerNames are the server names matched by the filter chain of the listener.
+	ServerNames []string `json:"serverNames,omitempty"`
+	RouteConfig string   `json:"routeConfig,omitempty"`
+	VirtualHost string   `json:"virtualHost,omitempty"`
+	Route       string   `json:"route,omitempty"`
+
+	// Clusters are the clusters the request is balanced to.
+	Clusters []WeightedCluster `json:"clusters,omitempty"`
+	// Redirect is the redirect sent instead of routing the request.
+	Redirect json.RawMessage `json:"redirect,omitempty"`
+	// DirectResponseStatus is the status sent instead of routing the request.
+	DirectResponseStatus uint32 `json:"directResponseStatus,omitempty"`
+	HostRewrite          string `json:"hostRewrite,omitempty"`
+	Timeout              string `json:"timeout,omitempty"`
+
+	// The header mutations of the route, its virtual host and its route config, in
+	// the order they're applied.
+	RequestHeadersToAdd     []HeaderMutation `json:"requestHeadersToAdd,omitempty"`
+	RequestHeadersToRemove  []string         `json:"requestHeadersToRemove,omitempty"`
+	ResponseHeadersToAdd    []HeaderMutation `json:"responseHeadersToAdd,omitempty"`
+	ResponseHeadersToRemove []string         `json:"responseHeadersToRemove,omitempty"`
+
+	// ExtAuthz is true if the request is checked by the external authorization
+	// service before being routed.
+	ExtAuthz bool `json:"extAuthz"`
+}
+
+// WeightedCluster is a cluster a request is balanced to.
+type WeightedCluster struct {
+	Name    string  `json:"name"`
+	Weight  uint32  `json:"weight"`
+	Percent float64 `json:"percent"`
+	// RequestHeadersToAdd are added to the requests sent to this cluster only.
+	RequestHeadersToAdd []HeaderMutation `json:"requestHeadersToAdd,omitempty"`
+}
+
+// HeaderMutation is a header added to a request or a response.
+type HeaderMutation struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Action string `json:"action"`
+	// Source is the level of the config the mutation comes from: "cluster",
+	// "route", "virtualHost" or "routeConfig".
+	Source string `json:"source"`
+}
+
+// Simulate returns how the gateways serving the given snapshot route the given
+// request. If the request is not matched, the error wraps ErrNoMatch and the
+// result describes the match up to where it failed.
+func Simulate(snapshot cache.ResourceSnapshot, req Request) (*Result, error) {
+	res := &Result{}
+
+	l := findListener(snapshot, req.Port)
+	if l == nil {
+		return res, fmt.Errorf("%w: no listener on port %d", ErrNoMatch, req.Port)
+	}
+	res.Listener = l.GetName()
+
+	chain := findFilterChain(l, req.SNI)
+	if chain == nil {
+		return res, fmt.Errorf("%w: no filter chain of listener %s matches SNI %q", ErrNoMatch, l.GetName(), req.SNI)
+	}
+	res.ServerNames = chain.GetFilterChainMatch().GetServerNames()
+
+	manager, err := httpConnectionManager(chain)
+	if err != nil {
+		return res, err
+	}
+	routeConfig, err := findRouteConfig(snapshot, manager)
+	if err != nil {
+		return res, err
+	}
+	res.RouteConfig = routeConfig.GetName()
+
+	host := req.Host
+	if manager.GetStripAnyHostPort() {
+		host = stripPort(host)
+	}
+	vhost := findVirtualHost(routeConfig.GetVirtualHosts(), host)
+	if vhost == nil {
+		return res, fmt.Errorf("%w: no virtual host of route config %s matches host %q", ErrNoMatch, routeConfig.GetName(), req.Host)
+	}
+	res.VirtualHost = vhost.GetName()
+
+	r := findRoute(vhost.GetRoutes(), req)
+	if r == nil {
+		return res, fmt.Errorf("%w: no route of virtual host %s matches", ErrNoMatch, vhost.GetName())
+	}
+	res.Route = r.GetName()
+
+	switch action := r.GetAction().(type) {
+	case *route.Route_Route:
+		res.Clusters = weightedClusters(action.Route)
+		res.HostRewrite = action.Route.GetHostRewriteLiteral()
+		if action.Route.GetTimeout() != nil {
+			res.Timeout = action.Route.GetTimeout().AsDuration().String()
+		}
+	case *route.Route_Redirect:
+		redirect, err := protojson.Marshal(action.Redirect)
+		if err != nil {
+			return res, err
+		}
+		// protojson randomizes its whitespace.
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, redirect); err != nil {
+			return res, err
+		}
+		res.Redirect = compacted.Bytes()
+	case *route.Route_DirectResponse:
+		res.DirectResponseStatus = action.DirectResponse.GetStatus()
+	}
+
+	levels := []struct {
+		source                            string
+		requestToAdd, responseToAdd       []*core.HeaderValueOption
+		requestToRemove, responseToRemove []string
+	}{
+		{"route", r.GetRequestHeadersToAdd(), r.GetResponseHeadersToAdd(), r.GetRequestHeadersToRemove(), r.GetResponseHeadersToRemove()},
+		{"virtualHost", vhost.GetRequestHeadersToAdd(), vhost.GetResponseHeadersToAdd(), vhost.GetRequestHeadersToRemove(), vhost.GetResponseHeadersToRemove()},
+		{"routeConfig", routeConfig.GetRequestHeadersToAdd(), routeConfig.GetResponseHeadersToAdd(), routeConfig.GetRequestHeadersToRemove(), routeConfig.GetResponseHeadersToRemove()},
+	}
+	if routeConfig.GetMostSpecificHeaderMutationsWins() {
+		// The most specific mutations are applied last, so that they win.
+		slices.Reverse(levels)
+	}
+	for _, level := range levels {
+		res.RequestHeadersToAdd = append(res.RequestHeadersToAdd, headerMutations(level.requestToAdd, level.source)...)
+		res.ResponseHeadersToAdd = append(res.ResponseHeadersToAdd, headerMutations(level.responseToAdd, level.source)...)
+		res.RequestHeadersToRemove = append(res.RequestHeadersToRemove, level.requestToRemove...)
+		res.ResponseHeadersToRemove = append(res.ResponseHeadersToRemove, level.responseToRemove...)
+	}
+
+	res.ExtAuthz, err = extAuthzEnabled(manager, r, vhost, routeConfig)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// findListener returns the listener bound to the given port.
+func findListener(snapshot cache.ResourceSnapshot, port uint32) *listener.Listener {
+	listeners := snapshot.GetResources(resource.ListenerType)
+	for _, name := range slices.Sorted(maps.Keys(listeners)) {
+		l, ok := listeners[name].(*listener.Listener)
+		if ok && l.GetAddress().GetSocketAddress().GetPortValue() == port {
+			return l
+		}
+	}
+	return nil
+}
+
+// findFilterChain returns the filter chain of the listener matching the given SNI.
+// Exact server names take precedence over the longest wildcard, which takes
+// precedence over filter chains without server names.
+func findFilterChain(l *listener.Listener, sni string) *listener.FilterChain {
+	var best *listener.FilterChain
+	bestScore := -1
+	for _, chain := range l.GetFilterChains() {
+		match := chain.GetFilterChainMatch()
+		if protocol := match.GetTransportProtocol(); (protocol == "tls" && sni == "") || (protocol == "raw_buffer" && sni != "") {
+			continue
+		}
+		score := serverNameScore(match.GetServerNames(), sni)
+		if score > bestScore {
+			best, bestScore = chain, score
+		}
+	}
+	if best == nil {
+		return l.GetDefaultFilterChain()
+	}
+	return best
+}
+
+// serverNameScore rates how specifically the given server names match the SNI: 0
+// for no server names, the length of the longest matching wildcard, or a score
+// above all wildcards for an exact match. It's -1 if no server name matches.
+func serverNameScore(serverNames []string, sni string) int {
+	if len(serverNames) == 0 {
+		return 0
+	}
+	score := -1
+	for _, name := range serverNames {
+		switch {
+		case name == sni:
+			return len(sni) + 1
+		case len(name) > 1 && name[0] == '*' && len(sni) >= len(name) && sni[len(sni)-len(name)+1:] == name[1:]:
+			score = max(score, len(name))
+		}
+	}
+	return score
+}
+
+// httpConnectionManager returns the HTTP connection manager of the filter chain.
+func httpConnectionManager(chain *listener.FilterChain) (*hcm.HttpConnectionManager, error) {
+	for _, filter := range chain.GetFilters() {
+		if filter.GetName() != wellknown.HTTPConnectionManager {
+			continue
+		}
+		manager := &hcm.HttpConnectionManager{}
+		if err := filter.GetTypedConfig().UnmarshalTo(manager); err != nil {
+			return nil, fmt.Errorf("failed to parse HTTP connection manager: %w", err)
+		}
+		return manager, nil
+	}
+	return nil, fmt.Errorf("%w: filter chain has no HTTP connection manager", ErrNoMatch)
+}
+
+// findRouteConfig returns the route config of the HTTP connection manager.
+func findRouteConfig(snapshot cache.ResourceSnapshot, manager *hcm.HttpConnectionManager) (*route.RouteConfiguration, error) {
+	if routeConfig := manager.GetRouteConfig(); routeConfig != nil {
+		return routeConfig, nil
+	}
+	name := manager.GetRds().GetRouteConfigName()
+	routeConfig, ok := snapshot.GetResources(resource.RouteType)[name].(*route.RouteConfiguration)
+	if !ok {
+		return nil, fmt.Errorf("%w: route config %q not found", ErrNoMatch, name)
+	}
+	return routeConfig, nil
+}
+
+// weightedClusters returns the clusters the route action balances requests to.
+func weightedClusters(action *route.RouteAction) []WeightedCluster {
+	if name := action.GetCluster(); name != "" {
+		return []WeightedCluster{{Name: name, Weight: 1, Percent: 100}}
+	}
+
+	var total uint32
+	for _, c := range action.GetWeightedClusters().GetClusters() {
+		total += c.GetWeight().GetValue()
+	}
+	clusters := make([]WeightedCluster, 0, len(action.GetWeightedClusters().GetClusters()))
+	for _, c := range action.GetWeightedClusters().GetClusters() {
+		weighted := WeightedCluster{
+			Name:                c.GetName(),
+			Weight:              c.GetWeight().GetValue(),
+			RequestHeadersToAdd: headerMutations(c.GetRequestHeadersToAdd(), "cluster"),
+		}
+		if total > 0 {
+			weighted.Percent = 100 * float64(weighted.Weight) / float64(total)
+		}
+		clusters = append(clusters, weighted)
+	}
+	return clusters
+}
+
+func headerMutations(headers []*core.HeaderValueOption, source string) []HeaderMutation {
+	mutations := make([]HeaderMutation, 0, len(headers))
+	for _, header := range headers {
+		mutations = append(mutations, HeaderMutation{
+			Key:    header.GetHeader().GetKey(),
+			Value:  header.GetHeader().GetValue(),
+			Action: header.GetAppendAction().String(),
+			Source: source,
+		})
+	}
+	return mutations
+}
+
+// extAuthzEnabled returns whether the external authorization filter checks the
+// requests matched by the given route. The filter can be disabled by the most
+// specific per filter config.
+func extAuthzEnabled(manager *hcm.HttpConnectionManager, r *route.Route, vhost *route.VirtualHost, routeConfig *route.RouteConfiguration) (bool, error) {
+	idx := slices.IndexFunc(manager.GetHttpFilters(), func(filter *hcm.HttpFilter) bool {
+		return filter.GetName() == wellknown.HTTPExternalAuthorization
+	})
+	if idx < 0 {
+		return false, nil
+	}
+	enabled := !manager.GetHttpFilters()[idx].GetDisabled()
+
+	for _, perFilterConfig := range []map[string]*anypb.Any{
+		r.GetTypedPerFilterConfig(), vhost.GetTypedPerFilterConfig(), routeConfig.GetTypedPerFilterConfig(),
+	} {
+		config, ok := perFilterConfig[wellknown.HTTPExternalAuthorization]
+		if !ok {
+			continue
+		}
+		perRoute := &extauthz.ExtAuthzPerRoute{}
+		if err := config.UnmarshalTo(perRoute); err != nil {
+			return false, fmt.Errorf("failed to parse ext_authz config: %w", err)
+		}
+		if perRoute.GetDisabled() {
+			return false, nil
+		}
+		if perRoute.GetCheckSettings() != nil {
+			return true, nil
+		}
+	}
+	return enabled, nil
+}
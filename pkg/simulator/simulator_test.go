@@ -0,0 +1,202 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	cachetypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"gotest.tools/v3/assert"
+	"k8s.io/apimachinery/pkg/types"
+	envoy "knative.dev/net-kourier/pkg/envoy/api"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+)
+
+func TestSimulate(t *testing.T) {
+	snapshot := newTestSnapshot(t)
+
+	tests := []struct {
+		name        string
+		req         Request
+		wantVHost   string
+		wantRoute   string
+		wantCluster map[string]float64
+		wantHeaders []HeaderMutation
+		wantAuthz   bool
+		wantErr     bool
+	}{{
+		name:        "weighted clusters",
+		req:         Request{Port: 8080, Host: "hello.example.com", Path: "/"},
+		wantVHost:   "hello",
+		wantRoute:   "default",
+		wantCluster: map[string]float64{"ns/hello-v1": 90, "ns/hello-v2": 10},
+		wantAuthz:   true,
+	}, {
+		name:        "tag header",
+		req:         Request{Port: 8080, Host: "hello.example.com:8080", Path: "/", Headers: http.Header{"K-Tag": {"v2"}}},
+		wantVHost:   "hello",
+		wantRoute:   "tag",
+		wantCluster: map[string]float64{"ns/hello-v2": 100},
+		wantHeaders: []HeaderMutation{{Key: "K-Tag-Route", Value: "v2", Action: "OVERWRITE_IF_EXISTS_OR_ADD", Source: "route"}},
+		wantAuthz:   true,
+	}, {
+		name:        "more specific path first",
+		req:         Request{Port: 8080, Host: "hello.example.com", Path: "/healthz?full=true"},
+		wantVHost:   "hello",
+		wantRoute:   "healthz",
+		wantCluster: map[string]float64{"ns/hello-v1": 100},
+	}, {
+		name:        "wildcard domain",
+		req:         Request{Port: 8080, Host: "other.example.com", Path: "/"},
+		wantVHost:   "wildcard",
+		wantRoute:   "wildcard",
+		wantCluster: map[string]float64{"ns/wildcard": 100},
+		wantAuthz:   true,
+	}, {
+		name:        "exact server name",
+		req:         Request{Port: 8443, SNI: "hello.example.com", Host: "hello.example.com", Path: "/"},
+		wantVHost:   "hello",
+		wantRoute:   "default",
+		wantCluster: map[string]float64{"ns/hello-v1": 90, "ns/hello-v2": 10},
+		wantAuthz:   true,
+	}, {
+		name:      "wildcard server name",
+		req:       Request{Port: 8443, SNI: "other.example.com", Host: "other.example.com", Path: "/"},
+		wantVHost: "wildcard",
+		wantRoute: "wildcard",
+		// The route config of the wildcard filter chain has no ext_authz filter.
+		wantCluster: map[string]float64{"ns/wildcard": 100},
+	}, {
+		name:    "unknown server name",
+		req:     Request{Port: 8443, SNI: "example.org", Host: "example.org", Path: "/"},
+		wantErr: true,
+	}, {
+		name:    "unknown port",
+		req:     Request{Port: 9090, Host: "hello.example.com", Path: "/"},
+		wantErr: true,
+	}, {
+		name:    "unknown host",
+		req:     Request{Port: 8080, Host: "example.org", Path: "/"},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := Simulate(snapshot, test.req)
+			if test.wantErr {
+				assert.Assert(t, errors.Is(err, ErrNoMatch), "err = %v", err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, res.VirtualHost, test.wantVHost)
+			assert.Equal(t, res.Route, test.wantRoute)
+			gotClusters := make(map[string]float64, len(res.Clusters))
+			for _, c := range res.Clusters {
+				gotClusters[c.Name] = c.Percent
+			}
+			assert.DeepEqual(t, gotClusters, test.wantCluster)
+			assert.DeepEqual(t, res.RequestHeadersToAdd, test.wantHeaders)
+			assert.Equal(t, res.ExtAuthz, test.wantAuthz)
+		})
+	}
+}
+
+func TestSimulateRedirect(t *testing.T) {
+	vhost := envoy.NewVirtualHost("redirect", []string{"*"}, []*route.Route{
+		envoy.NewRedirectRoute("redirect", nil, "/"),
+	})
+	manager := envoy.NewHTTPConnectionManager("redirect", &config.Kourier{})
+	l, err := envoy.NewHTTPListener(manager, 8080, false, []string{"0.0.0.0"})
+	assert.NilError(t, err)
+	snapshot, err := cache.NewSnapshot("1", map[resource.Type][]cachetypes.Resource{
+		resource.ListenerType: {l},
+		resource.RouteType:    {envoy.NewRouteConfig("redirect", []*route.VirtualHost{vhost})},
+	})
+	assert.NilError(t, err)
+
+	res, err := Simulate(snapshot, Request{Port: 8080, Host: "example.com", Path: "/"})
+	assert.NilError(t, err)
+	assert.Equal(t, string(res.Redirect), `{"httpsRedirect":true}`)
+	assert.Equal(t, len(res.Clusters), 0)
+}
+
+// newTestSnapshot returns a snapshot with an HTTP listener on port 8080 checking
+// requests with ext_authz, and an HTTPS listener on port 8443 with a filter chain
+// per server name.
+func newTestSnapshot(t *testing.T) *cache.Snapshot {
+	t.Helper()
+
+	hello := envoy.NewVirtualHost("hello", []string{"hello.example.com", "hello.example.com:*"}, []*route.Route{
+		envoy.NewRoute("tag", []*route.HeaderMatcher{{
+			Name: "K-Tag",
+			HeaderMatchSpecifier: &route.HeaderMatcher_StringMatch{
+				StringMatch: &matcher.StringMatcher{MatchPattern: &matcher.StringMatcher_Exact{Exact: "v2"}},
+			},
+		}}, "/", []*route.WeightedCluster_ClusterWeight{
+			envoy.NewWeightedCluster("ns/hello-v2", 100, nil),
+		}, 0, map[string]string{"K-Tag-Route": "v2"}, ""),
+		envoy.NewRouteExtAuthzDisabled("healthz", nil, "/healthz", []*route.WeightedCluster_ClusterWeight{
+			envoy.NewWeightedCluster("ns/hello-v1", 100, nil),
+		}, 0, nil, ""),
+		envoy.NewRoute("default", nil, "/", []*route.WeightedCluster_ClusterWeight{
+			envoy.NewWeightedCluster("ns/hello-v1", 90, nil),
+			envoy.NewWeightedCluster("ns/hello-v2", 10, nil),
+		}, 0, nil, ""),
+	})
+	wildcard := envoy.NewVirtualHost("wildcard", []string{"*.example.com"}, []*route.Route{
+		envoy.NewRoute("wildcard", nil, "/", []*route.WeightedCluster_ClusterWeight{
+			envoy.NewWeightedCluster("ns/wildcard", 100, nil),
+		}, 0, nil, ""),
+	})
+
+	withAuthz := &config.Kourier{ExternalAuthz: config.ExternalAuthz{
+		Enabled: true,
+		Config:  config.ExternalAuthzConfig{Host: "authz", Port: 9000, Protocol: "grpc"},
+	}}
+	httpListener, err := envoy.NewHTTPListener(envoy.NewHTTPConnectionManager("external", withAuthz), 8080, false, []string{"0.0.0.0"})
+	assert.NilError(t, err)
+
+	helloChain, err := envoy.NewSNIFilterChain(envoy.NewHTTPConnectionManager("external", withAuthz), &envoy.SNIMatch{
+		Hosts:      []string{"hello.example.com"},
+		CertSource: types.NamespacedName{Namespace: "ns", Name: "hello"},
+	}, withAuthz)
+	assert.NilError(t, err)
+	wildcardChain, err := envoy.NewSNIFilterChain(envoy.NewHTTPConnectionManager("wildcard", &config.Kourier{}), &envoy.SNIMatch{
+		Hosts:      []string{"*.example.com"},
+		CertSource: types.NamespacedName{Namespace: "ns", Name: "wildcard"},
+	}, withAuthz)
+	assert.NilError(t, err)
+	httpsListener, err := envoy.NewHTTPSListenerWithSNIFilterChains(8443, []*listener.FilterChain{wildcardChain, helloChain}, &config.Kourier{ListenIPAddresses: []string{"0.0.0.0"}})
+	assert.NilError(t, err)
+
+	snapshot, err := cache.NewSnapshot("1", map[resource.Type][]cachetypes.Resource{
+		resource.ListenerType: {httpListener, httpsListener},
+		resource.RouteType: {
+			envoy.NewRouteConfig("external", []*route.VirtualHost{hello, wildcard}),
+			envoy.NewRouteConfig("wildcard", []*route.VirtualHost{wildcard}),
+		},
+	})
+	assert.NilError(t, err)
+	return snapshot
+}
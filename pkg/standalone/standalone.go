@@ -0,0 +1,111 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package standalone serves the Envoy config of ingresses read from manifests to
+// gateways, without a Kubernetes cluster, and reloads it when the manifests
+// change.
+package standalone
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/net-kourier/pkg/envoy/server"
+	"knative.dev/net-kourier/pkg/generator"
+	"knative.dev/net-kourier/pkg/offline"
+	"knative.dev/pkg/logging"
+)
+
+// Watcher translates the manifests in a set of files and directories, the way
+// kourier-translate does, and publishes the snapshots to an xDS server whenever
+// the manifests change.
+type Watcher struct {
+	xdsServer *server.XdsServer
+	paths     []string
+
+	// fingerprint identifies the contents of the manifests last reloaded.
+	fingerprint string
+}
+
+// NewWatcher returns a watcher publishing the snapshots translated from the
+// manifests in the given paths to xdsServer.
+func NewWatcher(xdsServer *server.XdsServer, paths ...string) *Watcher {
+	return &Watcher{
+		xdsServer: xdsServer,
+		paths:     paths,
+	}
+}
+
+// Reload translates the manifests and publishes the snapshots, unless the
+// manifests didn't change since the last reload. It returns whether snapshots
+// were published. Rejected ingresses are left out of the published snapshots and
+// reported through the returned error.
+func (w *Watcher) Reload(ctx context.Context) (bool, error) {
+	fingerprint, err := offline.Fingerprint(w.paths...)
+	if err != nil {
+		return false, err
+	}
+	if fingerprint == w.fingerprint {
+		return false, nil
+	}
+	// Broken manifests aren't retried until they change again.
+	w.fingerprint = fingerprint
+
+	objects, err := offline.Load(w.paths...)
+	if err != nil {
+		w.xdsServer.SnapshotFailed()
+		return false, err
+	}
+	snapshots, err := offline.Translate(ctx, objects)
+	if snapshots == nil {
+		w.xdsServer.SnapshotFailed()
+		return false, err
+	}
+
+	previous := w.xdsServer.Snapshots()
+	if setErr := w.xdsServer.SetSnapshots(snapshots); setErr != nil {
+		return false, setErr
+	}
+	// The manifests are the complete config, so the snapshots are primed.
+	w.xdsServer.MarkSeeded()
+	generator.LogSnapshotDiffs(logging.FromContext(ctx), previous, snapshots)
+	return true, err
+}
+
+// Run reloads the manifests every interval until ctx is done. Failed reloads are
+// logged, and the snapshots published last keep being served meanwhile.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	logger := logging.FromContext(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		published, err := w.Reload(ctx)
+		if err != nil {
+			logger.Errorw("Failed to reload manifests", zap.Strings("paths", w.paths), zap.Error(err))
+		}
+		if published {
+			logger.Info("Reloaded manifests")
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standalone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	xds "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"gotest.tools/v3/assert"
+	"knative.dev/net-kourier/pkg/envoy/server"
+	"knative.dev/net-kourier/pkg/reconciler/ingress/config"
+	"knative.dev/pkg/system"
+)
+
+const manifests = `
+apiVersion: networking.internal.knative.dev/v1alpha1
+kind: Ingress
+metadata:
+  name: hello
+  namespace: ns
+  annotations:
+    networking.knative.dev/ingress.class: kourier.ingress.networking.knative.dev
+spec:
+  rules:
+  - hosts: [hello.example.com]
+    visibility: ExternalIP
+    http:
+      paths:
+      - splits:
+        - serviceName: hello
+          serviceNamespace: ns
+          servicePort: 80
+          percent: 100
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: hello
+  namespace: ns
+spec:
+  ports:
+  - port: 80
+    targetPort: 8080
+---
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: hello
+  namespace: ns
+subsets:
+- addresses:
+  - ip: 127.0.0.1
+  ports:
+  - port: 8080
+`
+
+func TestWatcherReload(t *testing.T) {
+	t.Setenv(system.NamespaceEnvKey, "knative-serving")
+	t.Setenv(config.GatewayNamespaceEnv, "kourier-system")
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "hello.yaml")
+	assert.NilError(t, os.WriteFile(manifest, []byte(manifests), 0o600))
+
+	xdsServer := server.NewXdsServer(ctx, 0, &xds.CallbackFuncs{})
+	watcher := NewWatcher(xdsServer, dir)
+
+	published, err := watcher.Reload(ctx)
+	assert.NilError(t, err)
+	assert.Assert(t, published)
+	snapshot := xdsServer.Snapshots()[config.DefaultGatewayFleet]
+	assert.Assert(t, snapshot != nil)
+	assert.Equal(t, len(snapshot.GetResources(resource.ClusterType)), 1)
+
+	// Nothing is published while the manifests don't change.
+	published, err = watcher.Reload(ctx)
+	assert.NilError(t, err)
+	assert.Assert(t, !published)
+
+	changed := strings.ReplaceAll(manifests, "hello.example.com", "hi.example.com")
+	assert.NilError(t, os.WriteFile(manifest, []byte(changed), 0o600))
+	published, err = watcher.Reload(ctx)
+	assert.NilError(t, err)
+	assert.Assert(t, published)
+	reloaded := xdsServer.Snapshots()[config.DefaultGatewayFleet]
+	assert.Assert(t, reloaded.GetVersion(resource.ListenerType) != snapshot.GetVersion(resource.ListenerType))
+
+	// Broken manifests keep the last snapshots served.
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("metadata: {name: broken}\n"), 0o600))
+	published, err = watcher.Reload(ctx)
+	assert.ErrorContains(t, err, "broken.yaml")
+	assert.Assert(t, !published)
+	assert.Equal(t, xdsServer.Snapshots()[config.DefaultGatewayFleet].GetVersion(resource.ListenerType), reloaded.GetVersion(resource.ListenerType))
+}